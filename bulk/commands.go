@@ -17,14 +17,9 @@ import (
 	"github.com/hexops/gotextdiff"
 	"github.com/hexops/gotextdiff/myers"
 	"github.com/hexops/gotextdiff/span"
-	"github.com/pb33f/libopenapi/datamodel/high/base"
-	"github.com/pb33f/libopenapi/datamodel/low"
-	lowbase "github.com/pb33f/libopenapi/datamodel/low/base"
-	"github.com/pb33f/libopenapi/index"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 )
 
 var afs afero.Fs = afero.NewOsFs()
@@ -79,19 +74,10 @@ func newInterpreter(expression, schemaURL string) mexpr.Interpreter {
 			cli.DecodeResponse(resp)
 			defer resp.Body.Close()
 			if body, err := io.ReadAll(resp.Body); err == nil {
-
-				var rootNode yaml.Node
-				var ls lowbase.Schema
-
-				if err := yaml.Unmarshal(body, &rootNode); err == nil {
-					if err := low.BuildModel(rootNode.Content[0], &ls); err == nil {
-						if err := ls.Build(rootNode.Content[0], index.NewSpecIndex(&rootNode)); err == nil {
-							s := base.NewSchema(&ls)
-							result := openapi.GenExample(s, 0)
-							if asMap, ok := result.(map[string]any); ok {
-								example = asMap
-							}
-						}
+				if s, err := cli.LoadSchema(body); err == nil {
+					result := openapi.GenExample(s, 0)
+					if asMap, ok := result.(map[string]any); ok {
+						example = asMap
 					}
 				}
 			}
@@ -1,17 +1,21 @@
 package cli
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/fxamacker/cbor/v2"
+	"github.com/gosimple/slug"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/text/cases"
@@ -25,9 +29,18 @@ type API struct {
 	RestishVersion string      `json:"restish_version" yaml:"restish_version"`
 	Short          string      `json:"short" yaml:"short"`
 	Long           string      `json:"long,omitempty" yaml:"long,omitempty"`
+	DocsURL        string      `json:"docs_url,omitempty" yaml:"docs_url,omitempty"`
 	Operations     []Operation `json:"operations,omitempty" yaml:"operations,omitempty"`
 	Auth           []APIAuth   `json:"auth,omitempty" yaml:"auth,omitempty"`
 	AutoConfig     AutoConfig  `json:"auto_config,omitempty" yaml:"auto_config,omitempty"`
+	Servers        []APIServer `json:"servers,omitempty" yaml:"servers,omitempty"`
+}
+
+// APIServer describes a server URL template with variables like `{region}`
+// which the user can choose values for when configuring the API.
+type APIServer struct {
+	URL       string                   `json:"url" yaml:"url"`
+	Variables map[string]AutoConfigVar `json:"variables,omitempty" yaml:"variables,omitempty"`
 }
 
 // Merge two APIs together. Takes the description if none is set and merges
@@ -41,7 +54,12 @@ func (a *API) Merge(other API) {
 		a.Long = other.Long
 	}
 
+	if a.DocsURL == "" {
+		a.DocsURL = other.DocsURL
+	}
+
 	a.Operations = append(a.Operations, other.Operations...)
+	a.Servers = append(a.Servers, other.Servers...)
 }
 
 var loaders []Loader
@@ -58,6 +76,64 @@ func AddLoader(loader Loader) {
 	loaders = append(loaders, loader)
 }
 
+// dedupeOperations makes sure generated command names and aliases are unique
+// so that imperfect API descriptions still produce a working command tree.
+// Conflicting operations get their HTTP method (and a counter if needed)
+// appended, while conflicting aliases are dropped.
+func dedupeOperations(ops []Operation) {
+	// Process in a stable order so the same operation is renamed each time,
+	// regardless of the order the loader produced them in.
+	order := make([]int, len(ops))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := ops[order[i]], ops[order[j]]
+		if a.URITemplate != b.URITemplate {
+			return a.URITemplate < b.URITemplate
+		}
+		return a.Method < b.Method
+	})
+
+	seen := map[string]bool{}
+	for _, i := range order {
+		op := &ops[i]
+		name := slug.Make(op.Name)
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			candidate := name + "-" + strings.ToLower(op.Method)
+			for n := 2; seen[candidate]; n++ {
+				candidate = fmt.Sprintf("%s-%s-%d", name, strings.ToLower(op.Method), n)
+			}
+			LogDebug("Duplicate command %s for %s %s, renamed to %s", name, op.Method, op.URITemplate, candidate)
+			op.Name = candidate
+			name = candidate
+		}
+		seen[name] = true
+	}
+
+	// Names take precedence over aliases, so check aliases afterward.
+	for _, i := range order {
+		op := &ops[i]
+		if len(op.Aliases) == 0 {
+			continue
+		}
+
+		aliases := []string{}
+		for _, alias := range op.Aliases {
+			if seen[alias] {
+				LogDebug("Duplicate command alias %s for %s, ignoring", alias, op.Name)
+				continue
+			}
+			seen[alias] = true
+			aliases = append(aliases, alias)
+		}
+		op.Aliases = aliases
+	}
+}
+
 func setupRootFromAPI(root *cobra.Command, api *API) {
 	if root.Short == "" {
 		root.Short = api.Short
@@ -67,6 +143,8 @@ func setupRootFromAPI(root *cobra.Command, api *API) {
 		root.Long = api.Long
 	}
 
+	dedupeOperations(api.Operations)
+
 	for _, op := range api.Operations {
 		if op.Group != "" && !root.ContainsGroup(op.Group) {
 			groupName := fmt.Sprintf("%s Commands:", cases.Title(language.Und, cases.NoLower).String(op.Group))
@@ -105,6 +183,57 @@ func cacheAPI(name string, api *API) {
 	}
 }
 
+// decompressSpec detects gzipped or zipped API description artifacts via
+// their magic bytes and returns the decompressed contents. For zip archives
+// the first JSON or YAML file is used. Uncompressed data is returned as-is.
+func decompressSpec(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		LogDebug("Decompressing gzipped API description")
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		LogDebug("Extracting zipped API description")
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		var found *zip.File
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(f.Name))
+			if ext == ".json" || ext == ".yaml" || ext == ".yml" {
+				found = f
+				break
+			}
+			if found == nil {
+				found = f
+			}
+		}
+
+		if found == nil {
+			return nil, fmt.Errorf("no API description found in zip archive")
+		}
+
+		rc, err := found.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	return data, nil
+}
+
 // Load will hydrate the command tree for an API, possibly refreshing the
 // API spec if the cache is out of date.
 func Load(entrypoint string, root *cobra.Command) (API, error) {
@@ -127,9 +256,25 @@ func Load(entrypoint string, root *cobra.Command) (API, error) {
 	desc := API{}
 	found := false
 
+	// Override the operation base path if requested, otherwise default to the
+	// API entrypoint.
+	opsBase := uri
+	if config != nil && config.OperationBase != "" {
+		opsBase = uri.ResolveReference(&url.URL{Path: config.OperationBase})
+	}
+	opsBaseOverride := viper.GetString("rsh-operation-base")
+	if opsBaseOverride != "" {
+		LogDebug("Using operation base %s", opsBaseOverride)
+		opsBase = uri.ResolveReference(&url.URL{Path: opsBaseOverride})
+	}
+
+	// One-off overrides via the CLI change the generated operations, so the
+	// API cache is neither used nor updated for them.
+	cacheable := opsBaseOverride == "" && viper.GetInt("rsh-expand-refs") == 0 && len(viper.GetStringSlice("rsh-server-var")) == 0
+
 	// See if there is a cache we can quickly load.
 	expires := Cache.GetTime(name + ".expires")
-	if !viper.GetBool("rsh-no-cache") && !expires.IsZero() && expires.After(time.Now()) {
+	if !viper.GetBool("rsh-no-cache") && cacheable && !expires.IsZero() && expires.After(time.Now()) {
 		var cached API
 		filename := filepath.Join(getCacheDir(), name+".cbor")
 		if data, err := os.ReadFile(filename); err == nil {
@@ -143,16 +288,26 @@ func Load(entrypoint string, root *cobra.Command) (API, error) {
 	}
 
 	fromFileOrUrl := func(uri string) ([]byte, error) {
+		var data []byte
+		var err error
 		uriLower := strings.ToLower(uri)
 		if strings.Index(uriLower, "http") == 0 {
 			resp, err := http.Get(uri)
 			if err != nil {
 				return []byte{}, err
 			}
-			return io.ReadAll(resp.Body)
+			defer resp.Body.Close()
+			data, err = io.ReadAll(resp.Body)
+			if err != nil {
+				return []byte{}, err
+			}
 		} else {
-			return os.ReadFile(os.ExpandEnv(uri))
+			data, err = os.ReadFile(os.ExpandEnv(uri))
+			if err != nil {
+				return []byte{}, err
+			}
 		}
+		return decompressSpec(data)
 	}
 	if name != "" && len(config.SpecFiles) > 0 {
 		// Load the local files
@@ -177,7 +332,7 @@ func Load(entrypoint string, root *cobra.Command) (API, error) {
 				if l.Detect(resp) {
 					found = true
 					resp.Body = io.NopCloser(bytes.NewReader(body))
-					tmp, err := load(root, *uri, *uriSpec, resp, name, l)
+					tmp, err := load(root, *opsBase, *uriSpec, resp, name, l)
 					if err != nil {
 						return API{}, err
 					}
@@ -189,49 +344,58 @@ func Load(entrypoint string, root *cobra.Command) (API, error) {
 
 		if found {
 			desc.RestishVersion = root.Version
-			cacheAPI(name, &desc)
+			if cacheable {
+				cacheAPI(name, &desc)
+			}
 			return desc, nil
 		}
 	}
 
-	LogDebug("Checking API entrypoint %s", entrypoint)
-	req, err := http.NewRequest(http.MethodGet, entrypoint, nil)
-	if err != nil {
-		return API{}, err
-	}
-
 	// We already cache the parsed API specs, no need to cache the
 	// server response.
 	// We will almost never be in a situation where we don't want to use
 	// the parsed API cache, but do want to use a cached response from
 	// the server.
 	client := &http.Client{Transport: InvalidateCachedTransport()}
-	httpResp, err := MakeRequest(req, WithClient(client), IgnoreCLIParams())
-	if err != nil {
-		return API{}, err
-	}
-	defer httpResp.Body.Close()
 
-	resp, err := ParseResponse(httpResp)
-	if err != nil {
-		return API{}, err
-	}
+	if config != nil && config.SpecURL != "" {
+		// The spec location is pinned, so skip auto-discovery entirely.
+		LogDebug("Using configured spec URL %s", config.SpecURL)
+		uris = append(uris, config.SpecURL)
+	} else {
+		LogDebug("Checking API entrypoint %s", entrypoint)
+		req, err := http.NewRequest(http.MethodGet, entrypoint, nil)
+		if err != nil {
+			return API{}, err
+		}
 
-	// Start with known link relations for API descriptions.
-	for _, l := range resp.Links["service-desc"] {
-		uris = append(uris, l.URI)
-	}
-	for _, l := range resp.Links["describedby"] {
-		uris = append(uris, l.URI)
-	}
+		httpResp, err := MakeRequest(req, WithClient(client), IgnoreCLIParams())
+		if err != nil {
+			return API{}, err
+		}
+		defer httpResp.Body.Close()
 
-	// Try hints from loaders next. These are likely places for API descriptions
-	// to be on the server, like e.g. `/openapi.json`.
-	for _, l := range loaders {
-		uris = append(uris, l.LocationHints()...)
-	}
+		resp, err := ParseResponse(httpResp)
+		if err != nil {
+			return API{}, err
+		}
 
-	uris = append(uris, uri.String())
+		// Start with known link relations for API descriptions.
+		for _, l := range resp.Links["service-desc"] {
+			uris = append(uris, l.URI)
+		}
+		for _, l := range resp.Links["describedby"] {
+			uris = append(uris, l.URI)
+		}
+
+		// Try hints from loaders next. These are likely places for API descriptions
+		// to be on the server, like e.g. `/openapi.json`.
+		for _, l := range loaders {
+			uris = append(uris, l.LocationHints()...)
+		}
+
+		uris = append(uris, uri.String())
+	}
 
 	for _, checkURI := range uris {
 		parsed, err := url.Parse(checkURI)
@@ -250,15 +414,21 @@ func Load(entrypoint string, root *cobra.Command) (API, error) {
 		if err != nil {
 			return API{}, err
 		}
+		defer resp.Body.Close()
 		if err := DecodeResponse(resp); err != nil {
 			return API{}, err
 		}
-		defer resp.Body.Close()
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return API{}, err
 		}
 
+		// Some APIs publish their description as a compressed artifact.
+		body, err = decompressSpec(body)
+		if err != nil {
+			return API{}, err
+		}
+
 		for _, l := range loaders {
 			// Reset the body
 			resp.Body = io.NopCloser(bytes.NewReader(body))
@@ -266,14 +436,8 @@ func Load(entrypoint string, root *cobra.Command) (API, error) {
 			if l.Detect(resp) {
 				resp.Body = io.NopCloser(bytes.NewReader(body))
 
-				// Override the operation base path if requested, otherwise
-				// default to the API entrypoint.
-				opsBase := uri
-				if config.OperationBase != "" {
-					opsBase = uri.ResolveReference(&url.URL{Path: config.OperationBase})
-				}
 				api, err := load(root, *opsBase, *resolved, resp, name, l)
-				if err == nil {
+				if err == nil && cacheable {
 					cacheAPI(name, &api)
 				}
 				return api, err
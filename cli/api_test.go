@@ -1,13 +1,20 @@
 package cli
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
 )
 
 type overrideLoader struct {
@@ -74,3 +81,130 @@ func TestBadSpecURL(t *testing.T) {
 	_, err := Load("https://api.example.com", &cobra.Command{})
 	assert.Error(t, err)
 }
+
+func TestLoadCompressedSpecFiles(t *testing.T) {
+	spec := []byte(`{"openapi": "3.0.0"}`)
+	dir := t.TempDir()
+
+	gzBuf := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzBuf)
+	gw.Write(spec)
+	gw.Close()
+	gzFile := filepath.Join(dir, "openapi.json.gz")
+	assert.NoError(t, os.WriteFile(gzFile, gzBuf.Bytes(), 0600))
+
+	zipBuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zipBuf)
+	readme, _ := zw.Create("README.txt")
+	readme.Write([]byte("not the spec"))
+	f, _ := zw.Create("api/openapi.json")
+	f.Write(spec)
+	zw.Close()
+	zipFile := filepath.Join(dir, "openapi.zip")
+	assert.NoError(t, os.WriteFile(zipFile, zipBuf.Bytes(), 0600))
+
+	for _, filename := range []string{gzFile, zipFile} {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			reset(false)
+			viper.Set("rsh-no-cache", true)
+
+			var loaded []byte
+			AddLoader(&overrideLoader{
+				load: func(entrypoint, spec url.URL, resp *http.Response) (API, error) {
+					loaded, _ = io.ReadAll(resp.Body)
+					return API{}, nil
+				},
+			})
+
+			configs["compressed-load-test"] = &APIConfig{
+				Base:      "https://compressed.example.com",
+				SpecFiles: []string{filename},
+			}
+
+			_, err := Load("https://compressed.example.com", &cobra.Command{})
+			assert.NoError(t, err)
+			assert.Equal(t, spec, loaded)
+		})
+	}
+}
+
+func TestLoadFromSpecURL(t *testing.T) {
+	defer gock.Off()
+
+	reset(false)
+	viper.Set("rsh-no-cache", true)
+
+	// Only the pinned spec URL is mocked, so any discovery request against the
+	// API base would fail the test.
+	gock.New("https://docs.spec-url.example.com").Get("/openapi.json").Reply(200).JSON(map[string]interface{}{
+		"openapi": "3.0.0",
+	})
+
+	loaded := ""
+	AddLoader(&overrideLoader{
+		load: func(entrypoint, spec url.URL, resp *http.Response) (API, error) {
+			loaded = spec.String()
+			return API{}, nil
+		},
+	})
+
+	configs["spec-url-test"] = &APIConfig{
+		Base:    "https://spec-url.example.com",
+		SpecURL: "https://docs.spec-url.example.com/openapi.json",
+	}
+
+	_, err := Load("https://spec-url.example.com", &cobra.Command{})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://docs.spec-url.example.com/openapi.json", loaded)
+	assert.True(t, gock.IsDone())
+}
+
+func TestLoadOperationBaseOverride(t *testing.T) {
+	reset(false)
+	viper.Set("rsh-operation-base", "/proxied")
+
+	var opsBase string
+	AddLoader(&overrideLoader{
+		load: func(entrypoint, spec url.URL, resp *http.Response) (API, error) {
+			opsBase = entrypoint.String()
+			return API{}, nil
+		},
+	})
+
+	configs["ops-base-test"] = &APIConfig{
+		Base:          "https://ops-base.example.com/api",
+		OperationBase: "/configured",
+		SpecFiles:     []string{"testdata/petstore.json"},
+	}
+
+	_, err := Load("https://ops-base.example.com/api", &cobra.Command{})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ops-base.example.com/proxied", opsBase)
+}
+
+func TestDuplicateOperationNames(t *testing.T) {
+	reset(false)
+
+	root := &cobra.Command{Use: "dupe-ops"}
+	api := &API{
+		Operations: []Operation{
+			{Name: "get-item", Aliases: []string{"item"}, Method: http.MethodGet, URITemplate: "https://api.example.com/items/{id}"},
+			{Name: "get-item", Method: http.MethodPut, URITemplate: "https://api.example.com/items/{id}"},
+			{Name: "get-item", Method: http.MethodPut, URITemplate: "https://api.example.com/other/{id}"},
+			{Name: "item", Aliases: []string{"get-item"}, Method: http.MethodGet, URITemplate: "https://api.example.com/other"},
+		},
+	}
+
+	setupRootFromAPI(root, api)
+
+	names := []string{}
+	for _, op := range api.Operations {
+		names = append(names, op.Name)
+	}
+	assert.Equal(t, []string{"get-item", "get-item-put", "get-item-put-2", "item"}, names)
+
+	// Aliases conflicting with command names are dropped.
+	assert.Empty(t, api.Operations[0].Aliases)
+	assert.Empty(t, api.Operations[3].Aliases)
+	assert.Len(t, root.Commands(), 4)
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -35,10 +36,14 @@ type TLSConfig struct {
 
 // APIProfile contains account-specific API information
 type APIProfile struct {
-	Base    string            `json:"base,omitempty" yaml:"base,omitempty"`
-	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Query   map[string]string `json:"query,omitempty" yaml:"query,omitempty"`
-	Auth    *APIAuth          `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Base       string            `json:"base,omitempty" yaml:"base,omitempty"`
+	Vars       map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Query      map[string]string `json:"query,omitempty" yaml:"query,omitempty"`
+	Auth       *APIAuth          `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Cookies    bool              `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+	Proxy      string            `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Pagination *PaginationConfig `json:"pagination,omitempty" yaml:"pagination,omitempty"`
 }
 
 // APIConfig describes per-API configuration options like the base URI and
@@ -48,8 +53,16 @@ type APIConfig struct {
 	Base          string                 `json:"base" yaml:"base"`
 	OperationBase string                 `json:"operation_base,omitempty" yaml:"operation_base,omitempty" mapstructure:"operation_base,omitempty"`
 	SpecFiles     []string               `json:"spec_files,omitempty" yaml:"spec_files,omitempty" mapstructure:"spec_files,omitempty"`
+	SpecURL       string                 `json:"spec_url,omitempty" yaml:"spec_url,omitempty" mapstructure:"spec_url,omitempty"`
+	DocsURL       string                 `json:"docs_url,omitempty" yaml:"docs_url,omitempty" mapstructure:"docs_url,omitempty"`
+	Health        []string               `json:"health,omitempty" yaml:"health,omitempty" mapstructure:"health,omitempty"`
+	Vars          map[string]string      `json:"vars,omitempty" yaml:"vars,omitempty" mapstructure:"vars,omitempty"`
+	Headers       map[string]string      `json:"headers,omitempty" yaml:"headers,omitempty" mapstructure:"headers,omitempty"`
+	Query         map[string]string      `json:"query,omitempty" yaml:"query,omitempty" mapstructure:"query,omitempty"`
 	Profiles      map[string]*APIProfile `json:"profiles,omitempty" yaml:"profiles,omitempty" mapstructure:",omitempty"`
 	TLS           *TLSConfig             `json:"tls,omitempty" yaml:"tls,omitempty" mapstructure:",omitempty"`
+	Proxy         string                 `json:"proxy,omitempty" yaml:"proxy,omitempty" mapstructure:"proxy,omitempty"`
+	Pagination    *PaginationConfig      `json:"pagination,omitempty" yaml:"pagination,omitempty" mapstructure:",omitempty"`
 }
 
 // Save the API configuration to disk.
@@ -170,24 +183,48 @@ func initAPIConfig() {
 		Run:   func(cmd *cobra.Command, args []string) { editAPIs(os.Exit) },
 	})
 
+	var deleteYes *bool
+	deleteCmd := &cobra.Command{
+		Use:     "delete short-name",
+		Aliases: []string{"rm"},
+		Short:   "Delete an API",
+		Long:    "Delete an API configuration along with its cached API description and auth tokens.",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			deleteAPI(defaultAsker{}, args[0], *deleteYes)
+		},
+	}
+	deleteYes = deleteCmd.Flags().BoolP("rsh-yes", "y", false, "Disable prompt (answer yes automatically)")
+	apiCommand.AddCommand(deleteCmd)
+
 	apiCommand.AddCommand(&cobra.Command{
 		Use:   "clear-auth-cache short-name",
 		Short: "Clear API auth token cache",
 		Long:  "Clear the API auth token cache for the current profile. This will force a re-authentication the next time you make a request.",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			apiName := args[0]
-			api := configs[apiName]
-			if api == nil {
-				panic("API " + apiName + " not found")
-			}
+			clearAuthCache(args[0])
+		},
+	})
 
-			// Remove the cache entry.
-			Cache.Set(apiName+":"+viper.GetString("rsh-profile"), "")
+	apiCommand.AddCommand(&cobra.Command{
+		Use:   "clear-cookies short-name",
+		Short: "Clear API cookie jar",
+		Long:  "Clear the saved cookies for the current profile. This will start a new session the next time you make a request.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			clearCookies(args[0])
+		},
+	})
 
-			if err := Cache.WriteConfig(); err != nil {
-				panic(fmt.Errorf("Unable to write cache file: %w", err))
-			}
+	apiCommand.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List configured APIs",
+		Long:    "List the configured APIs with their base URL and profiles. Use `-o json` or `-o yaml` for scripting.",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listAPIs(viper.GetString("rsh-output-format"))
 		},
 	})
 
@@ -257,11 +294,94 @@ func initAPIConfig() {
 					cmd.Help()
 				},
 			}
+			// Handled in `Run` once the API is loaded, registered here for help.
+			cmd.Flags().String("op", "", "Call an operation by HTTP method and path, e.g. 'GET /users/{id}', or 'list' to show all")
 			Root.AddCommand(cmd)
 		}(config)
 	}
 }
 
+// basePlaceholderRegex matches placeholders like `{env}` in base URLs.
+var basePlaceholderRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_-]*)\}`)
+
+// serverVarsFlag returns the one-off variable values passed via the
+// `rsh-server-var` flag as `name=value` pairs.
+func serverVarsFlag() map[string]string {
+	vars := map[string]string{}
+	for _, pair := range viper.GetStringSlice("rsh-server-var") {
+		if name, value, ok := strings.Cut(pair, "="); ok {
+			vars[strings.TrimSpace(name)] = value
+		}
+	}
+	return vars
+}
+
+// lookupVar returns the value of a base URL or server variable. Values come
+// from the `rsh-server-var` flag, `RSH_VAR_<NAME>` environment variables, then
+// the profile's vars, then the API's vars.
+func lookupVar(config *APIConfig, profile *APIProfile, name string) (string, bool) {
+	if v, ok := serverVarsFlag()[name]; ok {
+		return v, true
+	}
+	env := "RSH_VAR_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if v := os.Getenv(env); v != "" {
+		return v, true
+	}
+	if profile != nil {
+		if v, ok := profile.Vars[name]; ok {
+			return v, true
+		}
+	}
+	if config != nil {
+		if v, ok := config.Vars[name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// LookupVar returns the value of a variable for the API at the given
+// entrypoint, e.g. to fill in API description server variables.
+func LookupVar(entrypoint, name string) (string, bool) {
+	_, config := findAPI(entrypoint)
+	var profile *APIProfile
+	if config != nil {
+		profile = config.Profiles[viper.GetString("rsh-profile")]
+	}
+	return lookupVar(config, profile, name)
+}
+
+// expandBase fills in placeholders like `{env}` in a base URL. See
+// `lookupVar` for where values come from.
+func (a *APIConfig) expandBase(base string, profile *APIProfile) (string, error) {
+	var missing []string
+
+	expanded := basePlaceholderRegex.ReplaceAllStringFunc(base, func(match string) string {
+		if v, ok := lookupVar(a, profile, match[1:len(match)-1]); ok {
+			return v
+		}
+		missing = append(missing, match)
+		return match
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unresolved placeholder %s in base URL %s, set it in the API or profile vars, via $RSH_VAR_%s, or with --rsh-server-var", missing[0], base, strings.ToUpper(strings.ReplaceAll(missing[0][1:len(missing[0])-1], "-", "_")))
+	}
+
+	return expanded, nil
+}
+
+// baseFor returns the base URL for a profile, which may override the API's
+// base, with any placeholders filled in.
+func (a *APIConfig) baseFor(profile string) (string, error) {
+	p := a.Profiles[profile]
+	base := a.Base
+	if p != nil && p.Base != "" {
+		base = p.Base
+	}
+	return a.expandBase(base, p)
+}
+
 func findAPI(uri string) (string, *APIConfig) {
 	apiName := viper.GetString("api-name")
 
@@ -276,15 +396,11 @@ func findAPI(uri string) (string, *APIConfig) {
 			if config.Profiles[profile] == nil {
 				continue
 			}
-			if config.Profiles[profile].Base != "" {
-				if strings.HasPrefix(uri, config.Profiles[profile].Base) {
-					return name, config
-				}
-			} else if strings.HasPrefix(uri, config.Base) {
+			if base, err := config.baseFor(profile); err == nil && strings.HasPrefix(uri, base) {
 				return name, config
 			}
 		} else {
-			if strings.HasPrefix(uri, config.Base) {
+			if base, err := config.expandBase(config.Base, config.Profiles[profile]); err == nil && strings.HasPrefix(uri, base) {
 				// TODO: find the longest matching base?
 				return name, config
 			}
@@ -318,3 +434,151 @@ export EDITOR="vim"`)
 	c.Stderr = os.Stderr
 	panicOnErr(c.Run())
 }
+
+// apiSummary is a short description of a configured API used by `api list`.
+type apiSummary struct {
+	Name     string   `json:"name" yaml:"name"`
+	Base     string   `json:"base" yaml:"base"`
+	Profiles []string `json:"profiles" yaml:"profiles"`
+}
+
+// listAPIs prints the configured APIs sorted by name, either as aligned text
+// or as structured JSON/YAML.
+func listAPIs(outFormat string) {
+	summaries := []apiSummary{}
+	for name, config := range configs {
+		profiles := maps.Keys(config.Profiles)
+		sort.Strings(profiles)
+		summaries = append(summaries, apiSummary{
+			Name:     name,
+			Base:     config.Base,
+			Profiles: profiles,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	if outFormat == "json" || outFormat == "yaml" {
+		marshalled, err := MarshalShort(outFormat, true, summaries)
+		panicOnErr(err)
+		if highlighted, err := Highlight(outFormat, marshalled); err == nil {
+			marshalled = highlighted
+		}
+		Stdout.Write(marshalled)
+		return
+	}
+
+	width := 0
+	for _, s := range summaries {
+		if len(s.Name) > width {
+			width = len(s.Name)
+		}
+	}
+	for _, s := range summaries {
+		line := fmt.Sprintf("%-*s  %s", width, s.Name, s.Base)
+		if len(s.Profiles) > 0 {
+			line += " (" + strings.Join(s.Profiles, ", ") + ")"
+		}
+		fmt.Fprintln(Stdout, line)
+	}
+}
+
+// deleteAPI removes an API configuration, its cached API description, and
+// any cached auth tokens for its profiles after asking for confirmation.
+func deleteAPI(a asker, apiName string, yes bool) {
+	api := configs[apiName]
+	if api == nil {
+		panic("API " + apiName + " not found")
+	}
+
+	if !yes && !a.askConfirm("Delete API "+apiName+" ("+api.Base+")?", false, "") {
+		return
+	}
+
+	// Viper has no way to unset a key, so write the remaining APIs out via a
+	// fresh instance that hasn't read the existing file.
+	remaining := viper.New()
+	remaining.SetConfigFile(filepath.Join(viper.GetString("config-directory"), "apis.json"))
+	for k, v := range apis.AllSettings() {
+		if k != strings.ToLower(apiName) {
+			remaining.Set(k, v)
+		}
+	}
+	if err := remaining.WriteConfig(); err != nil {
+		panic(fmt.Errorf("Unable to write APIs file: %w", err))
+	}
+	apis = remaining
+
+	os.Remove(filepath.Join(getCacheDir(), apiName+".cbor"))
+	Cache.Set(apiName+".expires", "")
+	for profile := range api.Profiles {
+		clearCachedAuth(apiName + ":" + profile)
+	}
+
+	if err := Cache.WriteConfig(); err != nil {
+		panic(fmt.Errorf("Unable to write cache file: %w", err))
+	}
+
+	delete(configs, apiName)
+}
+
+// clearCachedAuth removes cached auth tokens for an `api:profile` key. Auth
+// handlers may add a suffix to the key, e.g. to cache tokens for different
+// OAuth scopes.
+func clearCachedAuth(key string) {
+	Cache.Set(key, "")
+	for k := range Cache.AllSettings() {
+		if strings.HasPrefix(k, strings.ToLower(key)+":") {
+			Cache.Set(k, "")
+		}
+	}
+}
+
+// logout revokes and clears the cached auth tokens for an API's current
+// profile, or every profile if `allProfiles` is set. Revocation failures are
+// logged but the tokens are still cleared locally.
+func logout(apiName string, allProfiles bool) {
+	api := configs[apiName]
+	if api == nil {
+		panic("API " + apiName + " not found")
+	}
+
+	profiles := []string{viper.GetString("rsh-profile")}
+	if allProfiles {
+		profiles = maps.Keys(api.Profiles)
+		sort.Strings(profiles)
+	}
+
+	for _, name := range profiles {
+		key := apiName + ":" + name
+
+		if profile := api.Profiles[name]; profile != nil && profile.Auth != nil {
+			if r, ok := authHandlers[profile.Auth.Name].(AuthRevoker); ok {
+				if err := r.RevokeAuth(key, profile.Auth.Params); err != nil {
+					LogWarning("Unable to revoke tokens for profile %s: %v", name, err)
+				}
+			}
+		}
+
+		clearCachedAuth(key)
+	}
+
+	if err := Cache.WriteConfig(); err != nil {
+		panic(fmt.Errorf("Unable to write cache file: %w", err))
+	}
+}
+
+// clearAuthCache removes any cached auth tokens for an API's current profile.
+func clearAuthCache(apiName string) {
+	api := configs[apiName]
+	if api == nil {
+		panic("API " + apiName + " not found")
+	}
+
+	clearCachedAuth(apiName + ":" + viper.GetString("rsh-profile"))
+
+	if err := Cache.WriteConfig(); err != nil {
+		panic(fmt.Errorf("Unable to write cache file: %w", err))
+	}
+}
@@ -2,8 +2,10 @@ package cli
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,10 +34,12 @@ func TestAPIClearCache(t *testing.T) {
 		Base: "https://api.example.com",
 	}
 	Cache.Set("test:default.token", "abc123")
+	Cache.Set("test:default:1a2b3c.token", "abc123")
 
 	runNoReset("api clear-auth-cache test")
 
 	assert.Equal(t, "", Cache.GetString("test:default.token"))
+	assert.Equal(t, "", Cache.GetString("test:default:1a2b3c.token"))
 }
 
 func TestAPIClearCacheProfile(t *testing.T) {
@@ -78,3 +82,121 @@ func TestEditBadCommand(t *testing.T) {
 		editAPIs(func(code int) {})
 	})
 }
+
+func TestBaseTemplate(t *testing.T) {
+	defer reset(false)
+	reset(false)
+
+	configs["base-vars"] = &APIConfig{
+		Base: "https://{env}.api.example.com/{version}",
+		Vars: map[string]string{"env": "prod", "version": "v1"},
+		Profiles: map[string]*APIProfile{
+			"default": {},
+			"staging": {Vars: map[string]string{"env": "staging"}},
+			"local":   {Base: "http://localhost:{port}", Vars: map[string]string{"port": "8000"}},
+			"broken":  {Base: "https://{region}.example.com"},
+		},
+	}
+	defer delete(configs, "base-vars")
+
+	assert.Equal(t, "https://prod.api.example.com/v1/items", fixAddress("base-vars/items"))
+
+	viper.Set("rsh-profile", "staging")
+	assert.Equal(t, "https://staging.api.example.com/v1/items", fixAddress("base-vars/items"))
+	name, _ := findAPI("https://staging.api.example.com/v1/items")
+	assert.Equal(t, "base-vars", name)
+
+	// Environment variables take precedence over the config.
+	t.Setenv("RSH_VAR_ENV", "dev")
+	assert.Equal(t, "https://dev.api.example.com/v1/items", fixAddress("base-vars/items"))
+
+	// One-off values via the flag take precedence over everything else.
+	viper.Set("rsh-server-var", []string{"env=qa", "version=v2"})
+	assert.Equal(t, "https://qa.api.example.com/v2/items", fixAddress("base-vars/items"))
+	viper.Set("rsh-server-var", []string{})
+
+	viper.Set("rsh-profile", "local")
+	assert.Equal(t, "http://localhost:8000/items", fixAddress("base-vars/items"))
+
+	viper.Set("rsh-profile", "broken")
+	_, err := configs["base-vars"].baseFor("broken")
+	assert.ErrorContains(t, err, "unresolved placeholder {region}")
+	assert.ErrorContains(t, err, "RSH_VAR_REGION")
+	assert.Panics(t, func() { fixAddress("base-vars/items") })
+}
+
+func TestAPIList(t *testing.T) {
+	defer reset(false)
+	reset(false)
+
+	configs = apiConfigs{
+		"list-a": {
+			name: "list-a",
+			Base: "https://a.example.com",
+			Profiles: map[string]*APIProfile{
+				"default": {},
+				"staging": {},
+			},
+		},
+		"list-bb": {
+			name: "list-bb",
+			Base: "https://b.example.com",
+		},
+	}
+
+	captured := runNoReset("api ls")
+	assert.Equal(t, "list-a   https://a.example.com (default, staging)\nlist-bb  https://b.example.com\n", captured)
+
+	captured = runNoReset("api list -o json")
+	assert.Contains(t, captured, `"list-a"`)
+	assert.Contains(t, captured, `"staging"`)
+}
+
+func TestAPIDelete(t *testing.T) {
+	defer reset(false)
+	reset(false)
+
+	for _, name := range []string{"delete-me", "keep-me"} {
+		configs[name] = &APIConfig{
+			name: name,
+			Base: "https://" + name + ".example.com",
+			Profiles: map[string]*APIProfile{
+				"default": {},
+				"other":   {},
+			},
+		}
+		assert.NoError(t, configs[name].Save())
+	}
+
+	cached := filepath.Join(getCacheDir(), "delete-me.cbor")
+	assert.NoError(t, os.WriteFile(cached, []byte{0xa0}, 0600))
+	Cache.Set("delete-me.expires", "2030-01-01T00:00:00Z")
+	Cache.Set("delete-me:other.token", "abc123")
+	Cache.Set("delete-me:default:1a2b3c.token", "abc123")
+	Cache.Set("keep-me:default.token", "def456")
+
+	// Declining the prompt leaves everything in place.
+	deleteAPI(&mockAsker{t: t, responses: []string{"n"}}, "delete-me", false)
+	assert.NotNil(t, configs["delete-me"])
+	assert.FileExists(t, cached)
+
+	deleteAPI(&mockAsker{t: t, responses: []string{"y"}}, "delete-me", false)
+	assert.Nil(t, configs["delete-me"])
+	assert.NoFileExists(t, cached)
+	assert.Equal(t, "", Cache.GetString("delete-me.expires"))
+	assert.Equal(t, "", Cache.GetString("delete-me:other.token"))
+	assert.Equal(t, "", Cache.GetString("delete-me:default:1a2b3c.token"))
+	assert.Equal(t, "def456", Cache.GetString("keep-me:default.token"))
+
+	// The change is persisted while other APIs are kept.
+	reset(false)
+	assert.Nil(t, configs["delete-me"])
+	assert.NotNil(t, configs["keep-me"])
+
+	captured := runNoReset("api delete keep-me -y")
+	assert.NotContains(t, captured, "not found")
+	assert.Nil(t, configs["keep-me"])
+
+	captured = runNoReset("api delete missing-api -y")
+	assert.Contains(t, captured, "API missing-api not found")
+}
@@ -10,7 +10,9 @@ import (
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/spf13/viper"
 	"golang.org/x/term"
 )
 
@@ -32,6 +34,60 @@ type AuthHandler interface {
 	OnRequest(req *http.Request, key string, params map[string]string) error
 }
 
+// AuthRefresher is an optional interface for auth handlers which cache
+// credentials that can go stale, like OAuth 2.0 tokens. When enabled, a 401
+// response causes the cached credentials to be invalidated and the request to
+// be retried once with fresh auth.
+type AuthRefresher interface {
+	// InvalidateAuth discards any cached credentials for the given key so that
+	// the next call to OnRequest fetches new ones.
+	InvalidateAuth(key string, params map[string]string) error
+}
+
+// AuthAlwaysRefresher is an optional interface for auth refreshers whose
+// credentials can be invalidated by the server at any time, like session
+// cookies. When AlwaysRefresh returns true, a 401 response refreshes auth and
+// retries even without `--rsh-auth-refresh`.
+type AuthAlwaysRefresher interface {
+	AuthRefresher
+
+	// AlwaysRefresh returns whether to refresh auth on every 401 response.
+	AlwaysRefresh() bool
+}
+
+// TokenInfo describes a cached auth token, see `AuthInspector`.
+type TokenInfo struct {
+	// Opaque is set when the token is not a JWT and the claims come from the
+	// provider's token introspection endpoint instead.
+	Opaque bool
+
+	// Header is the decoded JWT header, if any.
+	Header map[string]any
+
+	// Claims are the decoded JWT claims or the introspection response.
+	Claims map[string]any
+
+	// Expires is when the cached token expires.
+	Expires time.Time
+}
+
+// AuthInspector is an optional interface for auth handlers which cache tokens
+// that can be inspected, like OAuth 2.0 access tokens.
+type AuthInspector interface {
+	// AuthInfo describes the cached token for the given key without fetching
+	// a new one.
+	AuthInfo(key string, params map[string]string) (*TokenInfo, error)
+}
+
+// AuthRevoker is an optional interface for auth handlers which can revoke
+// cached credentials with the provider when logging out, e.g. via RFC 7009
+// OAuth 2.0 token revocation.
+type AuthRevoker interface {
+	// RevokeAuth revokes any cached credentials for the given key. They are
+	// cleared from the cache separately.
+	RevokeAuth(key string, params map[string]string) error
+}
+
 var authHandlers map[string]AuthHandler = map[string]AuthHandler{}
 
 // AddAuth registers a new named auth handler.
@@ -68,6 +124,65 @@ func (a *BasicAuth) OnRequest(req *http.Request, key string, params map[string]s
 	return nil
 }
 
+// APIKeyAuth implements API key authentication, sending a key in a header,
+// query param, or cookie.
+type APIKeyAuth struct{}
+
+// Parameters define the API key auth parameter names.
+func (a *APIKeyAuth) Parameters() []AuthParam {
+	return []AuthParam{
+		{Name: "name", Required: true, Help: "Name of the header, query param, or cookie, e.g. X-API-Key"},
+		{Name: "in", Help: "Where to send the key [header, query, cookie], defaults to header"},
+		{Name: "value", Required: true, Help: "The API key"},
+	}
+}
+
+// OnRequest gets run before the request goes out on the wire.
+func (a *APIKeyAuth) OnRequest(req *http.Request, key string, params map[string]string) error {
+	name := params["name"]
+	if name == "" {
+		return fmt.Errorf("API key auth requires a name")
+	}
+
+	value := os.ExpandEnv(params["value"])
+
+	switch params["in"] {
+	case "", "header":
+		req.Header.Set(name, value)
+	case "query":
+		query := req.URL.Query()
+		query.Set(name, value)
+		req.URL.RawQuery = query.Encode()
+	case "cookie":
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	default:
+		return fmt.Errorf("invalid API key location %s, expected one of header, query, cookie", params["in"])
+	}
+
+	return nil
+}
+
+// BearerAuth implements HTTP Bearer token authentication.
+type BearerAuth struct{}
+
+// Parameters define the HTTP Bearer auth parameter names.
+func (a *BearerAuth) Parameters() []AuthParam {
+	return []AuthParam{
+		{Name: "token", Required: true, Help: "The bearer token, may reference environment variables like $API_TOKEN"},
+	}
+}
+
+// OnRequest gets run before the request goes out on the wire.
+func (a *BearerAuth) OnRequest(req *http.Request, key string, params map[string]string) error {
+	token := os.ExpandEnv(params["token"])
+	if token == "" {
+		return fmt.Errorf("bearer auth requires a token")
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
 // ExternalToolAuth defers authentication to a third party tool.
 // This avoids baking all possible authentication implementations
 // inside restish itself.
@@ -164,3 +279,119 @@ func (a *ExternalToolAuth) OnRequest(req *http.Request, key string, params map[s
 	}
 	return nil
 }
+
+// authorize runs the configured auth handler for the API matching the given
+// URI or short name against a throwaway request and returns it, which causes
+// any tokens to be fetched and cached.
+func authorize(uri string) (*http.Request, error) {
+	addr := fixAddress(uri)
+	name, profile, err := authProfile(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, addr, nil)
+	if auth, ok := authHandlers[profile.Auth.Name]; ok {
+		err := auth.OnRequest(req, name+":"+viper.GetString("rsh-profile"), profile.Auth.Params)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return req, nil
+}
+
+// authProfile finds the API and current profile for a URI, making sure the
+// profile has auth set up.
+func authProfile(addr string) (string, *APIProfile, error) {
+	name, config := findAPI(addr)
+	if config == nil {
+		return "", nil, fmt.Errorf("no matched API for URL %s", addr)
+	}
+
+	profile := config.Profiles[viper.GetString("rsh-profile")]
+	if profile == nil {
+		return "", nil, fmt.Errorf("invalid profile %s", viper.GetString("rsh-profile"))
+	}
+
+	if profile.Auth == nil || profile.Auth.Name == "" {
+		return "", nil, fmt.Errorf("no auth set up for API")
+	}
+
+	return name, profile, nil
+}
+
+// inspectAuth describes the cached auth token for an API's current profile.
+func inspectAuth(uri string) (*TokenInfo, error) {
+	name, profile, err := authProfile(fixAddress(uri))
+	if err != nil {
+		return nil, err
+	}
+
+	inspector, ok := authHandlers[profile.Auth.Name].(AuthInspector)
+	if !ok {
+		return nil, fmt.Errorf("auth type %s has no token to inspect", profile.Auth.Name)
+	}
+
+	return inspector.AuthInfo(name+":"+viper.GetString("rsh-profile"), profile.Auth.Params)
+}
+
+// relativeTime describes a time relative to now, e.g. `in 1h5m0s`.
+func relativeTime(t time.Time) string {
+	d := time.Until(t).Round(time.Second)
+	if d < 0 {
+		return (-d).String() + " ago"
+	}
+	return "in " + d.String()
+}
+
+// formatTokenInfo renders the commonly useful parts of a token for humans.
+func formatTokenInfo(info *TokenInfo) string {
+	out := "Token: JWT"
+	if info.Opaque {
+		out = "Token: opaque (introspected)"
+	} else if alg, ok := info.Header["alg"].(string); ok {
+		out += " (" + alg + ")"
+	}
+	out += "\n"
+
+	for _, field := range []struct{ label, claim string }{
+		{"Subject", "sub"},
+		{"Issuer", "iss"},
+		{"Audience", "aud"},
+		{"Client", "client_id"},
+	} {
+		if v, ok := info.Claims[field.claim]; ok {
+			out += fmt.Sprintf("%s: %v\n", field.label, claimString(v))
+		}
+	}
+
+	// Providers use either a space separated `scope` or a `scp` list.
+	for _, claim := range []string{"scope", "scp"} {
+		if v, ok := info.Claims[claim]; ok {
+			out += fmt.Sprintf("Scopes: %s\n", claimString(v))
+			break
+		}
+	}
+
+	expires := info.Expires
+	if exp, ok := info.Claims["exp"].(float64); ok {
+		expires = time.Unix(int64(exp), 0)
+	}
+	if !expires.IsZero() {
+		out += fmt.Sprintf("Expires: %s (%s)\n", expires.Format(time.RFC3339), relativeTime(expires))
+	}
+
+	return out
+}
+
+// claimString joins list claims like `aud` or `scp` with spaces.
+func claimString(v any) string {
+	if list, ok := v.([]any); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprintf("%v", item)
+		}
+		return strings.Join(parts, " ")
+	}
+	return fmt.Sprintf("%v", v)
+}
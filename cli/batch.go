@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// BatchRequest describes a single sub-request of a `multipart/mixed` batch.
+type BatchRequest struct {
+	Method  string            `json:"method" yaml:"method"`
+	Path    string            `json:"path" yaml:"path"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    any               `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// readBatchRequests loads a list of sub-requests from a JSON or YAML file, or
+// from stdin if no filename is given.
+func readBatchRequests(filename string) ([]BatchRequest, error) {
+	var data []byte
+	var err error
+	if filename == "" || filename == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is a superset of JSON, so this handles both.
+	var requests []BatchRequest
+	if err := yaml.Unmarshal(data, &requests); err != nil {
+		return nil, err
+	}
+
+	if len(requests) == 0 {
+		return nil, errors.New("no batch requests found")
+	}
+
+	return requests, nil
+}
+
+// EncodeBatch packages sub-requests as `application/http` parts of a
+// `multipart/mixed` body, returning the content type (with boundary) and body.
+func EncodeBatch(requests []BatchRequest) (string, []byte, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	for i, r := range requests {
+		method := strings.ToUpper(r.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		headers := http.Header{}
+		for k, v := range r.Headers {
+			headers.Set(k, v)
+		}
+
+		var body []byte
+		switch b := r.Body.(type) {
+		case nil:
+		case string:
+			body = []byte(b)
+		default:
+			encoded, err := json.Marshal(makeJSONSafe(b))
+			if err != nil {
+				return "", nil, err
+			}
+			body = encoded
+			if headers.Get("Content-Type") == "" {
+				headers.Set("Content-Type", "application/json")
+			}
+		}
+		if len(body) > 0 {
+			headers.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+		}
+
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"application/http"},
+			"Content-Transfer-Encoding": {"binary"},
+			"Content-Id":                {fmt.Sprintf("%d", i+1)},
+		})
+		if err != nil {
+			return "", nil, err
+		}
+
+		fmt.Fprintf(part, "%s %s HTTP/1.1\r\n", method, r.Path)
+		if err := headers.Write(part); err != nil {
+			return "", nil, err
+		}
+		fmt.Fprint(part, "\r\n")
+		part.Write(body)
+	}
+
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return "multipart/mixed; boundary=" + w.Boundary(), buf.Bytes(), nil
+}
+
+// MultipartMixed describes `multipart/mixed` responses, e.g. from batch
+// requests. Each part is unmarshalled into an object with its headers and
+// body. Parts containing `application/http` responses also include the
+// response status.
+type MultipartMixed struct{}
+
+// Detect if the content type is multipart/mixed.
+func (m MultipartMixed) Detect(contentType string) bool {
+	return strings.HasPrefix(contentType, "multipart/mixed")
+}
+
+// Marshal is not supported, use `EncodeBatch` to build batch requests.
+func (m MultipartMixed) Marshal(value interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// Unmarshal the parts of a multipart body. The boundary is taken from the
+// first delimiter line since the content type parameters are not available.
+func (m MultipartMixed) Unmarshal(data []byte, value interface{}) error {
+	boundary := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "--") {
+			boundary = strings.TrimPrefix(line, "--")
+			break
+		}
+	}
+	if boundary == "" {
+		return errors.New("no multipart boundary found")
+	}
+
+	parts := []any{}
+	r := multipart.NewReader(bytes.NewReader(data), boundary)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		item, err := unmarshalPart(p)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, item)
+	}
+
+	if v, ok := value.(*interface{}); ok {
+		*v = parts
+		return nil
+	}
+
+	return fmt.Errorf("cannot unmarshal multipart into %T", value)
+}
+
+// unmarshalPart converts a single multipart part into a map, parsing nested
+// HTTP responses and structured bodies where possible.
+func unmarshalPart(p *multipart.Part) (map[string]any, error) {
+	defer p.Close()
+
+	ct := p.Header.Get("Content-Type")
+	headers := map[string]any{}
+	var data []byte
+
+	if mt, _, _ := mime.ParseMediaType(ct); mt == "application/http" {
+		resp, err := http.ReadResponse(bufio.NewReader(p), nil)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+
+		for k, v := range resp.Header {
+			headers[k] = strings.Join(v, ", ")
+		}
+
+		return map[string]any{
+			"status":  resp.StatusCode,
+			"headers": headers,
+			"body":    unmarshalPartBody(resp.Header.Get("Content-Type"), data),
+		}, nil
+	}
+
+	data, err := io.ReadAll(p)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range p.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	return map[string]any{
+		"headers": headers,
+		"body":    unmarshalPartBody(ct, data),
+	}, nil
+}
+
+// unmarshalPartBody decodes a part body using the registered content types,
+// falling back to the raw bytes.
+func unmarshalPartBody(ct string, data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var parsed any
+	if err := Unmarshal(ct, data, &parsed); err != nil {
+		return data
+	}
+	return parsed
+}
+
+// batch sends a list of sub-requests as a single `multipart/mixed` request.
+func batch(addr, filename string) {
+	requests, err := readBatchRequests(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	ct, body, err := EncodeBatch(requests)
+	if err != nil {
+		panic(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, fixAddress(addr), bytes.NewReader(body))
+	req.Header.Set("Content-Type", ct)
+	MakeRequestAndFormat(req)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestBatch(t *testing.T) {
+	defer gock.Off()
+
+	filename := filepath.Join(t.TempDir(), "requests.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(`
+- method: get
+  path: /items/1
+- method: post
+  path: /items
+  body:
+    name: foo
+`), 0600))
+
+	gock.New("http://batch.example.com").
+		Post("/batch").
+		MatchHeader("Content-Type", "^multipart/mixed; boundary=").
+		AddMatcher(func(req *http.Request, ereq *gock.Request) (bool, error) {
+			b, _ := io.ReadAll(req.Body)
+			body := string(b)
+			return strings.Contains(body, "GET /items/1 HTTP/1.1") &&
+				strings.Contains(body, "POST /items HTTP/1.1") &&
+				strings.Contains(body, `{"name":"foo"}`), nil
+		}).
+		Reply(200).
+		SetHeader("Content-Type", "multipart/mixed; boundary=resp").
+		BodyString("--resp\r\n" +
+			"Content-Type: application/http\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"id\": 1}\r\n" +
+			"--resp\r\n" +
+			"Content-Type: application/http\r\n\r\n" +
+			"HTTP/1.1 201 Created\r\n\r\n\r\n" +
+			"--resp--\r\n")
+
+	captured := run("batch http://batch.example.com/batch " + filename + " -f body[].status -o json")
+	assert.JSONEq(t, "[200, 201]", captured)
+}
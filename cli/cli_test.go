@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -26,6 +28,7 @@ func reset(color bool) {
 	// Most tests are easier to write without retries.
 	viper.Set("rsh-retry", 0)
 
+	resetInterrupt()
 	Init("test", "1.0.0'")
 	Defaults()
 }
@@ -113,6 +116,31 @@ func TestIgnoreStatusCodeExit(t *testing.T) {
 	expectExitCode(t, 0)
 }
 
+func TestQuietOnSuccess(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/foo").Reply(200).JSON(map[string]interface{}{
+		"Hello": "World",
+	})
+
+	captured := run("http://example.com/foo --rsh-quiet-on-success")
+	assert.Equal(t, "", captured)
+	expectExitCode(t, 0)
+}
+
+func TestQuietOnSuccessError(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/foo").Reply(404).JSON(map[string]interface{}{
+		"detail": "Not found",
+	})
+
+	expectJSON(t, "http://example.com/foo --rsh-quiet-on-success", `{
+		"detail": "Not found"
+	}`)
+	expectExitCode(t, 4)
+}
+
 func TestHeaderWithComma(t *testing.T) {
 	defer gock.Off()
 
@@ -122,6 +150,123 @@ func TestHeaderWithComma(t *testing.T) {
 	assert.Contains(t, out, "204 No Content")
 }
 
+func TestNoDefaultAcceptEncoding(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return req.Header.Get("Accept-Encoding") == "identity", nil
+		}).
+		Reply(204)
+
+	out := run("http://example.com/ --rsh-no-default-accept-encoding")
+	assert.Contains(t, out, "204 No Content")
+}
+
+func TestAcceptOverride(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return req.Header.Values("Accept")[0] == "application/cbor,application/vnd.api+json;q=0.5", nil
+		}).
+		Reply(204)
+
+	// Short names are expanded and the flag wins over `-H`.
+	out := run("http://example.com/ -H Accept:text/plain --rsh-accept cbor,application/vnd.api+json;q=0.5")
+	assert.Contains(t, out, "204 No Content")
+
+	out = run("http://example.com/ --rsh-accept bogus")
+	assert.Contains(t, out, `invalid accept media type "bogus"`)
+}
+
+func TestFilterFile(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "names.query")
+	assert.NoError(t, os.WriteFile(filename, []byte("body.items[].name\n"), 0o600))
+
+	gock.New("http://example.com").Get("/items").
+		Reply(200).
+		JSON(map[string]any{"items": []map[string]any{{"name": "one"}, {"name": "two"}}})
+
+	out := run("-o json --rsh-filter-file " + filename + " http://example.com/items")
+	assert.JSONEq(t, `["one", "two"]`, out)
+
+	out = run("-f body --rsh-filter-file " + filename + " http://example.com/items")
+	assert.Contains(t, out, "--rsh-filter and --rsh-filter-file cannot be used together")
+
+	out = run("--rsh-filter-file " + filename + ".missing http://example.com/items")
+	assert.Contains(t, out, "unable to read filter file")
+}
+
+func TestFilterLang(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/items").
+		Times(2).
+		Reply(200).
+		SetHeader("X-Total", "2").
+		JSON(map[string]any{"items": []map[string]any{{"name": "one"}, {"name": "two"}}})
+
+	out := run("-o json --rsh-filter-lang jmespath -f {names:body.items[*].name,total:headers.\"X-Total\"} http://example.com/items")
+	assert.JSONEq(t, `{"names": ["one", "two"], "total": "2"}`, out)
+
+	out = run("-o json -f body.items[].name http://example.com/items")
+	assert.JSONEq(t, `["one", "two"]`, out)
+
+	out = run("--rsh-filter-lang jq -f .items http://example.com/items")
+	assert.Contains(t, out, `unknown filter language "jq"`)
+}
+
+func TestFields(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/repos").
+		Reply(200).
+		JSON([]map[string]any{
+			{"id": 1, "name": "one", "owner": map[string]any{"login": "a"}, "private": false},
+			{"id": 2, "name": "two", "owner": map[string]any{"login": "b"}, "private": true},
+		})
+
+	// Each item is projected, with flat names for nested fields.
+	out := run("-o csv --rsh-fields id,owner.login http://example.com/repos")
+	assert.Equal(t, "id,owner_login\r\n1,a\r\n2,b\r\n", out)
+
+	// A single object is projected too, regardless of the filter language.
+	gock.New("http://example.com").Get("/user").
+		Reply(200).
+		JSON(map[string]any{"id": 1, "name": "one", "private": false})
+
+	out = run("-o json --rsh-filter-lang jmespath --rsh-fields name,private http://example.com/user")
+	assert.JSONEq(t, `{"name": "one", "private": false}`, out)
+
+	out = run("-f body --rsh-fields id http://example.com/repos")
+	assert.Contains(t, out, "--rsh-fields cannot be used with --rsh-filter")
+
+	out = run("--rsh-fields id|name http://example.com/repos")
+	assert.Contains(t, out, `invalid field "id|name"`)
+}
+
+func TestNoBody(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Delete("/items/1").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return req.Body == nil && req.Header.Get("Content-Type") == "", nil
+		}).
+		Reply(204)
+
+	out := run("delete http://example.com/items/1 foo: 1 -H Content-Type:application/json --rsh-no-body")
+	assert.Contains(t, out, "204 No Content")
+}
+
 type TestAuth struct{}
 
 // Parameters returns a list of OAuth2 Authorization Code inputs.
@@ -149,6 +294,12 @@ func TestAuthHeader(t *testing.T) {
 					Name: "test-auth",
 				},
 			},
+			"bearer": {
+				Auth: &APIAuth{
+					Name:   "http-bearer",
+					Params: map[string]string{"token": "def456"},
+				},
+			},
 			"no-auth": {},
 		},
 	}
@@ -159,6 +310,9 @@ func TestAuthHeader(t *testing.T) {
 	captured = runNoReset("auth-header test-auth-header")
 	assert.Equal(t, "abc123\n", captured)
 
+	captured = runNoReset("auth-header test-auth-header -p bearer")
+	assert.Equal(t, "Bearer def456\n", captured)
+
 	captured = runNoReset("auth-header test-auth-header -p bad")
 	assert.Contains(t, captured, "invalid profile bad")
 
@@ -166,6 +320,82 @@ func TestAuthHeader(t *testing.T) {
 	assert.Contains(t, captured, "no auth set up")
 }
 
+func TestLoginLogout(t *testing.T) {
+	reset(false)
+
+	AddAuth("test-auth", &TestAuth{})
+
+	configs["test-login"] = &APIConfig{
+		name: "test-login",
+		Base: "https://login-test.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Auth: &APIAuth{
+					Name: "test-auth",
+				},
+			},
+		},
+	}
+
+	captured := runNoReset("login test-login")
+	assert.Contains(t, captured, "Logged in to test-login")
+
+	captured = runNoReset("login bad-api")
+	assert.Contains(t, captured, "no matched API")
+
+	Cache.Set("test-login:default.token", "abc123")
+	runNoReset("logout test-login")
+	assert.Equal(t, "", Cache.GetString("test-login:default.token"))
+}
+
+// revokingAuth records which keys were revoked on logout.
+type revokingAuth struct {
+	TestAuth
+	revoked []string
+}
+
+func (h *revokingAuth) RevokeAuth(key string, params map[string]string) error {
+	h.revoked = append(h.revoked, key)
+	if params["fail"] != "" {
+		return fmt.Errorf("revocation failed")
+	}
+	return nil
+}
+
+func TestLogoutRevoke(t *testing.T) {
+	reset(false)
+
+	auth := &revokingAuth{}
+	AddAuth("test-revoke", auth)
+
+	configs["test-revoke"] = &APIConfig{
+		name: "test-revoke",
+		Base: "https://revoke-test.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {Auth: &APIAuth{Name: "test-revoke"}},
+			"other":   {Auth: &APIAuth{Name: "test-revoke", Params: map[string]string{"fail": "1"}}},
+			"none":    {},
+		},
+	}
+
+	Cache.Set("test-revoke:default:abc.token", "abc123")
+	Cache.Set("test-revoke:default:abc.refresh", "ref123")
+	Cache.Set("test-revoke:other:def.token", "def456")
+
+	runNoReset("auth-logout test-revoke")
+	assert.Equal(t, []string{"test-revoke:default"}, auth.revoked)
+	assert.Equal(t, "", Cache.GetString("test-revoke:default:abc.token"))
+	assert.Equal(t, "", Cache.GetString("test-revoke:default:abc.refresh"))
+	assert.Equal(t, "def456", Cache.GetString("test-revoke:other:def.token"))
+
+	// Failing to revoke still clears the local tokens.
+	auth.revoked = nil
+	captured := runNoReset("logout test-revoke --all-profiles")
+	assert.Equal(t, []string{"test-revoke:default", "test-revoke:other"}, auth.revoked)
+	assert.Contains(t, captured, "Unable to revoke tokens for profile other: revocation failed")
+	assert.Equal(t, "", Cache.GetString("test-revoke:other:def.token"))
+}
+
 func TestLinks(t *testing.T) {
 	defer gock.Off()
 
@@ -378,3 +608,118 @@ func TestCompletion(t *testing.T) {
 		"api.example.com/items/my-item/tags/{tag-id}\tGet tag details",
 	}, possible)
 }
+
+func TestOpenDocs(t *testing.T) {
+	defer gock.Off()
+	defer func(orig func(string) error) { OpenBrowser = orig }(OpenBrowser)
+
+	opened := ""
+	OpenBrowser = func(url string) error {
+		opened = url
+		return nil
+	}
+
+	gock.New("https://open-test.example.com/").Reply(404)
+	gock.New("https://open-test.example.com/openapi.json").Reply(200).JSON(map[string]interface{}{})
+
+	reset(false)
+	configs["open-test"] = &APIConfig{
+		name: "open-test",
+		Base: "https://open-test.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {},
+		},
+	}
+	configs["open-test-config"] = &APIConfig{
+		name:    "open-test-config",
+		Base:    "https://open-test-config.example.com",
+		DocsURL: "https://example.com/configured-docs",
+		Profiles: map[string]*APIProfile{
+			"default": {},
+		},
+	}
+	AddLoader(&testLoader{
+		API: API{
+			Short:   "Open Test API",
+			DocsURL: "https://example.com/docs",
+		},
+	})
+	viper.Set("rsh-no-cache", true)
+
+	runNoReset("open open-test")
+	assert.Equal(t, "https://example.com/docs", opened)
+
+	runNoReset("open open-test-config")
+	assert.Equal(t, "https://example.com/configured-docs", opened)
+
+	runNoReset("open https://example.com/other")
+	assert.Equal(t, "https://example.com/other", opened)
+
+	// Opening the browser fails, so the URL is printed instead.
+	OpenBrowser = func(url string) error {
+		return errors.New("no browser")
+	}
+	captured := runNoReset("open open-test-config")
+	assert.Contains(t, captured, "https://example.com/configured-docs")
+}
+
+func TestHealth(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	reset(false)
+	configs["health-test"] = &APIConfig{
+		name: "health-test",
+		Base: "https://health.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {},
+			"staging": {Base: "https://staging.health.example.com"},
+		},
+	}
+	defer delete(configs, "health-test")
+
+	gock.New("https://health.example.com").Get("/health").Reply(200)
+	gock.New("https://staging.health.example.com").Get("/health").Reply(200)
+
+	captured := runNoReset("health health-test -o json")
+	assert.Contains(t, captured, `"url": "https://health.example.com/health"`)
+	assert.Contains(t, captured, `"url": "https://staging.health.example.com/health"`)
+	assert.NotContains(t, captured, "down")
+	assert.NotContains(t, captured, "failed")
+
+	configs["health-test"].Health = []string{"/status", "https://other.example.com/ping"}
+
+	gock.New("https://health.example.com").Get("/status").Reply(200)
+	gock.New("https://other.example.com").Get("/ping").Times(2).Reply(503)
+	gock.New("https://staging.health.example.com").Get("/status").Reply(200)
+
+	captured = runNoReset("health health-test")
+	assert.Contains(t, captured, "https://health.example.com/status")
+	assert.Contains(t, captured, "down")
+	assert.Contains(t, captured, "2 of 4 health checks failed")
+}
+
+func TestFormatTokenInfo(t *testing.T) {
+	exp := time.Now().Add(time.Hour)
+	out := formatTokenInfo(&TokenInfo{
+		Header: map[string]any{"alg": "RS256"},
+		Claims: map[string]any{
+			"sub": "user1",
+			"iss": "https://auth.example.com/",
+			"aud": []any{"api1", "api2"},
+			"scp": []any{"read", "write"},
+			"exp": float64(exp.Unix()),
+		},
+	})
+	assert.Contains(t, out, "Token: JWT (RS256)\nSubject: user1\nIssuer: https://auth.example.com/\nAudience: api1 api2\nScopes: read write\nExpires: "+time.Unix(exp.Unix(), 0).Format(time.RFC3339))
+	assert.Regexp(t, `\(in (59m59s|1h0m0s)\)\n$`, out)
+
+	// Opaque tokens fall back to the cached expiration.
+	out = formatTokenInfo(&TokenInfo{
+		Opaque:  true,
+		Claims:  map[string]any{"active": true, "client_id": "id", "scope": "read"},
+		Expires: time.Now().Add(-5 * time.Minute),
+	})
+	assert.Contains(t, out, "Token: opaque (introspected)\nClient: id\nScopes: read\n")
+	assert.Contains(t, out, "(5m0s ago)")
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// minCompressSize is the smallest request body worth compressing. Below this
+// the encoding overhead can make the body larger.
+const minCompressSize = 1024
+
+// compressBody compresses the request body with the named content encoding,
+// e.g. `gzip` or `br`, and sets the `Content-Encoding` header. Small bodies
+// and bodies which are already encoded are left as-is. The uncompressed body
+// is returned if compression was applied so it can be sent again.
+func compressBody(req *http.Request, name string) ([]byte, error) {
+	encoder, ok := encodings[name].(ContentEncoder)
+	if !ok {
+		return nil, fmt.Errorf("unsupported request compression %s", name)
+	}
+
+	if req.Body == nil || req.Header.Get("Content-Encoding") != "" {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+
+	if len(body) < minCompressSize {
+		LogDebug("Not compressing small request body (%d bytes)", len(body))
+		setBody(req, body)
+		return nil, nil
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := encoder.Writer(buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	LogDebug("Compressed request body with %s from %d to %d bytes", name, len(body), buf.Len())
+	setBody(req, buf.Bytes())
+	req.Header.Set("Content-Encoding", name)
+
+	return body, nil
+}
+
+// setBody replaces the request body with a replayable in-memory body.
+func setBody(req *http.Request, body []byte) {
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+}
+
+// rejectsEncoding returns whether a response indicates the server does not
+// support the request's content encoding. Per RFC 7694 such servers respond
+// with `415 Unsupported Media Type` and list what they do accept in an
+// `Accept-Encoding` response header.
+func rejectsEncoding(resp *http.Response, name string) bool {
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		return false
+	}
+
+	for _, v := range resp.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(strings.SplitN(enc, ";", 2)[0]), name) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestCompressBody(t *testing.T) {
+	reset(false)
+	large := strings.Repeat("hello world ", 200)
+
+	for _, name := range []string{"gzip", "br", "zstd", "deflate"} {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(large))
+			uncompressed, err := compressBody(req, name)
+			assert.NoError(t, err)
+			assert.Equal(t, large, string(uncompressed))
+			assert.Equal(t, name, req.Header.Get("Content-Encoding"))
+			assert.Less(t, req.ContentLength, int64(len(large)))
+
+			// Decoding the body gives back the original.
+			resp := &http.Response{Header: req.Header, Body: req.Body}
+			assert.NoError(t, DecodeResponse(resp))
+			decoded, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, large, string(decoded))
+		})
+	}
+
+	// Small bodies aren't worth compressing.
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("small"))
+	uncompressed, err := compressBody(req, "gzip")
+	assert.NoError(t, err)
+	assert.Nil(t, uncompressed)
+	assert.Empty(t, req.Header.Get("Content-Encoding"))
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "small", string(body))
+
+	_, err = compressBody(req, "bogus")
+	assert.ErrorContains(t, err, "unsupported request compression bogus")
+}
+
+func TestCompressSafe(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	large := strings.Repeat("a", 2048)
+
+	gock.New("http://example.com").Post("/").
+		MatchHeader("Content-Encoding", "br").
+		Reply(http.StatusUnsupportedMediaType).
+		SetHeader("Accept-Encoding", "gzip")
+
+	gock.New("http://example.com").Post("/").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			b, _ := io.ReadAll(req.Body)
+			return req.Header.Get("Content-Encoding") == "" && string(b) == large, nil
+		}).
+		Reply(http.StatusNoContent)
+
+	reset(false)
+	viper.Set("rsh-compress", "br")
+	viper.Set("rsh-compress-safe", true)
+	Stderr = &strings.Builder{}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/", bytes.NewReader([]byte(large)))
+	resp, err := MakeRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Contains(t, Stderr.(*strings.Builder).String(), "Server does not accept br request bodies")
+	assert.True(t, gock.IsDone())
+}
+
+func TestCompressSigned(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	large := strings.Repeat("a", 2048)
+
+	// The signature covers the compressed body which is actually sent.
+	gock.New("http://example.com").Post("/").
+		MatchHeader("Content-Encoding", "gzip").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return sigV4Valid(req), nil
+		}).
+		Reply(http.StatusUnsupportedMediaType)
+
+	// Resending uncompressed signs the request again.
+	gock.New("http://example.com").Post("/").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return req.Header.Get("Content-Encoding") == "" && sigV4Valid(req), nil
+		}).
+		Reply(http.StatusNoContent)
+
+	reset(false)
+	withSigV4API(t)
+	viper.Set("rsh-compress", "gzip")
+	viper.Set("rsh-compress-safe", true)
+	Stderr = &strings.Builder{}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/", bytes.NewReader([]byte(large)))
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := MakeRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.True(t, gock.IsDone())
+}
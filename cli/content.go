@@ -2,9 +2,11 @@ package cli
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"reflect"
 	"sort"
 	"strings"
@@ -12,8 +14,12 @@ import (
 	"github.com/alexeyco/simpletable"
 	"github.com/amzn/ion-go/ion"
 	"github.com/fxamacker/cbor/v2"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/shamaton/msgpack/v2"
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
 	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 // ContentType is used to marshal/unmarshal data to various formats.
@@ -63,12 +69,68 @@ func buildAcceptHeader() string {
 	return strings.Join(accept, ",")
 }
 
+// normalizeAccept validates a user-provided `Accept` header value, expanding
+// registered short names like `json` or `cbor` to their media types. Multiple
+// comma-separated media types with parameters like `q=0.5` are allowed.
+func normalizeAccept(value string) (string, error) {
+	accept := []string{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if entry, ok := contentTypes[part]; ok && entry.name != "" {
+			part = entry.name
+		}
+
+		if _, _, err := mime.ParseMediaType(part); err != nil || !strings.Contains(part, "/") {
+			return "", fmt.Errorf("invalid accept media type %q", part)
+		}
+
+		accept = append(accept, part)
+	}
+
+	if len(accept) == 0 {
+		return "", errors.New("empty accept header")
+	}
+
+	return strings.Join(accept, ","), nil
+}
+
+// sortedContentTypes returns the registered short names ordered by q factor
+// descending, so specific formats come before generic ones. Ties go to the
+// first short name so the order is stable.
+func sortedContentTypes() []string {
+	names := maps.Keys(contentTypes)
+	sort.Slice(names, func(i, j int) bool {
+		a, b := contentTypes[names[i]], contentTypes[names[j]]
+		if a.q != b.q {
+			return a.q > b.q
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// detectContentType returns the short name & registered content type which
+// handles the given media type. When several match, e.g. `text/xml` is both
+// XML and text, the highest q factor wins.
+func detectContentType(contentType string) (string, contentTypeEntry, bool) {
+	for _, name := range sortedContentTypes() {
+		if entry := contentTypes[name]; entry.ct.Detect(contentType) {
+			return name, entry, true
+		}
+	}
+
+	return "", contentTypeEntry{}, false
+}
+
 // Marshal a value to the given content type, e.g. `application/json`.
 func Marshal(contentType string, value interface{}) ([]byte, error) {
-	for _, entry := range contentTypes {
-		if entry.ct.Detect(contentType) {
-			return entry.ct.Marshal(value)
-		}
+	if _, entry, ok := detectContentType(contentType); ok {
+		return entry.ct.Marshal(value)
 	}
 
 	return nil, fmt.Errorf("cannot marshal %s", contentType)
@@ -102,11 +164,9 @@ func MarshalShort(name string, pretty bool, value any) ([]byte, error) {
 
 // Unmarshal raw data from the given content type into a value.
 func Unmarshal(contentType string, data []byte, value interface{}) error {
-	for _, entry := range contentTypes {
-		if entry.ct.Detect(contentType) {
-			LogDebug("Unmarshalling from %s", entry.name)
-			return entry.ct.Unmarshal(data, value)
-		}
+	if _, entry, ok := detectContentType(contentType); ok {
+		LogDebug("Unmarshalling from %s", entry.name)
+		return entry.ct.Unmarshal(data, value)
 	}
 
 	return fmt.Errorf("cannot unmarshal %s", contentType)
@@ -190,6 +250,95 @@ func (t Table) Unmarshal(data []byte, value interface{}) error {
 	return fmt.Errorf("unimplemented")
 }
 
+// objectRows returns the items as objects along with the sorted union of their
+// keys, for formats which show an array of objects as rows & columns.
+func objectRows(data []any) ([]map[string]any, []string, error) {
+	rows := make([]map[string]any, 0, len(data))
+	seen := map[string]bool{}
+	headers := []string{}
+
+	for _, item := range data {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected an array of objects but found %T", item)
+		}
+		rows = append(rows, m)
+
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				headers = append(headers, k)
+			}
+		}
+	}
+	sort.Strings(headers)
+
+	return rows, headers, nil
+}
+
+// CSV describes an RFC 4180 CSV output format for arrays of objects, e.g. for
+// loading into spreadsheets.
+type CSV struct{}
+
+// Detect if the content type is CSV.
+func (c CSV) Detect(contentType string) bool {
+	return false
+}
+
+// csvCell returns the text for a cell. Nested values are JSON-encoded.
+func csvCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// Marshal the value to CSV with a header row.
+func (c CSV) Marshal(value interface{}) ([]byte, error) {
+	d, ok := makeJSONSafe(value).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("error building CSV. Must be array of objects")
+	}
+
+	rows, headers, err := objectRows(d)
+	if err != nil {
+		return nil, fmt.Errorf("error building CSV: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.UseCRLF = true
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(headers))
+		for i, h := range headers {
+			if record[i], err = csvCell(row[h]); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// Unmarshal the value from a CSV string.
+func (c CSV) Unmarshal(data []byte, value interface{}) error {
+	return fmt.Errorf("unimplemented")
+}
+
 // Only applicable to collection of repeating objects.
 // Filter down to a collection of objects first then apply the table output.
 // Simpletable has much more styling that can be applied.
@@ -340,6 +489,83 @@ func (y YAML) Marshal(value interface{}) ([]byte, error) {
 	return yaml.Marshal(value)
 }
 
+// MarshalPretty the value to encoded YAML for output. The default is block
+// style, which can be customized via the `rsh-yaml-*` options. These only
+// apply to output, never to request bodies.
+func (y YAML) MarshalPretty(value interface{}) ([]byte, error) {
+	flow := viper.GetBool("rsh-yaml-flow")
+	strs := viper.GetString("rsh-yaml-strings")
+	indent := viper.GetInt("rsh-yaml-indent")
+
+	if !flow && strs == "" && indent == 0 {
+		return yaml.Marshal(value)
+	}
+
+	var strStyle yamlv3.Style
+	switch strs {
+	case "":
+	case "literal":
+		strStyle = yamlv3.LiteralStyle
+	case "folded":
+		strStyle = yamlv3.FoldedStyle
+	case "quoted":
+		strStyle = yamlv3.DoubleQuotedStyle
+	default:
+		return nil, fmt.Errorf("unknown YAML string style %s, expected one of [literal, folded, quoted]", strs)
+	}
+
+	node := &yamlv3.Node{}
+	if err := node.Encode(makeJSONSafe(value)); err != nil {
+		return nil, err
+	}
+	styleYAML(node, flow, strStyle)
+
+	if indent == 0 {
+		indent = 2
+	}
+
+	buf := &bytes.Buffer{}
+	enc := yamlv3.NewEncoder(buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// styleYAML recursively sets the output style of YAML nodes. Literal and
+// folded string styles only apply to multi-line strings.
+func styleYAML(node *yamlv3.Node, flow bool, strStyle yamlv3.Style) {
+	switch node.Kind {
+	case yamlv3.MappingNode, yamlv3.SequenceNode:
+		if flow {
+			node.Style |= yamlv3.FlowStyle
+		}
+		for i, child := range node.Content {
+			if node.Kind == yamlv3.MappingNode && i%2 == 0 {
+				// Leave object keys as-is.
+				continue
+			}
+			styleYAML(child, flow, strStyle)
+		}
+	case yamlv3.ScalarNode:
+		if node.Tag != "!!str" || strStyle == 0 {
+			return
+		}
+		if strStyle == yamlv3.DoubleQuotedStyle || strings.Contains(node.Value, "\n") {
+			node.Style = strStyle
+		}
+	default:
+		for _, child := range node.Content {
+			styleYAML(child, flow, strStyle)
+		}
+	}
+}
+
 // Unmarshal the value from encoded YAML.
 func (y YAML) Unmarshal(data []byte, value interface{}) error {
 	return yaml.Unmarshal(data, value)
@@ -393,6 +619,38 @@ func (m MsgPack) Unmarshal(data []byte, value interface{}) error {
 	return msgpack.Unmarshal(data, value)
 }
 
+// TOML describes content types like `application/toml` or
+// `application/foo+toml`. https://toml.io/
+type TOML struct{}
+
+// Detect if the content type is TOML.
+func (t TOML) Detect(contentType string) bool {
+	first := strings.Split(contentType, ";")[0]
+	if first == "application/toml" || strings.HasSuffix(first, "+toml") {
+		return true
+	}
+
+	return false
+}
+
+// Marshal the value to encoded TOML. The value must be an object since TOML
+// documents are always tables. Null values are omitted as TOML has no null.
+func (t TOML) Marshal(value interface{}) ([]byte, error) {
+	// Decoded CBOR and others may contain `map[any]any`, which TOML can't
+	// encode, so convert to JSON-compatible maps first.
+	value = makeJSONSafe(value)
+	if _, ok := value.(map[string]any); !ok {
+		return nil, fmt.Errorf("TOML requires an object at the top level but got %T", value)
+	}
+
+	return toml.Marshal(value)
+}
+
+// Unmarshal the value from encoded TOML.
+func (t TOML) Unmarshal(data []byte, value interface{}) error {
+	return toml.Unmarshal(data, value)
+}
+
 // Ion describes content types like `application/ion`.
 type Ion struct{}
 
@@ -3,7 +3,9 @@ package cli
 import (
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
 )
 
 var contentTests = []struct {
@@ -16,6 +18,7 @@ var contentTests = []struct {
 	{"text", []string{"text/plain", "text/html"}, &Text{}, []byte("hello world"), nil},
 	{"json", []string{"application/json", "foo+json"}, &JSON{}, []byte("{\"hello\":\"world\"}\n"), []byte("{\n  \"hello\": \"world\"\n}\n")},
 	{"yaml", []string{"application/yaml", "foo+yaml"}, &YAML{}, []byte("hello: world\n"), nil},
+	{"toml", []string{"application/toml", "foo+toml"}, &TOML{}, []byte("hello = 'world'\n"), nil},
 	{"cbor", []string{"application/cbor", "foo+cbor"}, &CBOR{}, []byte("\xf6"), nil},
 	{"msgpack", []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack", "foo+msgpack"}, &MsgPack{}, []byte("\x81\xa5\x68\x65\x6c\x6c\x6f\xa5\x77\x6f\x72\x6c\x64"), nil},
 	{"ion", []string{"application/ion", "foo+ion"}, &Ion{}, []byte("\xe0\x01\x00\xea\x0f"), []byte("null")},
@@ -51,3 +54,94 @@ func TestContentTypes(parent *testing.T) {
 		})
 	}
 }
+
+func TestTOMLMarshal(t *testing.T) {
+	// Maps with non-string keys, e.g. from CBOR, are normalized and nulls are
+	// dropped since TOML has no null.
+	b, err := TOML{}.Marshal(map[any]any{"a": 1, "b": nil, 5: map[any]any{"c": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, "a = 1\n\n[5]\nc = true\n", string(b))
+
+	_, err = TOML{}.Marshal([]any{1, 2})
+	assert.ErrorContains(t, err, "object at the top level")
+}
+
+func TestCSVMarshal(t *testing.T) {
+	// Columns are the sorted union of all keys and values are quoted as needed.
+	b, err := CSV{}.Marshal([]any{
+		map[string]any{"id": 1, "name": "Alice, \"Al\""},
+		map[string]any{"id": 2, "tags": []any{"a", "b"}, "meta": map[string]any{"x": nil}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,meta,name,tags\r\n1,,\"Alice, \"\"Al\"\"\",\r\n2,\"{\"\"x\"\":null}\",,\"[\"\"a\"\",\"\"b\"\"]\"\r\n", string(b))
+
+	_, err = CSV{}.Marshal(map[string]any{"id": 1})
+	assert.ErrorContains(t, err, "Must be array of objects")
+
+	_, err = CSV{}.Marshal([]any{1, 2})
+	assert.ErrorContains(t, err, "expected an array of objects")
+}
+
+func TestYAMLStyles(t *testing.T) {
+	defer func() {
+		viper.Set("rsh-yaml-flow", false)
+		viper.Set("rsh-yaml-strings", "")
+		viper.Set("rsh-yaml-indent", 0)
+	}()
+
+	value := map[string]any{
+		"name": "foo",
+		"desc": "line one\nline two\n",
+		"tags": []any{"a", "b"},
+		"nested": map[string]any{
+			"id": 1,
+		},
+	}
+
+	viper.Set("rsh-yaml-flow", true)
+
+	// Request bodies are never styled.
+	b, err := YAML{}.Marshal(map[string]any{"tags": []any{"a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "tags:\n- a\n", string(b))
+
+	b, err = YAML{}.MarshalPretty(value)
+	assert.NoError(t, err)
+	assert.Equal(t, "{desc: \"line one\\nline two\\n\", name: foo, nested: {id: 1}, tags: [a, b]}\n", string(b))
+
+	viper.Set("rsh-yaml-flow", false)
+	viper.Set("rsh-yaml-strings", "literal")
+	viper.Set("rsh-yaml-indent", 4)
+	b, err = YAML{}.MarshalPretty(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `desc: |
+    line one
+    line two
+name: foo
+nested:
+    id: 1
+tags:
+    - a
+    - b
+`, string(b))
+
+	viper.Set("rsh-yaml-strings", "quoted")
+	viper.Set("rsh-yaml-indent", 0)
+	b, err = YAML{}.MarshalPretty(map[string]any{"name": "foo", "id": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 1\nname: \"foo\"\n", string(b))
+
+	viper.Set("rsh-yaml-strings", "bad")
+	_, err = YAML{}.MarshalPretty(value)
+	assert.Error(t, err)
+}
+
+func TestYAMLStylesOutput(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/").Reply(200).JSON(map[string]any{"tags": []any{"a", "b"}})
+
+	out := run("-o yaml -f body --rsh-yaml-flow http://example.com/")
+	assert.Equal(t, "{tags: [a, b]}\n", out)
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// CookieLoginAuth implements session cookie authentication. A login request is
+// made on first use and the cookies it sets are cached and sent with each
+// request until they expire or the server responds with a 401.
+type CookieLoginAuth struct{}
+
+// Parameters define the cookie login auth parameter names.
+func (a *CookieLoginAuth) Parameters() []AuthParam {
+	return []AuthParam{
+		{Name: "url", Required: true, Help: "Login URL, e.g. https://api.example.com/login"},
+		{Name: "method", Help: "Login request method, defaults to POST"},
+		{Name: "body", Help: "Login request body, may reference environment variables like $API_PASSWORD"},
+		{Name: "content_type", Help: "Login request body content type, defaults to application/json"},
+	}
+}
+
+// parseCookies parses cookies in `Cookie` header format, e.g. `a=1; b=2`.
+func parseCookies(header string) []*http.Cookie {
+	return (&http.Request{Header: http.Header{"Cookie": {header}}}).Cookies()
+}
+
+// cookieLogin makes the login request and returns the cookies it sets in
+// `Cookie` header format along with the earliest cookie expiration time, if
+// any.
+func cookieLogin(params map[string]string) (string, time.Time, error) {
+	var expires time.Time
+
+	if params["url"] == "" {
+		return "", expires, fmt.Errorf("cookie login auth requires a url")
+	}
+
+	method := strings.ToUpper(params["method"])
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	var body io.Reader
+	if params["body"] != "" {
+		body = strings.NewReader(os.ExpandEnv(params["body"]))
+	}
+
+	req, err := http.NewRequest(method, os.ExpandEnv(params["url"]), body)
+	if err != nil {
+		return "", expires, err
+	}
+
+	if body != nil {
+		contentType := params["content_type"]
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	// Logins often redirect after setting the cookie, so don't follow them.
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	LogDebug("Logging in to get a session cookie")
+	resp, err := MakeRequest(req, WithClient(client), IgnoreStatus(), IgnoreCLIParams(), fromAuthHandler())
+	if err != nil {
+		return "", expires, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return "", expires, fmt.Errorf("login failed with %s", resp.Status)
+	}
+
+	parts := []string{}
+	for _, c := range resp.Cookies() {
+		if c.MaxAge < 0 {
+			// The server is deleting this cookie.
+			continue
+		}
+
+		parts = append(parts, (&http.Cookie{Name: c.Name, Value: c.Value}).String())
+
+		e := c.Expires
+		if c.MaxAge > 0 {
+			e = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		if !e.IsZero() && (expires.IsZero() || e.Before(expires)) {
+			expires = e
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", expires, fmt.Errorf("login response did not set any cookies")
+	}
+
+	return strings.Join(parts, "; "), expires, nil
+}
+
+// OnRequest gets run before the request goes out on the wire.
+func (a *CookieLoginAuth) OnRequest(req *http.Request, key string, params map[string]string) error {
+	cookiesKey := key + ".cookies"
+	expiresKey := key + ".expires"
+
+	cookies := Cache.GetString(cookiesKey)
+	expires := Cache.GetTime(expiresKey)
+
+	if cookies == "" || (!expires.IsZero() && time.Now().After(expires)) {
+		var err error
+		cookies, expires, err = cookieLogin(params)
+		if err != nil {
+			return err
+		}
+
+		Cache.Set(cookiesKey, cookies)
+		Cache.Set(expiresKey, expires)
+		if err := Cache.WriteConfig(); err != nil {
+			return err
+		}
+	} else {
+		LogDebug("Loading session cookie from cache.")
+	}
+
+	// Replace any stale session cookies, e.g. when retrying after logging in
+	// again, but keep other cookies set on the request.
+	session := parseCookies(cookies)
+	names := map[string]bool{}
+	for _, c := range session {
+		names[c.Name] = true
+	}
+
+	existing := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, c := range existing {
+		if !names[c.Name] {
+			req.AddCookie(c)
+		}
+	}
+	for _, c := range session {
+		req.AddCookie(c)
+	}
+
+	return nil
+}
+
+// InvalidateAuth removes the cached session cookies so the next request logs
+// in again.
+func (a *CookieLoginAuth) InvalidateAuth(key string, params map[string]string) error {
+	LogDebug("Invalidating cached session cookie.")
+	Cache.Set(key+".cookies", "")
+	Cache.Set(key+".expires", time.Time{})
+	return Cache.WriteConfig()
+}
+
+// AlwaysRefresh logs in again on any 401 since sessions can expire on the
+// server at any time.
+func (a *CookieLoginAuth) AlwaysRefresh() bool {
+	return true
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieLoginAuth(t *testing.T) {
+	defer reset(false)
+	reset(false)
+
+	logins := 0
+	session := "first"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.JSONEq(t, `{"user": "alice", "password": "secret"}`, string(body))
+			logins++
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session, Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+
+		if c, err := r.Cookie("session"); err != nil || c.Value != session {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// Other cookies are passed through.
+		c, _ := r.Cookie("other")
+		assert.NotNil(t, c)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Setenv("RSH_TEST_PASSWORD", "secret")
+	configs["cookie-login-test"] = &APIConfig{
+		Base: server.URL,
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Auth: &APIAuth{
+					Name: "cookie-login",
+					Params: map[string]string{
+						"url":  server.URL + "/login",
+						"body": `{"user": "alice", "password": "$RSH_TEST_PASSWORD"}`,
+					},
+				},
+			},
+		},
+	}
+	defer func() {
+		clearAuthCache("cookie-login-test")
+		delete(configs, "cookie-login-test")
+	}()
+
+	request := func() int {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+		req.AddCookie(&http.Cookie{Name: "other", Value: "1"})
+		resp, err := MakeRequest(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// Logs in once and then reuses the cached cookie.
+	assert.Equal(t, http.StatusNoContent, request())
+	assert.Equal(t, http.StatusNoContent, request())
+	assert.Equal(t, 1, logins)
+
+	// An expired session logs in again and retries.
+	session = "second"
+	assert.Equal(t, http.StatusNoContent, request())
+	assert.Equal(t, 2, logins)
+}
+
+func TestCookieLoginAuthFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, _, err := cookieLogin(map[string]string{"url": server.URL + "/login"})
+	assert.ErrorContains(t, err, "login failed with 403 Forbidden")
+
+	_, _, err = cookieLogin(map[string]string{})
+	assert.ErrorContains(t, err, "requires a url")
+}
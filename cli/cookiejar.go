@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/publicsuffix"
+)
+
+// cookieJarMu guards reading & writing cookie jar files.
+var cookieJarMu sync.Mutex
+
+// storedCookie is a cookie along with the URL that set it, which is needed to
+// apply the same domain & path scoping rules when it is loaded again.
+type storedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// persistentJar is a cookie jar which remembers the cookies that were set so
+// they can be saved to disk and replayed into a fresh jar on the next run.
+// Scoping and expiration are handled by the standard library jar.
+type persistentJar struct {
+	*cookiejar.Jar
+	filename string
+	mu       sync.Mutex
+	stored   []storedCookie
+}
+
+// cookieJarPath returns the cookie jar filename for an API profile.
+func cookieJarPath(apiName, profile string) string {
+	return filepath.Join(getCacheDir(), "cookies", apiName+"-"+profile+".json")
+}
+
+// loadCookieJar loads the cookie jar for an API profile, returning an empty
+// jar if nothing has been saved yet. Expired cookies are dropped.
+func loadCookieJar(apiName, profile string) (*persistentJar, error) {
+	inner, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, err
+	}
+
+	jar := &persistentJar{
+		Jar:      inner,
+		filename: cookieJarPath(apiName, profile),
+	}
+
+	cookieJarMu.Lock()
+	data, err := os.ReadFile(jar.filename)
+	cookieJarMu.Unlock()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return jar, nil
+		}
+		return nil, err
+	}
+
+	var stored []storedCookie
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, s := range stored {
+		if s.Cookie == nil || (!s.Cookie.Expires.IsZero() && s.Cookie.Expires.Before(now)) {
+			continue
+		}
+		u, err := url.Parse(s.URL)
+		if err != nil {
+			continue
+		}
+		jar.SetCookies(u, []*http.Cookie{s.Cookie})
+	}
+
+	return jar, nil
+}
+
+// SetCookies handles the receipt of the cookies in a reply for the given URL.
+func (j *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+	for _, c := range cookies {
+		c := *c
+		if c.MaxAge > 0 {
+			// Relative expiration only makes sense when first received.
+			c.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+			c.MaxAge = 0
+		}
+		c.Raw = ""
+		c.Unparsed = nil
+
+		// Newer cookies replace older ones with the same name & scope.
+		kept := j.stored[:0]
+		for _, s := range j.stored {
+			if !sameCookie(s, origin, &c) {
+				kept = append(kept, s)
+			}
+		}
+		j.stored = kept
+
+		if c.MaxAge >= 0 && (c.Expires.IsZero() || c.Expires.After(time.Now())) {
+			j.stored = append(j.stored, storedCookie{URL: origin, Cookie: &c})
+		}
+	}
+}
+
+// sameCookie returns whether a stored cookie would be replaced by a new one.
+func sameCookie(s storedCookie, origin string, c *http.Cookie) bool {
+	if s.Cookie.Name != c.Name || s.Cookie.Domain != c.Domain || s.Cookie.Path != c.Path {
+		return false
+	}
+	if c.Domain != "" {
+		return true
+	}
+	// Host-only cookies are scoped to the host that set them.
+	a, errA := url.Parse(s.URL)
+	b, errB := url.Parse(origin)
+	return errA == nil && errB == nil && a.Host == b.Host
+}
+
+// Save writes the jar's unexpired cookies to disk.
+func (j *persistentJar) Save() error {
+	j.mu.Lock()
+	now := time.Now()
+	stored := []storedCookie{}
+	for _, s := range j.stored {
+		if s.Cookie.Expires.IsZero() || s.Cookie.Expires.After(now) {
+			stored = append(stored, s)
+		}
+	}
+	j.mu.Unlock()
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	cookieJarMu.Lock()
+	defer cookieJarMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.filename), 0700); err != nil {
+		return err
+	}
+
+	// Cookies are often session credentials, so keep them private.
+	return os.WriteFile(j.filename, data, 0600)
+}
+
+// clearCookies removes the saved cookie jar for an API's current profile.
+func clearCookies(apiName string) {
+	if configs[apiName] == nil {
+		panic("API " + apiName + " not found")
+	}
+
+	cookieJarMu.Lock()
+	defer cookieJarMu.Unlock()
+
+	err := os.Remove(cookieJarPath(apiName, viper.GetString("rsh-profile")))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieJar(t *testing.T) {
+	t.Setenv("TEST_CACHE_DIR", t.TempDir())
+	defer delete(configs, "cookie-jar")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+		}
+		session := ""
+		if c, err := r.Cookie("session"); err == nil {
+			session = c.Value
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"session": "` + session + `"}`))
+	}))
+	defer server.Close()
+
+	setup := func() {
+		reset(false)
+		configs["cookie-jar"] = &APIConfig{
+			name: "cookie-jar",
+			Base: server.URL,
+			Profiles: map[string]*APIProfile{
+				"default": {Cookies: true},
+			},
+		}
+	}
+
+	setup()
+	runNoReset("cookie-jar/login")
+
+	// The cookie is saved and sent on later invocations.
+	setup()
+	assert.Contains(t, runNoReset("-f body.session cookie-jar/me"), "abc123")
+
+	setup()
+	assert.NotContains(t, runNoReset("-f body.session --rsh-no-cookies cookie-jar/me"), "abc123")
+
+	setup()
+	runNoReset("api clear-cookies cookie-jar")
+	_, err := os.Stat(cookieJarPath("cookie-jar", "default"))
+	assert.True(t, os.IsNotExist(err))
+
+	setup()
+	assert.NotContains(t, runNoReset("-f body.session cookie-jar/me"), "abc123")
+}
+
+func TestCookieJarScoping(t *testing.T) {
+	reset(false)
+	t.Setenv("TEST_CACHE_DIR", t.TempDir())
+
+	jar, err := loadCookieJar("scoping", "default")
+	assert.NoError(t, err)
+
+	a, _ := url.Parse("https://a.example.com/v1/login")
+	jar.SetCookies(a, []*http.Cookie{
+		{Name: "session", Value: "abc", Path: "/v1"},
+		{Name: "shared", Value: "def", Domain: "example.com", Path: "/"},
+		{Name: "old", Value: "ghi", Expires: time.Now().Add(-time.Hour)},
+		{Name: "short", Value: "jkl", MaxAge: 3600},
+	})
+	assert.NoError(t, jar.Save())
+
+	jar, err = loadCookieJar("scoping", "default")
+	assert.NoError(t, err)
+
+	names := func(u string) []string {
+		parsed, _ := url.Parse(u)
+		result := []string{}
+		for _, c := range jar.Cookies(parsed) {
+			result = append(result, c.Name+"="+c.Value)
+		}
+		return result
+	}
+
+	assert.ElementsMatch(t, []string{"session=abc", "shared=def", "short=jkl"}, names("https://a.example.com/v1/items"))
+	assert.ElementsMatch(t, []string{"shared=def"}, names("https://a.example.com/v2/items"))
+	assert.ElementsMatch(t, []string{"shared=def"}, names("https://b.example.com/v1/items"))
+	assert.Empty(t, names("https://other.com/v1/items"))
+}
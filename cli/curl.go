@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// errRequestNotSent is returned by `MakeRequest` when a request was printed
+// instead of being sent, e.g. via `--rsh-curl`.
+var errRequestNotSent = errors.New("request not sent")
+
+// curlInlineBodyLimit is the largest body passed inline as an argument. Larger
+// bodies ending in a newline are passed via stdin using a heredoc.
+const curlInlineBodyLimit = 1024
+
+// shellSafeRegex matches arguments which don't need to be quoted.
+var shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes a value for safe use as a POSIX shell argument.
+func shellQuote(value string) string {
+	if shellSafeRegex.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// curlCommand returns a `curl` command line equivalent to the request. The
+// request body is read and reset so the request could still be sent.
+func curlCommand(req *http.Request, tlsConfig *TLSConfig) (string, error) {
+	// Each option goes on its own line for readability.
+	first := "curl"
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodHead:
+		first += " --head"
+	default:
+		first += " -X " + shellQuote(req.Method)
+	}
+	args := []string{first + " " + shellQuote(req.URL.String())}
+
+	if tlsConfig != nil {
+		if tlsConfig.InsecureSkipVerify {
+			args = append(args, "--insecure")
+		}
+		if tlsConfig.Cert != "" {
+			args = append(args, "--cert "+shellQuote(tlsConfig.Cert))
+		}
+		if tlsConfig.Key != "" {
+			args = append(args, "--key "+shellQuote(tlsConfig.Key))
+		}
+		if tlsConfig.CACert != "" {
+			args = append(args, "--cacert "+shellQuote(tlsConfig.CACert))
+		}
+	}
+
+	if proxy, ok := req.Context().Value(proxyContextKey{}).(*url.URL); ok {
+		args = append(args, "--proxy "+shellQuote(proxy.String()))
+	}
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H "+shellQuote("Host: "+req.Host))
+	}
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if strings.EqualFold(name, "Accept-Encoding") {
+			// Let curl negotiate and decode the compression it supports.
+			args = append(args, "--compressed")
+			continue
+		}
+		for _, value := range req.Header[name] {
+			args = append(args, "-H "+shellQuote(name+": "+value))
+		}
+	}
+
+	heredoc := ""
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		switch {
+		case len(body) == 0:
+		case !utf8.Valid(body):
+			return "", fmt.Errorf("cannot print a curl command for a binary request body")
+		case (len(body) > curlInlineBodyLimit || bytes.Contains(body, []byte("\n"))) && bytes.HasSuffix(body, []byte("\n")):
+			// Pass large or multi-line bodies via stdin, keeping the command
+			// readable and avoiding argument length limits. The heredoc always
+			// ends with a newline, so bodies without one are quoted inline below.
+			marker := "EOF"
+			for bytes.Contains(body, []byte(marker)) {
+				marker += "_"
+			}
+			args = append(args, "--data-binary @-")
+			heredoc = fmt.Sprintf(" <<'%s'\n%s\n%s", marker, string(body[:len(body)-1]), marker)
+		default:
+			args = append(args, "--data-binary "+shellQuote(string(body)))
+		}
+	}
+
+	return strings.Join(args, " \\\n  ") + heredoc, nil
+}
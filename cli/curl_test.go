@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "https://example.com/a", shellQuote("https://example.com/a"))
+	assert.Equal(t, "'https://example.com/a?b=c'", shellQuote("https://example.com/a?b=c"))
+	assert.Equal(t, "'Accept: */*'", shellQuote("Accept: */*"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "''", shellQuote(""))
+}
+
+func TestCurlCommand(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/items?q=a+b", strings.NewReader(`{"name":"it's"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	cmd, err := curlCommand(req, &TLSConfig{InsecureSkipVerify: true, CACert: "/etc/ca.pem"})
+	assert.NoError(t, err)
+	assert.Equal(t, `curl -X POST 'https://example.com/items?q=a+b' \
+  --insecure \
+  --cacert /etc/ca.pem \
+  --compressed \
+  -H 'Content-Type: application/json' \
+  --data-binary '{"name":"it'\''s"}'`, cmd)
+
+	// The body can still be sent.
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, `{"name":"it's"}`, string(body))
+
+	// Multi-line bodies use stdin.
+	req, _ = http.NewRequest(http.MethodPut, "https://example.com/items/1", strings.NewReader("{\n  \"EOF\": true\n}\n"))
+	cmd, err = curlCommand(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "curl -X PUT https://example.com/items/1 \\\n  --data-binary @- <<'EOF_'\n{\n  \"EOF\": true\n}\nEOF_", cmd)
+
+	// A heredoc would add a trailing newline, so bodies without one are quoted
+	// inline instead.
+	req, _ = http.NewRequest(http.MethodPut, "https://example.com/items/1", strings.NewReader("{\n  \"a\": 1\n}"))
+	cmd, err = curlCommand(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "curl -X PUT https://example.com/items/1 \\\n  --data-binary '{\n  \"a\": 1\n}'", cmd)
+
+	req, _ = http.NewRequest(http.MethodPut, "https://example.com/items/1", strings.NewReader("\xff\xfe"))
+	_, err = curlCommand(req, nil)
+	assert.ErrorContains(t, err, "binary")
+}
+
+func TestCurlFlag(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Post("/items").Reply(200)
+
+	out := run("post http://example.com/items -H X-Foo:bar --rsh-curl name: foo")
+	assert.Contains(t, out, "curl -X POST http://example.com/items \\\n")
+	assert.Contains(t, out, "  -H 'X-Foo: bar' \\\n")
+	assert.Contains(t, out, "  -H 'Content-Type: application/json; charset=utf-8' \\\n")
+	assert.Contains(t, out, `  --data-binary '{"name":"foo"}'`)
+	assert.False(t, gock.IsDone())
+}
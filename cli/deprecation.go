@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// warnedDeprecations tracks endpoints which have already been warned about so
+// that paginated or repeated requests only warn once.
+var warnedDeprecations = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: map[string]bool{}}
+
+// warnDeprecatedResponse warns when the server marks an endpoint as
+// deprecated via the `Deprecation` header (RFC 9745) or as going away via the
+// `Sunset` header (RFC 8594).
+func warnDeprecatedResponse(resp Response) {
+	deprecation := resp.Headers["Deprecation"]
+	sunset := resp.Headers["Sunset"]
+	if deprecation == "" && sunset == "" {
+		return
+	}
+
+	key := resp.Method + " " + resp.URL
+	if u, err := url.Parse(resp.URL); err == nil {
+		key = resp.Method + " " + u.Host + u.Path
+	}
+
+	warnedDeprecations.Lock()
+	defer warnedDeprecations.Unlock()
+	if warnedDeprecations.seen[key] {
+		return
+	}
+	warnedDeprecations.seen[key] = true
+
+	msg := "The server marked " + key + " as deprecated"
+	if deprecation == "" {
+		msg = "The server marked " + key + " as going away"
+	} else if strings.HasPrefix(deprecation, "@") {
+		// Structured field date, e.g. `@1688169599`.
+		if ts, err := strconv.ParseInt(deprecation[1:], 10, 64); err == nil {
+			msg += " as of " + time.Unix(ts, 0).UTC().Format("2006-01-02")
+		}
+	}
+
+	if sunset != "" {
+		if t, err := http.ParseTime(sunset); err == nil {
+			msg += ", it will be removed after " + t.UTC().Format("2006-01-02")
+		}
+	}
+
+	for _, l := range resp.Links["deprecation"] {
+		msg += ", see " + l.URI
+		break
+	}
+
+	LogWarning("%s", msg)
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestDeprecationHeaders(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/sunset").Times(2).
+		Reply(http.StatusOK).
+		SetHeader("Deprecation", "@1688169600").
+		SetHeader("Sunset", "Sun, 30 Jun 2030 23:59:59 GMT").
+		SetHeader("Link", `<https://example.com/migrate>; rel="deprecation"`).
+		JSON(map[string]any{"hello": "world"})
+
+	out := run("http://example.com/sunset")
+	assert.Contains(t, out, "WARN: The server marked GET example.com/sunset as deprecated as of 2023-07-01, it will be removed after 2030-06-30, see https://example.com/migrate\n")
+
+	// Only warn once per endpoint.
+	out = runNoReset("http://example.com/sunset")
+	assert.NotContains(t, out, "WARN")
+}
+
+func TestSunsetHeader(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/going-away").
+		Reply(http.StatusOK).
+		SetHeader("Sunset", "Sun, 30 Jun 2030 23:59:59 GMT").
+		JSON(map[string]any{"hello": "world"})
+
+	out := run("http://example.com/going-away")
+	assert.Contains(t, out, "WARN: The server marked GET example.com/going-away as going away, it will be removed after 2030-06-30\n")
+}
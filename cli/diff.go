@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// diffMasked replaces volatile values which should not be compared.
+const diffMasked = "<masked>"
+
+// diffAgainst compares a parsed response against a saved baseline file and
+// prints a unified diff of any differences. The response body (or the result
+// of `--rsh-filter` if set) is compared after normalizing both sides to
+// indented JSON, so key order and whitespace do not matter.
+func diffAgainst(parsed Response, filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	// JSON is a subset of YAML, so this handles both baseline formats.
+	var baseline any
+	if err := yaml.Unmarshal(b, &baseline); err != nil {
+		return fmt.Errorf("unable to parse baseline %s: %w", filename, err)
+	}
+	baseline = makeJSONSafe(baseline)
+
+	filter := viper.GetString("rsh-filter")
+	if filter == "" {
+		filter = "body"
+	}
+
+	current, err := queryData(filter, makeJSONSafe(parsed.Map()))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range viper.GetStringSlice("rsh-diff-ignore") {
+		parts := strings.Split(path, ".")
+		baseline = maskPath(baseline, parts)
+		current = maskPath(current, parts)
+	}
+
+	orig, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	mod, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	edits := myers.ComputeEdits(span.URIFromPath(filename), string(orig)+"\n", string(mod)+"\n")
+	if len(edits) == 0 {
+		LogInfo("Response matches %s", filename)
+		return nil
+	}
+
+	diff := fmt.Sprint(gotextdiff.ToUnified(filename, "response", string(orig)+"\n", edits))
+	if useColor {
+		d, _ := Highlight("diff", []byte(diff))
+		diff = string(d)
+	}
+	fmt.Fprintln(Stdout, diff)
+
+	return fmt.Errorf("response differs from %s", filename)
+}
+
+// maskPath replaces the value at a dotted path like `meta.updated` with a
+// placeholder. A `[]` suffix on a path part applies the rest of the path to
+// every item of an array, e.g. `items[].id`. Missing paths are ignored.
+func maskPath(value any, parts []string) any {
+	if len(parts) == 0 {
+		return diffMasked
+	}
+
+	key := parts[0]
+	all := strings.HasSuffix(key, "[]")
+	key = strings.TrimSuffix(key, "[]")
+
+	if key == "" && all {
+		// A bare `[]` path part refers to the current array.
+		if items, ok := value.([]any); ok {
+			for i := range items {
+				items[i] = maskPath(items[i], parts[1:])
+			}
+		}
+		return value
+	}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+
+	v, ok := m[key]
+	if !ok {
+		return value
+	}
+
+	if all {
+		m[key] = maskPath(v, append([]string{"[]"}, parts[1:]...))
+	} else {
+		m[key] = maskPath(v, parts[1:])
+	}
+
+	return value
+}
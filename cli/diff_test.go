@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestDiffAgainst(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "baseline.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"id": 1, "name": "foo", "items": [{"updated": "yesterday"}]}`), 0600))
+
+	gock.New("http://example.com").Get("/same").Reply(200).JSON(map[string]any{
+		"name":  "foo",
+		"id":    1,
+		"items": []any{map[string]any{"updated": "today"}},
+	})
+
+	captured := run("http://example.com/same --rsh-diff-against " + filename + " --rsh-diff-ignore items[].updated")
+	assert.NotContains(t, captured, "@@")
+	assert.NotContains(t, captured, "differs")
+
+	gock.New("http://example.com").Get("/changed").Reply(200).JSON(map[string]any{
+		"name":  "bar",
+		"id":    1,
+		"items": []any{map[string]any{"updated": "today"}},
+	})
+
+	captured = run("http://example.com/changed --rsh-diff-against " + filename + " --rsh-diff-ignore items[].updated")
+	assert.Contains(t, captured, `-  "name": "foo"`)
+	assert.Contains(t, captured, `+  "name": "bar"`)
+	assert.Contains(t, captured, "response differs from")
+}
+
+func TestMaskPath(t *testing.T) {
+	value := map[string]any{
+		"meta":  map[string]any{"etag": "abc", "keep": true},
+		"items": []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+	}
+
+	value = maskPath(value, []string{"meta", "etag"}).(map[string]any)
+	value = maskPath(value, []string{"items[]", "id"}).(map[string]any)
+	value = maskPath(value, []string{"missing", "field"}).(map[string]any)
+
+	assert.Equal(t, map[string]any{
+		"meta":  map[string]any{"etag": diffMasked, "keep": true},
+		"items": []any{map[string]any{"id": diffMasked}, map[string]any{"id": diffMasked}},
+	}, value)
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+)
+
+// reContentRange parses the start of an RFC 7233 `Content-Range` header, e.g.
+// `bytes 100-199/200` or `bytes */200`.
+var reContentRange = regexp.MustCompile(`^bytes (\d+)-\d+/(\d+|\*)$|^bytes \*/(\d+)$`)
+
+// download makes a request and writes the raw response body to a file,
+// bypassing the formatter. When resuming, a partial file is completed via an
+// HTTP range request if the server supports it, otherwise the download is
+// restarted. Error responses are formatted as usual and not written. An
+// empty filename uses the name suggested by the server, see `remoteFilename`.
+func download(req *http.Request, filename string) error {
+	compress := viper.GetBool("rsh-compress-output")
+
+	offset := int64(0)
+	if viper.GetBool("rsh-resume") {
+		if filename == "" {
+			return fmt.Errorf("cannot resume a download without --rsh-output-file")
+		}
+		if compress {
+			// The partial file size would not match the response byte offset.
+			return fmt.Errorf("cannot resume a compressed download")
+		}
+
+		if info, err := os.Stat(filename); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			LogDebug("Resuming download of %s from byte %d", filename, offset)
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+			// Byte ranges refer to the encoded content, so ask for it as-is.
+			req.Header.Set("Accept-Encoding", "identity")
+		}
+	}
+
+	ctx, cancel := withInterrupt(req.Context())
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	resp, err := MakeRequest(req)
+	if err != nil {
+		return interruptError(err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		m := reContentRange.FindStringSubmatch(resp.Header.Get("Content-Range"))
+		if m == nil || m[1] != strconv.FormatInt(offset, 10) {
+			return fmt.Errorf("unexpected content range %q when resuming from byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		m := reContentRange.FindStringSubmatch(resp.Header.Get("Content-Range"))
+		if m != nil && m[3] == strconv.FormatInt(offset, 10) {
+			// Nothing left to download.
+			lastStatus = http.StatusOK
+			fmt.Fprintf(Stderr, "%s is already complete (%d bytes)\n", filename, offset)
+			return nil
+		}
+		fallthrough
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		parsed, err := ParseResponse(resp)
+		if err != nil {
+			return err
+		}
+		return Formatter.Format(parsed)
+	default:
+		if offset > 0 {
+			LogWarning("Server does not support range requests, restarting download")
+		}
+		if err := DecodeResponse(resp); err != nil {
+			return err
+		}
+	}
+
+	remote := filename == ""
+	if remote {
+		filename = remoteFilename(resp)
+
+		// Like curl, never overwrite an existing file with a server-chosen name.
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		if remote && errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("refusing to overwrite existing file %s, use --rsh-output-file to choose the filename", filename)
+		}
+		return err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var gz io.WriteCloser
+	if compress {
+		if gz, err = (GzipEncoding{}).Writer(f); err != nil {
+			return err
+		}
+		w = gz
+	}
+
+	written, err := io.Copy(w, resp.Body)
+	if err != nil {
+		err = interruptError(err)
+		if compress || remote {
+			// Resuming needs an explicit, uncompressed output file.
+			return fmt.Errorf("download interrupted after %d bytes: %w", written, err)
+		}
+		return fmt.Errorf("download interrupted after %d bytes, use --rsh-resume to continue: %w", written, err)
+	}
+
+	total := written
+	if flags&os.O_APPEND != 0 {
+		total += offset
+	}
+
+	compressed := ""
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		if info, err := f.Stat(); err == nil {
+			compressed = fmt.Sprintf(" (%d bytes gzipped)", info.Size())
+		}
+	}
+
+	fmt.Fprintf(Stderr, "%s %s\nWrote %d bytes%s to %s\n", resp.Proto, resp.Status, total, compressed, filename)
+	return nil
+}
+
+// reDispositionFilename leniently finds a filename in `Content-Disposition`
+// headers which aren't valid enough for `mime.ParseMediaType`, e.g. unquoted
+// names with spaces.
+var reDispositionFilename = regexp.MustCompile(`(?i)filename\s*=\s*(?:"([^"]*)"|([^;]*))`)
+
+// contentDispositionFilename returns the sanitized filename suggested by a
+// `Content-Disposition` header, if any. RFC 5987 encoded `filename*` values
+// take precedence over plain `filename` values.
+func contentDispositionFilename(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	if _, params, err := mime.ParseMediaType(header); err == nil {
+		// The standard library decodes `filename*` into `filename`.
+		return sanitizeFilename(params["filename"])
+	}
+
+	if m := reDispositionFilename.FindStringSubmatch(header); m != nil {
+		return sanitizeFilename(m[1] + strings.TrimSpace(m[2]))
+	}
+
+	return ""
+}
+
+// sanitizeFilename makes a suggested filename safe to write in the current
+// directory by dropping any directories, control characters, and leading dots
+// so it can't escape the directory or create hidden files.
+func sanitizeFilename(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.TrimLeft(strings.TrimSpace(name), ".")
+}
+
+// remoteFilename returns the filename for a response in the current directory
+// from its `Content-Disposition` header, falling back to the last segment of
+// the request URL path and then a generated name based on the content type.
+func remoteFilename(resp *http.Response) string {
+	if name := contentDispositionFilename(resp.Header.Get("Content-Disposition")); name != "" {
+		return name
+	}
+
+	if resp.Request != nil {
+		if name := sanitizeFilename(path.Base(resp.Request.URL.Path)); name != "" {
+			return name
+		}
+	}
+
+	name := "download"
+	if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		if exts, _ := mime.ExtensionsByType(mt); len(exts) > 0 {
+			// Prefer the extension matching the subtype, e.g. `.jpeg` over
+			// `.jfif` for `image/jpeg`.
+			ext := exts[0]
+			for _, e := range exts {
+				if strings.HasSuffix(mt, "/"+e[1:]) || strings.HasSuffix(mt, "+"+e[1:]) {
+					ext = e
+					break
+				}
+			}
+			name += ext
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestDownload(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	gock.New("http://example.com").Get("/file").Reply(200).BodyString("hello")
+
+	captured := run("http://example.com/file --rsh-output-file " + filename)
+	assert.Contains(t, captured, "Wrote 5 bytes")
+	expectExitCode(t, 0)
+
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
+
+func TestDownloadError(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+
+	gock.New("http://example.com").Get("/file").Reply(404).JSON(map[string]any{
+		"detail": "Not found",
+	})
+
+	captured := run("http://example.com/file --rsh-output-file " + filename)
+	assert.Contains(t, captured, "Not found")
+	expectExitCode(t, 4)
+
+	_, err := os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadResume(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0600))
+
+	gock.New("http://example.com").Get("/file").
+		MatchHeader("Range", "^bytes=5-$").
+		Reply(206).
+		SetHeader("Content-Range", "bytes 5-10/11").
+		BodyString(" world")
+
+	captured := run("http://example.com/file --rsh-output-file " + filename + " --rsh-resume")
+	assert.Contains(t, captured, "Wrote 11 bytes")
+
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+}
+
+func TestDownloadResumeUnsupported(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0600))
+
+	// Server ignores the range and sends the full body.
+	gock.New("http://example.com").Get("/file").Reply(200).BodyString("hello world")
+
+	run("http://example.com/file --rsh-output-file " + filename + " --rsh-resume")
+
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+}
+
+func TestDownloadResumeComplete(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0600))
+
+	gock.New("http://example.com").Get("/file").
+		Reply(416).
+		SetHeader("Content-Range", "bytes */5")
+
+	captured := run("http://example.com/file --rsh-output-file " + filename + " --rsh-resume")
+	assert.Contains(t, captured, "already complete")
+	expectExitCode(t, 0)
+
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
+
+func TestDownloadCompressed(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "file.json.gz")
+
+	gock.New("http://example.com").Get("/file").Reply(200).BodyString(`{"hello": "world"}`)
+
+	captured := run("http://example.com/file --rsh-output-file " + filename + " --rsh-compress-output")
+	assert.Contains(t, captured, "Wrote 18 bytes")
+	assert.Contains(t, captured, "gzipped")
+
+	f, err := os.Open(filename)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	r, err := GzipEncoding{}.Reader(f)
+	assert.NoError(t, err)
+	b, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"hello": "world"}`, string(b))
+}
+
+func TestDownloadRemoteName(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	assert.NoError(t, os.Chdir(dir))
+
+	gock.New("http://example.com").Get("/export").Reply(200).
+		SetHeader("Content-Disposition", `attachment; filename="../report.csv"`).
+		BodyString("a,b")
+	gock.New("http://example.com").Get("/files/image.png").Reply(200).BodyString("png")
+
+	captured := run("http://example.com/export -O")
+	assert.Contains(t, captured, "Wrote 3 bytes to report.csv")
+
+	captured = run("http://example.com/files/image.png -O")
+	assert.Contains(t, captured, "Wrote 3 bytes to image.png")
+
+	b, err := os.ReadFile(filepath.Join(dir, "report.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b", string(b))
+	assert.FileExists(t, filepath.Join(dir, "image.png"))
+}
+
+func TestDownloadRemoteNameExists(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	assert.NoError(t, os.Chdir(dir))
+	assert.NoError(t, os.WriteFile("report.csv", []byte("mine"), 0600))
+
+	gock.New("http://example.com").Get("/export").Reply(200).
+		SetHeader("Content-Disposition", `attachment; filename="report.csv"`).
+		BodyString("a,b")
+
+	captured := run("http://example.com/export -O")
+	assert.Contains(t, captured, "refusing to overwrite existing file report.csv")
+
+	b, err := os.ReadFile(filepath.Join(dir, "report.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "mine", string(b))
+}
+
+func TestDownloadInterrupted(t *testing.T) {
+	defer reset(false)
+
+	// Promise more than is sent so the download fails partway through.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+		w.Write([]byte("abc"))
+	}))
+	defer server.Close()
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	assert.NoError(t, os.Chdir(dir))
+
+	captured := run(server.URL + "/file.bin --rsh-output-file partial.bin")
+	assert.Contains(t, captured, "download interrupted after 3 bytes, use --rsh-resume to continue")
+
+	// Resuming needs an explicit filename, so don't suggest it.
+	captured = run(server.URL + "/file.bin -O")
+	assert.Contains(t, captured, "download interrupted after 3 bytes")
+	assert.NotContains(t, captured, "--rsh-resume")
+}
+
+func TestContentDispositionFilename(t *testing.T) {
+	for _, tc := range []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"inline", ""},
+		{`attachment; filename="report.csv"`, "report.csv"},
+		{`attachment; filename="fallback.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt`, "€ rates.txt"},
+		{`attachment; filename=my file.txt`, "my file.txt"},
+		{`attachment; filename="../../etc/passwd"`, "passwd"},
+		{`attachment; filename="C:\\temp\\evil.exe"`, "evil.exe"},
+		{`attachment; filename=".bashrc"`, "bashrc"},
+		{`attachment; filename=".."`, ""},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.expected, contentDispositionFilename(tc.header))
+		})
+	}
+}
+
+func TestRemoteFilenameFallback(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	resp := &http.Response{
+		Header:  http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Request: req,
+	}
+	assert.Equal(t, "download.json", remoteFilename(resp))
+
+	resp.Header.Set("Content-Type", "image/jpeg")
+	assert.Equal(t, "download.jpeg", remoteFilename(resp))
+
+	resp.Header.Set("Content-Type", "application/x-unknown")
+	assert.Equal(t, "download", remoteFilename(resp))
+
+	req.URL.Path = "/files/data.bin"
+	assert.Equal(t, "data.bin", remoteFilename(resp))
+}
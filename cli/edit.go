@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -34,7 +35,36 @@ func getEditor() string {
 	return editor
 }
 
-func edit(addr string, args []string, interactive, noPrompt bool, exitFunc func(int), editMarshal func(interface{}) ([]byte, error), editUnmarshal func([]byte, interface{}) error, ext string) {
+// editContentType looks up a registered content type by its short name, e.g.
+// `yaml` or `cbor`, for use when editing or submitting a resource.
+func editContentType(short string) (contentTypeEntry, error) {
+	entry, ok := contentTypes[short]
+	if !ok || entry.name == "" || strings.Contains(entry.name, "*") {
+		return entry, fmt.Errorf("unsupported format %s", short)
+	}
+	return entry, nil
+}
+
+// edit fetches a resource, modifies it via shorthand arguments and/or an
+// interactive editor, then submits it back. The resource is edited using
+// the `editFormat` and submitted using the `bodyFormat`, which are short names
+// of registered content types. A `bodyFormat` of `auto` submits using the same
+// content type the resource was fetched as. When `patch` is `merge` or `json`
+// only the changes are sent via a `PATCH`, falling back to a `PUT` if the
+// server doesn't support it.
+func edit(addr string, args []string, interactive, noPrompt bool, exitFunc func(int), editFormat, bodyFormat, patch string) {
+	editCT, err := editContentType(editFormat)
+	panicOnErr(err)
+
+	if _, ok := patchContentTypes[patch]; patch != "" && !ok {
+		panic(fmt.Errorf("unsupported patch format %s, use %s or %s", patch, patchMerge, patchJSON))
+	}
+
+	if bodyFormat != "auto" {
+		_, err := editContentType(bodyFormat)
+		panicOnErr(err)
+	}
+
 	if !interactive && len(args) == 0 {
 		fmt.Fprintln(os.Stderr, "No arguments passed to modify the resource. Use `-i` to enable interactive mode.")
 		exitFunc(1)
@@ -71,13 +101,7 @@ export EDITOR="vim"`)
 		filter = "body"
 	}
 
-	var logger func(format string, a ...interface{})
-	if enableVerbose {
-		logger = LogDebug
-	}
-	filtered, _, err := shorthand.GetPath(filter, data, shorthand.GetOptions{
-		DebugLogger: logger,
-	})
+	filtered, err := queryData(filter, data)
 	panicOnErr(err)
 	data = filtered
 
@@ -111,7 +135,7 @@ export EDITOR="vim"`)
 
 	if interactive {
 		// Create temp file
-		tmp, err := os.CreateTemp("", "rsh-edit*"+ext)
+		tmp, err := os.CreateTemp("", "rsh-edit*."+editFormat)
 		panicOnErr(err)
 		defer os.Remove(tmp.Name())
 
@@ -120,7 +144,7 @@ export EDITOR="vim"`)
 		// itself may not allow the `$schema` key... hmm.
 
 		// Write the current body
-		marshalled, err := editMarshal(modified)
+		marshalled, err := MarshalShort(editFormat, true, modified)
 		panicOnErr(err)
 		tmp.Write(marshalled)
 		tmp.Close()
@@ -141,7 +165,7 @@ export EDITOR="vim"`)
 		b, err := os.ReadFile(tmp.Name())
 		panicOnErr(err)
 
-		panicOnErr(editUnmarshal(b, &modified))
+		panicOnErr(editCT.ct.Unmarshal(b, &modified))
 	}
 
 	modified = makeJSONSafe(modified)
@@ -172,19 +196,79 @@ export EDITOR="vim"`)
 		}
 	}
 
-	// TODO: support different submission formats, e.g. based on any given
-	// `Content-Type` header?
+	if patch != "" {
+		if allow := resp.Headers["Allow"]; allow != "" && !strings.Contains(strings.ToUpper(allow), http.MethodPatch) {
+			LogWarning("Server does not allow PATCH, sending the full resource via PUT")
+		} else {
+			// Compare the JSON representations so e.g. integers and floats from
+			// different formats are considered equal.
+			var original, changed any
+			panicOnErr(json.Unmarshal(orig, &original))
+			panicOnErr(json.Unmarshal(mod, &changed))
+			body, patchType, err := makePatch(patch, original, changed)
+			panicOnErr(err)
+			b, err := json.Marshal(body)
+			panicOnErr(err)
+
+			req, _ = http.NewRequest(http.MethodPatch, fixAddress(addr), bytes.NewReader(b))
+			req.Header.Set("Content-Type", patchType)
+			setConditionalHeaders(req, etag, lastModified)
+
+			parsed, err := GetParsedResponse(req)
+			if errors.Is(err, errRequestNotSent) {
+				return
+			}
+			panicOnErr(err)
+
+			if parsed.Status != http.StatusMethodNotAllowed && parsed.Status != http.StatusNotImplemented && parsed.Status != http.StatusUnsupportedMediaType {
+				formatResponse(parsed)
+				return
+			}
+			LogWarning("Server does not support %s, sending the full resource via PUT", patchType)
+		}
+	}
+
+	// The submission format is independent of both the fetched and edited
+	// formats, e.g. a CBOR resource can be edited as YAML and sent as CBOR.
 	// TODO: content-encoding for large bodies?
-	// TODO: determine if a PATCH could be used instead?
-	b, _ := json.Marshal(modified)
+	contentType := resp.Headers["Content-Type"]
+	var bodyCT ContentType
+	if bodyFormat == "auto" {
+		for _, name := range sortedContentTypes() {
+			// Skip output-only formats like `gron` which have no media type, and
+			// plain text which can't encode a structured resource.
+			entry := contentTypes[name]
+			if _, isText := entry.ct.(*Text); isText || entry.name == "" {
+				continue
+			}
+			if entry.ct.Detect(contentType) {
+				bodyCT = entry.ct
+				break
+			}
+		}
+		if bodyCT == nil {
+			panic(fmt.Errorf("cannot submit %s, use --rsh-body-format to pick a format", contentType))
+		}
+	} else {
+		entry, _ := editContentType(bodyFormat)
+		contentType = entry.name
+		bodyCT = entry.ct
+	}
+	b, err := bodyCT.Marshal(modified)
+	panicOnErr(err)
 	req, _ = http.NewRequest(http.MethodPut, fixAddress(addr), bytes.NewReader(b))
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	setConditionalHeaders(req, etag, lastModified)
 
+	MakeRequestAndFormat(req)
+}
+
+// setConditionalHeaders makes a request conditional on the resource not
+// having changed since it was fetched.
+func setConditionalHeaders(req *http.Request, etag, lastModified string) {
 	if etag != "" {
 		req.Header.Set("If-Match", etag)
 	} else if lastModified != "" {
 		req.Header.Set("If-Unmodified-Since", lastModified)
 	}
-
-	MakeRequestAndFormat(req)
 }
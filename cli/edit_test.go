@@ -1,11 +1,13 @@
 package cli
 
 import (
-	"encoding/json"
+	"bytes"
+	"io"
 	"net/http"
 	"os"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/h2non/gock.v1"
 )
@@ -31,14 +33,14 @@ func TestEditSuccess(t *testing.T) {
 
 	os.Setenv("VISUAL", "")
 	os.Setenv("EDITOR", "true") // dummy to just return
-	edit("http://example.com/items/foo", []string{"bar:456"}, true, true, func(int) {}, json.Marshal, json.Unmarshal, "json")
+	edit("http://example.com/items/foo", []string{"bar:456"}, true, true, func(int) {}, "json", "json", "")
 }
 
 func TestEditNonInteractiveArgsRequired(t *testing.T) {
 	code := 999
 	edit("http://example.com/items/foo", []string{}, false, true, func(c int) {
 		code = c
-	}, json.Marshal, json.Unmarshal, "json")
+	}, "json", "json", "")
 
 	assert.Equal(t, 1, code)
 }
@@ -49,7 +51,7 @@ func TestEditInteractiveMissingEditor(t *testing.T) {
 	code := 999
 	edit("http://example.com/items/foo", []string{}, true, true, func(c int) {
 		code = c
-	}, json.Marshal, json.Unmarshal, "json")
+	}, "json", "json", "")
 
 	assert.Equal(t, 1, code)
 }
@@ -64,7 +66,7 @@ func TestEditBadGet(t *testing.T) {
 	code := 999
 	edit("http://example.com/items/foo", []string{"foo:123"}, false, true, func(c int) {
 		code = c
-	}, json.Marshal, json.Unmarshal, "json")
+	}, "json", "json", "")
 
 	assert.Equal(t, 1, code)
 }
@@ -83,7 +85,7 @@ func TestEditNoChange(t *testing.T) {
 	code := 999
 	edit("http://example.com/items/foo", []string{"foo:123"}, false, true, func(c int) {
 		code = c
-	}, json.Marshal, json.Unmarshal, "json")
+	}, "json", "json", "")
 
 	assert.Equal(t, 0, code)
 }
@@ -102,7 +104,174 @@ func TestEditNotObject(t *testing.T) {
 	code := 999
 	edit("http://example.com/items/foo", []string{"foo:123"}, false, true, func(c int) {
 		code = c
-	}, json.Marshal, json.Unmarshal, "json")
+	}, "json", "json", "")
 
 	assert.Equal(t, 1, code)
 }
+
+func TestEditBodyFormat(t *testing.T) {
+	defer gock.Off()
+
+	original, _ := cbor.Marshal(map[string]any{"foo": 123})
+
+	gock.New("http://example.com").
+		Get("/items/foo").
+		Reply(http.StatusOK).
+		SetHeader("Content-Type", "application/cbor").
+		Body(bytes.NewReader(original))
+
+	// Edit as YAML, but submit in the same format the resource was fetched as.
+	gock.New("http://example.com").
+		Put("/items/foo").
+		MatchHeader("Content-Type", "application/cbor").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			var body map[string]any
+			b, _ := io.ReadAll(req.Body)
+			if err := cbor.Unmarshal(b, &body); err != nil {
+				return false, err
+			}
+			return assert.ObjectsAreEqual(map[string]any{"foo": uint64(123), "bar": uint64(456)}, body), nil
+		}).
+		Reply(http.StatusOK)
+
+	os.Setenv("VISUAL", "")
+	os.Setenv("EDITOR", "true") // dummy to just return
+	edit("http://example.com/items/foo", []string{"bar:456"}, true, true, func(int) {}, "yaml", "auto", "")
+	assert.True(t, gock.IsDone())
+}
+
+func TestEditBodyFormatXML(t *testing.T) {
+	reset(false)
+	defer gock.Off()
+
+	// Both XML and plain text accept `text/xml`, the resource must always be
+	// submitted as XML.
+	for i := 0; i < 10; i++ {
+		gock.New("http://example.com").
+			Get("/items/foo").
+			Reply(http.StatusOK).
+			SetHeader("Content-Type", "text/xml").
+			BodyString(`<item><foo>123</foo></item>`)
+
+		gock.New("http://example.com").
+			Put("/items/foo").
+			MatchHeader("Content-Type", "text/xml").
+			AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+				var body any
+				b, _ := io.ReadAll(req.Body)
+				if err := (XML{}).Unmarshal(b, &body); err != nil {
+					return false, err
+				}
+				return assert.ObjectsAreEqual(map[string]any{"item": map[string]any{"foo": "123", "bar": "456"}}, body), nil
+			}).
+			Reply(http.StatusOK)
+
+		os.Setenv("VISUAL", "")
+		os.Setenv("EDITOR", "true") // dummy to just return
+		edit("http://example.com/items/foo", []string{"item.bar:456"}, true, true, func(int) {}, "json", "auto", "")
+
+		assert.True(t, gock.IsDone())
+	}
+}
+
+func TestEditBadFormat(t *testing.T) {
+	assert.Panics(t, func() {
+		edit("http://example.com/items/foo", []string{"foo:123"}, false, true, func(int) {}, "table", "json", "")
+	})
+
+	assert.Panics(t, func() {
+		edit("http://example.com/items/foo", []string{"foo:123"}, false, true, func(int) {}, "json", "bogus", "")
+	})
+
+	assert.Panics(t, func() {
+		edit("http://example.com/items/foo", []string{"foo:123"}, false, true, func(int) {}, "json", "json", "bogus")
+	})
+}
+
+func TestEditMergePatch(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/items/foo").
+		Reply(http.StatusOK).
+		SetHeader("Etag", "abc123").
+		JSON(map[string]interface{}{
+			"foo": 123,
+			"baz": map[string]any{"a": 1, "b": 2},
+		})
+
+	gock.New("http://example.com").
+		Patch("/items/foo").
+		MatchHeader("If-Match", "abc123").
+		MatchHeader("Content-Type", "application/merge-patch+json").
+		BodyString(`{"bar": 456, "baz": {"b": 3}}`).
+		Reply(http.StatusOK)
+
+	edit("http://example.com/items/foo", []string{"bar:456, baz.b:3"}, false, true, func(int) {}, "json", "json", "merge")
+	assert.True(t, gock.IsDone())
+}
+
+func TestEditJSONPatch(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/items/foo").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{
+			"foo": 123,
+			"a/b": true,
+		})
+
+	gock.New("http://example.com").
+		Patch("/items/foo").
+		MatchHeader("Content-Type", "application/json-patch+json").
+		BodyString(`[{"op": "replace", "path": "/a~1b", "value": false}, {"op": "add", "path": "/bar", "value": 456}]`).
+		Reply(http.StatusOK)
+
+	edit("http://example.com/items/foo", []string{"bar:456, a/b:false"}, false, true, func(int) {}, "json", "json", "json")
+	assert.True(t, gock.IsDone())
+}
+
+func TestEditPatchFallback(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/items/foo").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{
+			"foo": 123,
+		})
+
+	gock.New("http://example.com").
+		Patch("/items/foo").
+		Reply(http.StatusMethodNotAllowed)
+
+	gock.New("http://example.com").
+		Put("/items/foo").
+		BodyString(`{"foo": 123, "bar": 456}`).
+		Reply(http.StatusOK)
+
+	edit("http://example.com/items/foo", []string{"bar:456"}, false, true, func(int) {}, "json", "json", "merge")
+	assert.True(t, gock.IsDone())
+}
+
+func TestEditPatchNotAllowed(t *testing.T) {
+	defer gock.Off()
+
+	// The `Allow` header says PATCH won't work, so it's never tried.
+	gock.New("http://example.com").
+		Get("/items/foo").
+		Reply(http.StatusOK).
+		SetHeader("Allow", "GET, PUT").
+		JSON(map[string]interface{}{
+			"foo": 123,
+		})
+
+	gock.New("http://example.com").
+		Put("/items/foo").
+		BodyString(`{"foo": 123, "bar": 456}`).
+		Reply(http.StatusOK)
+
+	edit("http://example.com/items/foo", []string{"bar:456"}, false, true, func(int) {}, "json", "json", "json")
+	assert.True(t, gock.IsDone())
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 )
 
 // ContentEncoding is used to encode/decode content for transfer over the wire,
@@ -17,6 +18,12 @@ type ContentEncoding interface {
 	Reader(stream io.Reader) (io.Reader, error)
 }
 
+// ContentEncoder is an optional interface for content encodings which also
+// support encoding content, for example to compress output files.
+type ContentEncoder interface {
+	Writer(stream io.Writer) (io.WriteCloser, error)
+}
+
 // contentTypes is a list of acceptable content types
 var encodings = map[string]ContentEncoding{}
 
@@ -71,6 +78,11 @@ func (g DeflateEncoding) Reader(stream io.Reader) (io.Reader, error) {
 	return flate.NewReader(stream), nil
 }
 
+// Writer returns a new writer for the stream that adds deflate encoding.
+func (g DeflateEncoding) Writer(stream io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(stream, flate.DefaultCompression)
+}
+
 // GzipEncoding supports gzip-encoded response content.
 type GzipEncoding struct{}
 
@@ -79,6 +91,11 @@ func (g GzipEncoding) Reader(stream io.Reader) (io.Reader, error) {
 	return gzip.NewReader(stream)
 }
 
+// Writer returns a new writer for the stream that adds gzip encoding.
+func (g GzipEncoding) Writer(stream io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(stream), nil
+}
+
 // BrotliEncoding supports RFC 7932 Brotli content encoding.
 type BrotliEncoding struct{}
 
@@ -86,3 +103,48 @@ type BrotliEncoding struct{}
 func (b BrotliEncoding) Reader(stream io.Reader) (io.Reader, error) {
 	return io.Reader(brotli.NewReader(stream)), nil
 }
+
+// Writer returns a new writer for the stream that adds brotli encoding.
+func (b BrotliEncoding) Writer(stream io.Writer) (io.WriteCloser, error) {
+	return brotli.NewWriter(stream), nil
+}
+
+// ZstdEncoding supports RFC 8878 Zstandard content encoding.
+type ZstdEncoding struct{}
+
+// Reader returns a new reader for the stream that removes the zstd encoding.
+// The content is decoded as it streams in and the decoder's resources are
+// released once the stream ends or fails.
+func (z ZstdEncoding) Reader(stream io.Reader) (io.Reader, error) {
+	// A single goroutine-free decoder is plenty for a single response.
+	dec, err := zstd.NewReader(stream, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{dec: dec}, nil
+}
+
+// Writer returns a new writer for the stream that adds zstd encoding.
+func (z ZstdEncoding) Writer(stream io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(stream)
+}
+
+// zstdReader closes the zstd decoder as soon as the stream is done, since
+// callers only close the original response body.
+type zstdReader struct {
+	dec *zstd.Decoder
+	err error
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.dec.Read(p)
+	if err != nil {
+		r.err = err
+		r.dec.Close()
+	}
+	return n, err
+}
@@ -6,9 +6,11 @@ import (
 	"compress/gzip"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,6 +38,14 @@ func brEnc(data string) []byte {
 	return b.Bytes()
 }
 
+func zstdEnc(data string) []byte {
+	b := bytes.NewBuffer(nil)
+	w, _ := zstd.NewWriter(b)
+	w.Write([]byte(data))
+	w.Close()
+	return b.Bytes()
+}
+
 var encodingTests = []struct {
 	name   string
 	header string
@@ -45,6 +55,7 @@ var encodingTests = []struct {
 	{"gzip", "gzip", gzipEnc("hello world")},
 	{"deflate", "deflate", deflateEnc("hello world")},
 	{"brotli", "br", brEnc("hello world")},
+	{"zstd", "zstd", zstdEnc("hello world")},
 }
 
 func TestEncodings(parent *testing.T) {
@@ -66,3 +77,22 @@ func TestEncodings(parent *testing.T) {
 		})
 	}
 }
+
+func TestZstdStream(t *testing.T) {
+	// Large responses are decoded as they stream in, and reading past the end
+	// after the decoder is released keeps returning EOF.
+	reset(false)
+	large := strings.Repeat("hello world ", 1<<16)
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"zstd"}},
+		Body:   io.NopCloser(bytes.NewReader(zstdEnc(large))),
+	}
+
+	assert.NoError(t, DecodeResponse(resp))
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(data))
+
+	_, err = resp.Body.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// fanoutRequest makes a single request to one API and fills in its result
+// row. Panics, e.g. from auth handlers, are recorded as errors so one bad API
+// doesn't prevent the others from running.
+func fanoutRequest(uri string, row map[string]any) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			row["error"] = fmt.Sprintf("%v", r)
+		}
+		row["latency"] = time.Since(start).Round(time.Millisecond).String()
+	}()
+
+	req, _ := http.NewRequest(http.MethodGet, uri, nil)
+	parsed, err := GetParsedResponse(req, IgnoreStatus())
+	if err != nil {
+		row["error"] = err.Error()
+		return
+	}
+
+	row["status"] = parsed.Status
+	row["body"] = parsed.Body
+}
+
+// fanout makes the same GET request against the given path of multiple APIs
+// concurrently, prints a combined result labeled by API, and returns an error
+// if any of the requests failed. All configured APIs are used if none are
+// given.
+func fanout(path string, apis []string, concurrency int) error {
+	if strings.Contains(path, "://") {
+		return fmt.Errorf("path must be relative to the API base, e.g. /health")
+	}
+
+	if len(apis) == 0 {
+		for name := range configs {
+			apis = append(apis, name)
+		}
+		sort.Strings(apis)
+	}
+
+	if len(apis) == 0 {
+		return fmt.Errorf("no APIs configured")
+	}
+
+	for _, name := range apis {
+		if configs[name] == nil {
+			return fmt.Errorf("API %s not found", name)
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	rows := make([]map[string]any, len(apis))
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for i, name := range apis {
+		base, err := configs[name].baseFor(viper.GetString("rsh-profile"))
+		uri := strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+		rows[i] = map[string]any{
+			"api":    name,
+			"url":    uri,
+			"status": 0,
+		}
+		if err != nil {
+			rows[i]["error"] = err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(row map[string]any) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fanoutRequest(row["url"].(string), row)
+		}(rows[i])
+	}
+	wg.Wait()
+
+	failed := 0
+	body := make([]any, 0, len(rows))
+	for _, row := range rows {
+		if row["error"] != nil || row["status"].(int) >= 400 {
+			failed++
+		}
+		body = append(body, row)
+	}
+
+	if viper.GetString("rsh-filter") == "" {
+		viper.Set("rsh-filter", "body")
+	}
+
+	if err := Formatter.Format(Response{Status: http.StatusOK, Body: body}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d requests failed", failed, len(rows))
+	}
+
+	return nil
+}
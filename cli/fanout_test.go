@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestFanout(t *testing.T) {
+	defer gock.Off()
+	defer func() {
+		delete(configs, "fanout-a")
+		delete(configs, "fanout-b")
+		delete(configs, "fanout-c")
+	}()
+
+	reset(false)
+	configs["fanout-a"] = &APIConfig{Base: "https://a.example.com"}
+	configs["fanout-b"] = &APIConfig{Base: "https://b.example.com/v1/"}
+	configs["fanout-c"] = &APIConfig{Base: "https://c.example.com"}
+
+	gock.New("https://a.example.com").Get("/health").Reply(200).JSON(map[string]any{"ok": true})
+	gock.New("https://b.example.com").Get("/v1/health").Reply(503).JSON(map[string]any{"ok": false})
+	gock.New("https://c.example.com").Get("/health").ReplyError(assert.AnError)
+
+	out := runNoReset("fanout /health --apis fanout-a,fanout-b,fanout-c -o json")
+	assert.Contains(t, out, "2 of 3 requests failed")
+
+	var rows []map[string]any
+	if !assert.NoError(t, json.NewDecoder(strings.NewReader(out)).Decode(&rows), out) || !assert.Len(t, rows, 3) {
+		return
+	}
+
+	// Results are in the requested order and labeled by API.
+	assert.Equal(t, "fanout-a", rows[0]["api"])
+	assert.Equal(t, "https://a.example.com/health", rows[0]["url"])
+	assert.Equal(t, 200.0, rows[0]["status"])
+	assert.Equal(t, map[string]any{"ok": true}, rows[0]["body"])
+	assert.Nil(t, rows[0]["error"])
+
+	assert.Equal(t, "fanout-b", rows[1]["api"])
+	assert.Equal(t, "https://b.example.com/v1/health", rows[1]["url"])
+	assert.Equal(t, 503.0, rows[1]["status"])
+
+	// One failing API doesn't affect the others.
+	assert.Equal(t, "fanout-c", rows[2]["api"])
+	assert.Equal(t, 0.0, rows[2]["status"])
+	assert.Contains(t, rows[2]["error"], assert.AnError.Error())
+}
+
+func TestFanoutUnknownAPI(t *testing.T) {
+	out := run("fanout /health --apis does-not-exist")
+	assert.Contains(t, out, "API does-not-exist not found")
+}
@@ -1,12 +1,14 @@
 package cli
 
 import (
-	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"image/color"
+	"html"
 	"net/http"
+	"os"
 	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,15 +16,12 @@ import (
 	"unicode/utf8"
 
 	"github.com/alecthomas/chroma"
-	"github.com/alecthomas/chroma/quick"
 	"github.com/alecthomas/chroma/styles"
 	"github.com/charmbracelet/glamour/ansi"
 	"github.com/danielgtaylor/shorthand/v2"
+	"github.com/jmespath/go-jmespath"
 	"github.com/spf13/viper"
 	"golang.org/x/exp/maps"
-	"golang.org/x/term"
-
-	"github.com/eliukblau/pixterm/pkg/ansimage"
 )
 
 // DisplayRanges includes all viewable Unicode characters along with white
@@ -337,6 +336,131 @@ func makeJSONSafe(obj interface{}) interface{} {
 	return obj
 }
 
+// HTML error page condensing
+var (
+	reHTMLTitle    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	reHTMLNoise    = regexp.MustCompile(`(?is)<(head|script|style|noscript|svg)(\s[^>]*)?>.*?</(head|script|style|noscript|svg)>|<!--.*?-->`)
+	reHTMLBreak    = regexp.MustCompile(`(?i)<(br|/p|/div|/h[1-6]|/li|/tr|hr)[^>]*>`)
+	reHTMLTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+	reHTMLSpace    = regexp.MustCompile(`[ \t\r\f\v]+`)
+	maxHTMLSummary = 500
+)
+
+// condenseHTML turns an HTML page (e.g. a gateway error page) into a short
+// plain-text summary consisting of its title and leading text content.
+func condenseHTML(page string) string {
+	title := ""
+	if m := reHTMLTitle.FindStringSubmatch(page); m != nil {
+		title = strings.TrimSpace(html.UnescapeString(reHTMLSpace.ReplaceAllString(m[1], " ")))
+	}
+
+	text := reHTMLNoise.ReplaceAllString(page, "")
+	text = reHTMLBreak.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(reHTMLTag.ReplaceAllString(text, ""))
+
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(reHTMLSpace.ReplaceAllString(line, " "))
+		if line == "" || line == title {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	text = strings.Join(lines, "\n")
+
+	if len(text) > maxHTMLSummary {
+		// Cut at a character boundary so multi-byte text stays valid.
+		cut := maxHTMLSummary
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = strings.TrimSpace(text[:cut]) + "…"
+	}
+
+	if title != "" && text != "" {
+		return title + "\n\n" + text
+	}
+	return title + text
+}
+
+// loadFilterFile reads the filter query from `--rsh-filter-file` and uses
+// it as the `--rsh-filter` value, so every command sees the same filter.
+func loadFilterFile() error {
+	filename := viper.GetString("rsh-filter-file")
+	if filename == "" {
+		return nil
+	}
+
+	if viper.GetString("rsh-filter") != "" {
+		return fmt.Errorf("--rsh-filter and --rsh-filter-file cannot be used together")
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("unable to read filter file: %w", err)
+	}
+
+	filter := strings.TrimSpace(string(data))
+	if filter == "" {
+		return fmt.Errorf("filter file %s is empty", filename)
+	}
+
+	viper.Set("rsh-filter", filter)
+	return nil
+}
+
+// reField matches the simple field paths accepted by `--rsh-fields`, e.g.
+// `id`, `owner.login`, or `tags[0]`.
+var reField = regexp.MustCompile(`^[\w$@-]+(\[-?[0-9]*\])*(\.[\w$@-]+(\[-?[0-9]*\])*)*$`)
+
+// fieldsFilter builds a shorthand projection of the body from comma-separated
+// field paths. Projections apply to each item of an array body. Nested fields
+// are named by their path with `_` in place of `.` so the result stays flat,
+// e.g. for table or CSV output.
+func fieldsFilter(fields string) (string, error) {
+	projection := []string{}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !reField.MatchString(field) {
+			return "", fmt.Errorf("invalid field %q, use -f for complex queries", field)
+		}
+		if strings.Contains(field, ".") {
+			field = strings.ReplaceAll(field, ".", "_") + ": " + field
+		}
+		projection = append(projection, field)
+	}
+
+	if len(projection) == 0 {
+		return "", fmt.Errorf("no fields given")
+	}
+
+	return "body.{" + strings.Join(projection, ", ") + "}", nil
+}
+
+// loadFields turns `--rsh-fields` into the equivalent `--rsh-filter` query.
+func loadFields() error {
+	fields := viper.GetString("rsh-fields")
+	if fields == "" {
+		return nil
+	}
+
+	if viper.GetString("rsh-filter") != "" {
+		return fmt.Errorf("--rsh-fields cannot be used with --rsh-filter or --rsh-filter-file")
+	}
+
+	filter, err := fieldsFilter(fields)
+	if err != nil {
+		return err
+	}
+
+	viper.Set("rsh-filter", filter)
+	viper.Set("rsh-filter-lang", filterShorthand)
+	return nil
+}
+
 // printable returns true if the given body can be printed to a terminal
 // based on displayable unicode character ranges and whitespace. If true,
 // then the body is also returned as a byte slice ready to be written to
@@ -382,8 +506,13 @@ func Highlight(lexer string, data []byte) ([]byte, error) {
 	// Reset indent level used by the `readable` lexer.
 	indentLevel = 0
 
+	// Gron output is made up of Javascript-style assignments.
+	if lexer == "gron" {
+		lexer = "javascript"
+	}
+
 	sb := &strings.Builder{}
-	if err := quick.Highlight(sb, string(data), lexer, "terminal256", "cli-dark"); err != nil {
+	if err := highlight(sb, string(data), lexer); err != nil {
 		return nil, err
 	}
 	return []byte(sb.String()), nil
@@ -412,9 +541,67 @@ func NewDefaultFormatter(tty, color bool) *DefaultFormatter {
 	}
 }
 
-// filterData filters the current response using shorthand query and returns the
-// result.
+// Filter languages, see `--rsh-filter-lang`.
+const (
+	filterShorthand = "shorthand"
+	filterJMESPath  = "jmespath"
+)
+
+// filterLang returns the configured filter language.
+func filterLang() (string, error) {
+	lang := strings.ToLower(viper.GetString("rsh-filter-lang"))
+	switch lang {
+	case "", filterShorthand:
+		return filterShorthand, nil
+	case filterJMESPath:
+		return filterJMESPath, nil
+	}
+	return "", fmt.Errorf("unknown filter language %q, expected one of [%s, %s]", lang, filterShorthand, filterJMESPath)
+}
+
+// queryData runs the filter against the data using the configured filter
+// language and returns the result.
+func queryData(filter string, data any) (any, error) {
+	lang, err := filterLang()
+	if err != nil {
+		return nil, err
+	}
+
+	if lang == filterJMESPath {
+		// JMESPath works on the JSON data model, e.g. all numbers are floats and
+		// maps have string keys, so round-trip through JSON first.
+		b, err := json.Marshal(makeJSONSafe(data))
+		if err != nil {
+			return nil, err
+		}
+		var doc any
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		result, err := jmespath.Search(filter, doc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JMESPath filter: %w", err)
+		}
+		return result, nil
+	}
+
+	opts := shorthand.GetOptions{}
+	if enableVerbose {
+		opts.DebugLogger = LogDebug
+	}
+
+	result, _, err := shorthand.GetPath(filter, data, opts)
+	return result, err
+}
+
+// filterData filters the current response using the configured filter
+// language and returns the result.
 func (f *DefaultFormatter) filterData(filter string, data map[string]any) (any, error) {
+	if lang, err := filterLang(); err != nil || lang == filterJMESPath {
+		// JMESPath expressions like `length(body)` needn't start with a field.
+		return queryData(filter, data)
+	}
+
 	keys := maps.Keys(data)
 	sort.Strings(keys)
 	found := strings.HasPrefix(filter, "*") || strings.HasPrefix(filter, "..") || strings.HasPrefix(filter, "{")
@@ -437,13 +624,7 @@ func (f *DefaultFormatter) filterData(filter string, data map[string]any) (any,
 		return nil, fmt.Errorf("filter must begin with one of '%v' and use '.' delimiters", strings.Join(keys, "', '"))
 	}
 
-	opts := shorthand.GetOptions{}
-	if enableVerbose {
-		opts.DebugLogger = LogDebug
-	}
-
-	result, _, err := shorthand.GetPath(filter, data, opts)
-	return result, err
+	return queryData(filter, data)
 }
 
 func (f *DefaultFormatter) formatRaw(data any) ([]byte, string, bool) {
@@ -538,25 +719,35 @@ func (f *DefaultFormatter) formatAuto(format string, resp Response) ([]byte, err
 	}
 
 	ct := resp.Headers["Content-Type"]
-	if resp.Body != nil && (ct == "image/png" || ct == "image/jpeg" || ct == "image/webp" || ct == "image/gif") {
+	if resp.Body != nil && isImage(ct) {
 		if b, ok := resp.Body.([]byte); ok {
-			// This is likely an image. Let's display it if we can! Get the window
-			// size, read and scale the image, and display it using unicode.
-			w, h, err := term.GetSize(0)
+			// This is likely an image. Let's display it if we can!
+			mode, err := imageMode(f.tty)
 			if err != nil {
-				// Default to standard terminal size
-				w, h = 80, 24
+				return nil, err
 			}
 
-			image, err := ansimage.NewScaledFromReader(bytes.NewReader(b), h*2, w*1, color.Transparent, ansimage.ScaleModeFit, ansimage.NoDithering)
+			if mode == imageNone {
+				return encoded, nil
+			}
+
+			rendered, err := renderImage(mode, ct, b)
 			if err == nil {
-				return append(encoded, f.nl([]byte(image.Render()))...), nil
+				return append(encoded, f.nl(rendered)...), nil
 			} else {
 				LogWarning("Unable to display image: %v", err)
 			}
 		}
 	}
 
+	if page, ok := resp.Body.(string); ok && resp.Status >= 400 && strings.HasPrefix(ct, "text/html") && !viper.GetBool("rsh-raw") {
+		// Proxies and gateways often return HTML error pages even when the
+		// client asked for structured data. Show a condensed summary instead
+		// of dumping markup; raw output mode still shows the full page.
+		LogDebug("Condensing HTML error page, use --rsh-raw to see the full page")
+		return append(encoded, f.nl([]byte(condenseHTML(page)))...), nil
+	}
+
 	if b, ok := printable(resp.Body); ok {
 		return append(encoded, f.nl(b)...), nil
 	}
@@ -595,6 +786,11 @@ func (f *DefaultFormatter) Format(resp Response) error {
 	outFormat := viper.GetString("rsh-output-format")
 	filter := viper.GetString("rsh-filter")
 
+	if outFormat == "template" && filter == "" {
+		// Templates get the full response, e.g. `{{.status}} {{.body.name}}`.
+		filter = "@"
+	}
+
 	// Special case: raw response output mode. The response wasn't decoded so we
 	// have a bunch of bytes and the user asked for raw output, so just write it.
 	// This enables completely bypassing decoding and file downloads.
@@ -615,7 +811,8 @@ func (f *DefaultFormatter) Format(resp Response) error {
 			outFormat = "json"
 		}
 	}
-	if !f.tty && filter == "" {
+	if (!f.tty || outFormat == "html") && filter == "" {
+		// HTML is a standalone page of just the body, even in a terminal.
 		filter = "body"
 	}
 
@@ -639,10 +836,17 @@ func (f *DefaultFormatter) Format(resp Response) error {
 	var lexer string
 	handled := false
 
+	if outFormat == "template" {
+		// Custom text output rendered from a Go template. Nothing is colorized
+		// since the template controls the output.
+		encoded, err = renderTemplate(data)
+		handled = true
+	}
+
 	// Special case: raw output with scalars or an array of scalars. This enables
 	// shell-friendly output without quotes or with each item on its own line
 	// which is easy to use in e.g. bash `for` loops.
-	if viper.GetBool("rsh-raw") {
+	if viper.GetBool("rsh-raw") && !handled {
 		var ok bool
 		if encoded, lexer, ok = f.formatRaw(data); ok {
 			handled = true
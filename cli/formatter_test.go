@@ -3,7 +3,9 @@ package cli
 import (
 	"bytes"
 	"encoding/base64"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -46,6 +48,8 @@ var formatterTests = []struct {
 	raw     bool
 	format  string
 	filter  string
+	lang    string
+	status  int
 	headers map[string]string
 	body    any
 	result  any
@@ -76,6 +80,27 @@ var formatterTests = []struct {
 		body:   []byte{},
 		result: []byte{0x20, 0x30, 0x20, 0xa, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x2d, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x3a, 0x20, 0x30, 0xa, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x2d, 0x54, 0x79, 0x70, 0x65, 0x3a, 0x20, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x2f, 0x70, 0x6e, 0x67, 0xa, 0xa},
 	},
+	{
+		name:   "html-error-page",
+		tty:    true,
+		status: 502,
+		headers: map[string]string{
+			"Content-Type": "text/html; charset=utf-8",
+		},
+		body:   "<html><head><title>502 Bad Gateway</title><style>h1 { color: red; }</style></head><body><center><h1>502 Bad Gateway</h1></center><hr><p>nginx &amp; friends</p></body></html>",
+		result: " 502 Bad Gateway\nContent-Type: text/html; charset=utf-8\n\n502 Bad Gateway\n\nnginx & friends\n",
+	},
+	{
+		name:   "html-error-page-raw",
+		tty:    true,
+		raw:    true,
+		status: 502,
+		headers: map[string]string{
+			"Content-Type": "text/html",
+		},
+		body:   "<html><body><h1>Oops</h1></body></html>",
+		result: " 502 Bad Gateway\nContent-Type: text/html\n\n<html><body><h1>Oops</h1></body></html>\n",
+	},
 	{
 		name:   "json-pretty-explicit-full",
 		tty:    true,
@@ -185,6 +210,27 @@ var formatterTests = []struct {
 		body:   map[string]any{"example": true},
 		result: "example: true\n",
 	},
+	{
+		name:   "redirect-gron",
+		format: "gron",
+		body:   map[string]any{"items": []any{map[string]any{"id": 1}}},
+		result: "body = {};\nbody.items = [];\nbody.items[0] = {};\nbody.items[0].id = 1;\n",
+	},
+	{
+		name:   "tty-gron-filtered",
+		tty:    true,
+		format: "gron",
+		filter: "body",
+		body:   map[string]any{"id": "a"},
+		result: "body = {};\nbody.id = \"a\";\n",
+	},
+	{
+		name:   "tty-html-body",
+		tty:    true,
+		format: "html",
+		body:   []any{map[string]any{"id": 1}},
+		result: "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Restish Response</title>\n<style>\n" + htmlPageStyle + "</style>\n</head>\n<body>\n<table>\n<thead>\n<tr><th>id</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td></tr>\n</tbody>\n</table>\n</body>\n</html>\n",
+	},
 	{
 		name:   "error-prefix",
 		filter: "boby.id", // should be body.id
@@ -197,6 +243,36 @@ var formatterTests = []struct {
 		body:   map[string]any{"id": 123},
 		err:    "expected '.'",
 	},
+	{
+		name:   "jmespath-projection",
+		format: "json",
+		filter: "body.items[?id > `1`].name",
+		lang:   "jmespath",
+		body:   map[string]any{"items": []any{map[string]any{"id": 1, "name": "a"}, map[string]any{"id": int64(2), "name": "b"}}},
+		result: "[\n  \"b\"\n]\n",
+	},
+	{
+		name:   "jmespath-function",
+		format: "json",
+		filter: "length(body)",
+		lang:   "JMESPath",
+		body:   []any{1, 2, 3},
+		result: "3\n",
+	},
+	{
+		name:   "jmespath-invalid",
+		filter: "body.[",
+		lang:   "jmespath",
+		body:   map[string]any{"id": 123},
+		err:    "invalid JMESPath filter",
+	},
+	{
+		name:   "unknown-lang",
+		filter: "body.id",
+		lang:   "jq",
+		body:   map[string]any{"id": 123},
+		err:    "unknown filter language",
+	},
 }
 
 func TestFormatter(t *testing.T) {
@@ -208,12 +284,16 @@ func TestFormatter(t *testing.T) {
 			viper.Reset()
 			viper.Set("rsh-raw", input.raw)
 			viper.Set("rsh-filter", input.filter)
+			viper.Set("rsh-filter-lang", input.lang)
+			// Don't depend on the terminal running the tests.
+			viper.Set("rsh-image-mode", imageANSI)
 			if input.format != "" {
 				viper.Set("rsh-output-format", input.format)
 			} else {
 				viper.Set("rsh-output-format", "auto")
 			}
 			err := formatter.Format(Response{
+				Status:  input.status,
 				Headers: input.headers,
 				Body:    input.body,
 			})
@@ -231,3 +311,29 @@ func TestFormatter(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldsFilter(t *testing.T) {
+	filter, err := fieldsFilter("id, owner.login,,tags[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, "body.{id, owner_login: owner.login, tags[0]}", filter)
+
+	_, err = fieldsFilter(" , ")
+	assert.EqualError(t, err, "no fields given")
+
+	_, err = fieldsFilter("id,{name}")
+	assert.EqualError(t, err, `invalid field "{name}", use -f for complex queries`)
+}
+
+func TestCondenseHTMLMultiByte(t *testing.T) {
+	// Each character is 3 bytes, so the byte limit falls mid-character.
+	summary := condenseHTML("<p>" + strings.Repeat("错", maxHTMLSummary) + "</p>")
+	assert.True(t, utf8.ValidString(summary))
+	assert.Equal(t, strings.Repeat("错", maxHTMLSummary/3)+"…", summary)
+}
+
+func TestHighlightGron(t *testing.T) {
+	// Gron output is colorized like Javascript rather than as plain text.
+	out, err := Highlight("gron", []byte("body.id = 1;\n"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "\x1b[")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// GraphQLRequest returns a request which POSTs a GraphQL query and its
+// variables to the given URL.
+func GraphQLRequest(uri, query string, variables map[string]any) (*http.Request, error) {
+	if variables == nil {
+		variables = map[string]any{}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json;q=0.9")
+
+	return req, nil
+}
+
+// graphQLVariables parses shorthand arguments or stdin into query variables.
+func graphQLVariables(args []string) (map[string]any, error) {
+	body, err := GetBody("application/json", args)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]any{}
+	if strings.TrimSpace(body) == "" {
+		return variables, nil
+	}
+
+	if err := json.Unmarshal([]byte(body), &variables); err != nil {
+		return nil, fmt.Errorf("GraphQL variables must be an object: %w", err)
+	}
+
+	return variables, nil
+}
+
+// graphQL sends a query with optional shorthand variables to a GraphQL
+// endpoint, formatting the response. Queries starting with `@` are loaded
+// from a file, e.g. `@query.graphql`.
+func graphQL(uri, query string, args []string) error {
+	if strings.HasPrefix(query, "@") {
+		b, err := os.ReadFile(query[1:])
+		if err != nil {
+			return err
+		}
+		query = string(b)
+	}
+
+	variables, err := graphQLVariables(args)
+	if err != nil {
+		return err
+	}
+
+	req, err := GraphQLRequest(fixAddress(uri), query, variables)
+	if err != nil {
+		return err
+	}
+
+	MakeRequestAndFormat(req)
+	return nil
+}
+
+// graphQLOperation sends the GraphQL document of an operation. Options which
+// were passed become variables, and any body input is merged on top of them.
+func (o Operation) graphQLOperation(cmd *cobra.Command, flags map[string]interface{}, uri string, args []string) error {
+	variables, err := graphQLVariables(args)
+	if err != nil {
+		return err
+	}
+
+	for _, param := range o.QueryParams {
+		if !cmd.Flags().Changed(param.OptionName()) {
+			continue
+		}
+
+		if _, ok := variables[param.Name]; !ok {
+			variables[param.Name] = reflect.ValueOf(flags[param.Name]).Elem().Interface()
+		}
+	}
+
+	req, err := GraphQLRequest(uri, o.GraphQL, variables)
+	if err != nil {
+		return err
+	}
+
+	MakeRequestAndFormat(req)
+	return nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestGraphQLCommand(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Post("/graphql").
+		MatchType("json").
+		JSON(map[string]any{
+			"query":     "query($id: ID!) { user(id: $id) { name } }",
+			"variables": map[string]any{"id": "abc"},
+		}).
+		Reply(http.StatusOK).
+		JSON(map[string]any{"data": map[string]any{"user": map[string]any{"name": "Alice"}}})
+
+	// Queries can be loaded from a file.
+	filename := filepath.Join(t.TempDir(), "user.graphql")
+	os.WriteFile(filename, []byte("query($id: ID!) { user(id: $id) { name } }"), 0o600)
+
+	out := run("graphql http://example.com/graphql @" + filename + " id: abc -f body.data.user.name -r")
+	assert.Equal(t, "Alice\n", out)
+	assert.True(t, gock.IsDone())
+}
+
+func TestGraphQLVariablesObject(t *testing.T) {
+	_, err := graphQLVariables([]string{"[1, 2]"})
+	assert.ErrorContains(t, err, "must be an object")
+}
+
+func TestGraphQLOperation(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Post("/graphql").
+		JSON(map[string]any{
+			"query": "mutation($role: Role, $input: UserInput!) { createUser(role: $role, input: $input) { id } }",
+			"variables": map[string]any{
+				"role":  "ADMIN",
+				"input": map[string]any{"name": "Alice"},
+			},
+		}).
+		Reply(http.StatusOK).
+		JSON(map[string]any{"data": map[string]any{"createUser": map[string]any{"id": "1"}}})
+
+	op := Operation{
+		Name:          "create-user",
+		Method:        http.MethodPost,
+		URITemplate:   "http://example.com/graphql",
+		BodyMediaType: "application/json",
+		QueryParams: []*Param{
+			{Type: "string", Name: "role"},
+			{Type: "integer", Name: "first"},
+		},
+		GraphQL: "mutation($role: Role, $input: UserInput!) { createUser(role: $role, input: $input) { id } }",
+	}
+
+	cmd := op.command()
+
+	viper.Reset()
+	viper.Set("nocolor", true)
+	Init("test", "1.0.0")
+	Defaults()
+	capture := &strings.Builder{}
+	Stdout = capture
+	Stderr = capture
+	viper.Set("rsh-filter", "body.data.createUser.id")
+	viper.Set("rsh-raw", true)
+	cmd.Flags().Parse([]string{"--role=ADMIN"})
+	cmd.Run(cmd, []string{"input.name: Alice"})
+
+	assert.Equal(t, "1\n", capture.String())
+	assert.True(t, gock.IsDone())
+}
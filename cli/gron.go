@@ -5,11 +5,17 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/spf13/viper"
 )
 
 // PathBuffer builds up a path string from multiple parts.
@@ -183,3 +189,173 @@ func marshalGron(pb *PathBuffer, data any, isAnon bool, out []byte) ([]byte, err
 
 	return out, nil
 }
+
+// parseGronPath parses a path like `body.items[0]["weird key"]` into its
+// keys and array indexes. The leading root name, e.g. `body`, is discarded.
+// Returns the path and the rest of the line after it.
+func parseGronPath(line string) ([]any, string, error) {
+	path := []any{}
+
+	// identEnd returns the end of an identifier starting at i.
+	identEnd := func(i int) int {
+		for i < len(line) && line[i] != '.' && line[i] != '[' && line[i] != ' ' && line[i] != '=' {
+			i++
+		}
+		return i
+	}
+
+	i := identEnd(0)
+	if i == 0 {
+		return nil, "", fmt.Errorf("missing path")
+	}
+
+	for i < len(line) {
+		switch line[i] {
+		case '.':
+			end := identEnd(i + 1)
+			if end == i+1 {
+				return nil, "", fmt.Errorf("empty key at position %d", i)
+			}
+			path = append(path, line[i+1:end])
+			i = end
+		case '[':
+			if strings.HasPrefix(line[i:], `["`) {
+				// Quoted key, where only quotes are escaped.
+				key := strings.Builder{}
+				j := i + 2
+				for ; j < len(line); j++ {
+					if line[j] == '\\' && j+1 < len(line) && line[j+1] == '"' {
+						key.WriteByte('"')
+						j++
+						continue
+					}
+					if line[j] == '"' {
+						break
+					}
+					key.WriteByte(line[j])
+				}
+				if !strings.HasPrefix(line[j:], `"]`) {
+					return nil, "", fmt.Errorf("unterminated key at position %d", i)
+				}
+				path = append(path, key.String())
+				i = j + 2
+			} else {
+				end := strings.IndexByte(line[i:], ']')
+				if end < 0 {
+					return nil, "", fmt.Errorf("unterminated index at position %d", i)
+				}
+				index, err := strconv.Atoi(line[i+1 : i+end])
+				if err != nil || index < 0 {
+					return nil, "", fmt.Errorf("invalid index %s", line[i:i+end+1])
+				}
+				path = append(path, index)
+				i += end + 1
+			}
+		default:
+			return path, line[i:], nil
+		}
+	}
+
+	return path, "", nil
+}
+
+// ungronSet sets the value at the given path within current, creating any
+// missing objects and arrays along the way, and returns the updated value.
+func ungronSet(current any, path []any, value any) any {
+	if len(path) == 0 {
+		// Don't replace existing values with empty containers, e.g. when lines
+		// are out of order.
+		switch value.(type) {
+		case map[string]any:
+			if _, ok := current.(map[string]any); ok {
+				return current
+			}
+		case []any:
+			if _, ok := current.([]any); ok {
+				return current
+			}
+		}
+		return value
+	}
+
+	switch key := path[0].(type) {
+	case int:
+		list, _ := current.([]any)
+		for len(list) <= key {
+			list = append(list, nil)
+		}
+		list[key] = ungronSet(list[key], path[1:], value)
+		return list
+	default:
+		m, ok := current.(map[string]any)
+		if !ok {
+			m = map[string]any{}
+		}
+		m[key.(string)] = ungronSet(m[key.(string)], path[1:], value)
+		return m
+	}
+}
+
+// unmarshalGron reconstructs a value from gron lines like `body.id = 1;`.
+// Filtered output, e.g. from `grep`, results in a partial value with any
+// missing array items set to `null`.
+func unmarshalGron(data []byte) (any, error) {
+	var result any
+
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		path, rest, err := parseGronPath(line)
+		if err == nil && !strings.HasPrefix(rest, " = ") {
+			err = fmt.Errorf("expected ' = '")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid gron on line %d: %w", n+1, err)
+		}
+
+		var value any
+		switch raw := strings.TrimSuffix(strings.TrimSpace(rest[3:]), ";"); raw {
+		case "{}":
+			value = map[string]any{}
+		case "[]":
+			value = []any{}
+		default:
+			if err := json.Unmarshal([]byte(raw), &value); err != nil {
+				return nil, fmt.Errorf("invalid gron value on line %d: %w", n+1, err)
+			}
+		}
+
+		result = ungronSet(result, path, value)
+	}
+
+	return result, nil
+}
+
+// ungron reads gron lines from a file or stdin and prints the reconstructed
+// value.
+func ungron(filename string) error {
+	var data []byte
+	var err error
+	if filename == "" || filename == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	value, err := unmarshalGron(data)
+	if err != nil {
+		return err
+	}
+
+	if viper.GetString("rsh-filter") == "" {
+		viper.Set("rsh-filter", "body")
+	}
+
+	return Formatter.Format(Response{Status: http.StatusOK, Body: value})
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -71,3 +73,59 @@ body.d[5]["name\"with"] = "quote";
 	})
 	assert.Error(t, err)
 }
+
+func TestGronRoundTrip(t *testing.T) {
+	value := map[string]any{
+		"a": "hello = world;",
+		"b": []any{1.0, nil, map[string]any{"c": true}},
+		"d": map[string]any{
+			"dotted.name":        "foo",
+			"name[with]brackets": "bar",
+			"name\"with":         "quote",
+			"empty":              map[string]any{},
+		},
+	}
+
+	b, err := Gron{}.Marshal(value)
+	assert.NoError(t, err)
+
+	result, err := unmarshalGron(b)
+	assert.NoError(t, err)
+	assert.Equal(t, value, result)
+}
+
+func TestUngronPartial(t *testing.T) {
+	// Lines filtered with e.g. `grep` leave gaps in arrays.
+	result, err := unmarshalGron([]byte(`json.items[2].name = "c";
+json.items[0].name = "a";
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"items": []any{
+			map[string]any{"name": "a"},
+			nil,
+			map[string]any{"name": "c"},
+		},
+	}, result)
+}
+
+func TestUngronErrors(t *testing.T) {
+	for _, input := range []string{
+		`body.a 1;`,
+		`body.a = nope;`,
+		`body["unterminated = 1;`,
+		`body[x] = 1;`,
+		` = 1;`,
+	} {
+		_, err := unmarshalGron([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestUngronCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "input.gron")
+	assert.NoError(t, os.WriteFile(filename, []byte("body = {};\nbody.id = 1;\nbody.tags[0] = \"a\";\n"), 0600))
+
+	out := run("ungron -o json " + filename)
+	assert.JSONEq(t, `{"id": 1, "tags": ["a"]}`, out)
+}
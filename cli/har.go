@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+// HAR 1.2 types, see http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+
+	// body captures the raw response body as it is read.
+	body *harBody
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harBody wraps a response body to capture its contents and the time it took
+// to receive as it is read by the rest of the CLI.
+type harBody struct {
+	io.ReadCloser
+	entry *harEntry
+	start time.Time
+	buf   bytes.Buffer
+	done  bool
+
+	// contentEncoding is used to decode the captured body, e.g. `gzip`.
+	contentEncoding string
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	harMu.Lock()
+	defer harMu.Unlock()
+	b.buf.Write(p[:n])
+	if err != nil && !b.done {
+		b.finish()
+	}
+
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	harMu.Lock()
+	if !b.done {
+		b.finish()
+	}
+	harMu.Unlock()
+
+	return b.ReadCloser.Close()
+}
+
+// finish records the receive time. Must be called with the lock held.
+func (b *harBody) finish() {
+	b.done = true
+	b.entry.Timings.Receive = millis(time.Since(b.start))
+	b.entry.Time = b.entry.Timings.Wait + b.entry.Timings.Receive
+}
+
+var (
+	harMu      sync.Mutex
+	harEntries []*harEntry
+)
+
+// millis converts a duration to fractional milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// harEnabled returns whether requests should be recorded to a HAR file.
+func harEnabled() bool {
+	return viper.GetString("rsh-har") != ""
+}
+
+// harPairs converts headers or query params to HAR name/value pairs, sorted by
+// name for stable output.
+func harPairs(values map[string][]string) []harNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := []harNameValue{}
+	for _, name := range names {
+		for _, value := range values[name] {
+			result = append(result, harNameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+// harCookies converts cookies to HAR name/value pairs.
+func harCookies(cookies []*http.Cookie) []harNameValue {
+	result := []harNameValue{}
+	for _, c := range cookies {
+		result = append(result, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
+
+// recordHAR records a request/response pair. The request body must be passed
+// in since it has already been sent, and the response body is captured as it
+// is read.
+func recordHAR(req *http.Request, reqBody []byte, resp *http.Response, start time.Time) {
+	entry := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harPairs(req.Header),
+			QueryString: harPairs(req.URL.Query()),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			HTTPVersion: resp.Proto,
+			Cookies:     harCookies(resp.Cookies()),
+			Headers:     harPairs(resp.Header),
+			Content: harContent{
+				MimeType: resp.Header.Get("Content-Type"),
+			},
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+		},
+		Timings: harTimings{
+			Wait: millis(time.Since(start)),
+		},
+	}
+	entry.Time = entry.Timings.Wait
+
+	if req.Proto == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+
+	// The status looks like `200 OK`.
+	if _, text, ok := strings.Cut(resp.Status, " "); ok {
+		entry.Response.StatusText = text
+	}
+
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+
+	if resp.Body != nil {
+		entry.body = &harBody{
+			ReadCloser:      resp.Body,
+			entry:           entry,
+			start:           time.Now(),
+			contentEncoding: resp.Header.Get("Content-Encoding"),
+		}
+		resp.Body = entry.body
+	}
+
+	harMu.Lock()
+	harEntries = append(harEntries, entry)
+	harMu.Unlock()
+}
+
+// harContentFor fills in the response content from the captured body,
+// decoding it if needed. Binary content is base64 encoded.
+func harContentFor(entry *harEntry) {
+	if entry.body == nil {
+		return
+	}
+
+	raw := entry.body.buf.Bytes()
+	entry.Response.BodySize = len(raw)
+
+	decoded := raw
+	if name := entry.body.contentEncoding; name != "" {
+		if encoding := encodings[name]; encoding != nil {
+			if reader, err := encoding.Reader(bytes.NewReader(raw)); err == nil {
+				if d, err := io.ReadAll(reader); err == nil {
+					decoded = d
+				}
+			}
+		}
+	}
+
+	entry.Response.Content.Size = len(decoded)
+	if utf8.Valid(decoded) {
+		entry.Response.Content.Text = string(decoded)
+	} else {
+		entry.Response.Content.Text = base64.StdEncoding.EncodeToString(decoded)
+		entry.Response.Content.Encoding = "base64"
+	}
+}
+
+// resetHAR clears any recorded requests.
+func resetHAR() {
+	harMu.Lock()
+	harEntries = nil
+	harMu.Unlock()
+}
+
+// writeHAR writes all recorded requests to a HAR file.
+func writeHAR(filename string) error {
+	harMu.Lock()
+	defer harMu.Unlock()
+
+	for _, entry := range harEntries {
+		harContentFor(entry)
+	}
+
+	entries := harEntries
+	if entries == nil {
+		entries = []*harEntry{}
+	}
+
+	data, err := json.MarshalIndent(harFile{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: Root.Name(), Version: Root.Version},
+			Entries: entries,
+		},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestHAR(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "out.har")
+
+	gock.New("http://example.com").
+		Get("/items").
+		MatchParam("q", "test").
+		Reply(200).
+		SetHeader("Link", "</items2>; rel=\"next\"").
+		JSON([]any{1})
+
+	gock.New("http://example.com").
+		Get("/items2").
+		Reply(200).
+		JSON([]any{2})
+
+	run("http://example.com/items?q=test --rsh-har " + filename)
+	expectExitCode(t, 0)
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+
+	var har harFile
+	assert.NoError(t, json.Unmarshal(data, &har))
+
+	// Each page of the auto-paginated response is recorded.
+	assert.Equal(t, "1.2", har.Log.Version)
+	if !assert.Len(t, har.Log.Entries, 2) {
+		return
+	}
+
+	first := har.Log.Entries[0]
+	assert.Equal(t, "GET", first.Request.Method)
+	assert.Equal(t, "http://example.com/items?q=test", first.Request.URL)
+	assert.Equal(t, []harNameValue{{Name: "q", Value: "test"}}, first.Request.QueryString)
+	assert.Equal(t, 200, first.Response.Status)
+	assert.Equal(t, "application/json", first.Response.Content.MimeType)
+	assert.JSONEq(t, "[1]", first.Response.Content.Text)
+
+	assert.Equal(t, "http://example.com/items2", har.Log.Entries[1].Request.URL)
+	assert.JSONEq(t, "[2]", har.Log.Entries[1].Response.Content.Text)
+}
+
+func TestHARRequestBody(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "out.har")
+
+	gock.New("http://example.com").
+		Post("/items").
+		BodyString(`{"id":1}`).
+		Reply(204)
+
+	run("post http://example.com/items id: 1 --rsh-har " + filename)
+	expectExitCode(t, 0)
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+
+	var har harFile
+	assert.NoError(t, json.Unmarshal(data, &har))
+	if !assert.Len(t, har.Log.Entries, 1) || !assert.NotNil(t, har.Log.Entries[0].Request.PostData) {
+		return
+	}
+
+	entry := har.Log.Entries[0]
+	assert.Equal(t, "application/json; charset=utf-8", entry.Request.PostData.MimeType)
+	assert.JSONEq(t, `{"id":1}`, entry.Request.PostData.Text)
+	assert.Equal(t, 204, entry.Response.Status)
+	assert.Equal(t, "No Content", entry.Response.StatusText)
+}
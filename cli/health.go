@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// healthPaths returns the configured health check paths or URLs for an API,
+// defaulting to `/health`.
+func healthPaths(config *APIConfig) []string {
+	if len(config.Health) > 0 {
+		return config.Health
+	}
+	return []string{"/health"}
+}
+
+// checkHealth makes a request to each of an API's health check endpoints for
+// every configured profile and returns a row per check. A check is `up` if
+// it returns a 2xx status code.
+func checkHealth(name string, config *APIConfig) []map[string]any {
+	profiles := []string{}
+	for p := range config.Profiles {
+		profiles = append(profiles, p)
+	}
+	if len(profiles) == 0 {
+		profiles = append(profiles, "default")
+	}
+	sort.Strings(profiles)
+
+	// Each check uses its profile's auth, headers, etc.
+	origProfile := viper.GetString("rsh-profile")
+	origAPIName := viper.GetString("api-name")
+	defer func() {
+		viper.Set("rsh-profile", origProfile)
+		viper.Set("api-name", origAPIName)
+	}()
+	viper.Set("api-name", name)
+
+	rows := []map[string]any{}
+	for _, profile := range profiles {
+		viper.Set("rsh-profile", profile)
+
+		base, err := config.baseFor(profile)
+		if err != nil {
+			// The base URL can't be resolved, so every check is down.
+			LogWarning("Health check for profile %s failed: %v", profile, err)
+			rows = append(rows, map[string]any{
+				"profile": profile,
+				"url":     "",
+				"status":  "down",
+				"code":    0,
+				"latency": "0s",
+			})
+			continue
+		}
+
+		for _, path := range healthPaths(config) {
+			uri := path
+			if !strings.Contains(path, "://") {
+				uri = strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+			}
+
+			row := map[string]any{
+				"profile": profile,
+				"url":     uri,
+				"status":  "down",
+			}
+
+			start := time.Now()
+			code, err := healthRequest(uri)
+			row["latency"] = time.Since(start).Round(time.Millisecond).String()
+			if err != nil {
+				LogWarning("Health check %s failed: %v", uri, err)
+			}
+			row["code"] = code
+			if code >= 200 && code < 300 {
+				row["status"] = "up"
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// healthRequest makes a single health check request and returns the response
+// status code. Panics, e.g. from auth handlers, are returned as errors so one
+// bad check doesn't prevent the others from running.
+func healthRequest(uri string) (code int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	req, _ := http.NewRequest(http.MethodGet, uri, nil)
+	resp, err := MakeRequest(req, IgnoreStatus())
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// health runs the health checks for an API, prints a status table, and
+// returns an error if any of the checks are down.
+func health(name string) error {
+	config := configs[name]
+	if config == nil {
+		return fmt.Errorf("API %s not found", name)
+	}
+
+	rows := checkHealth(name, config)
+
+	down := 0
+	body := make([]any, 0, len(rows))
+	for _, row := range rows {
+		if row["status"] != "up" {
+			down++
+		}
+		body = append(body, row)
+	}
+
+	// Default to a compact table rather than the usual response output.
+	if viper.GetString("rsh-output-format") == "auto" {
+		viper.Set("rsh-output-format", "table")
+	}
+	if viper.GetString("rsh-filter") == "" {
+		viper.Set("rsh-filter", "body")
+	}
+
+	if err := Formatter.Format(Response{Status: http.StatusOK, Body: body}); err != nil {
+		return err
+	}
+
+	if down > 0 {
+		return fmt.Errorf("%d of %d health checks failed", down, len(rows))
+	}
+
+	return nil
+}
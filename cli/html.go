@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// htmlPageStyle styles the standalone HTML page and tables.
+const htmlPageStyle = `body { background: #1c1c1c; color: #d0d0d0; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; margin: 2em; }
+pre { padding: 1em; border-radius: 4px; overflow-x: auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #444; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #303030; }
+tr:nth-child(even) td { background: #262626; }
+`
+
+// HTML describes an output format for standalone HTML pages, e.g. to share
+// results with others. Arrays of objects become tables and anything else is
+// shown as syntax-highlighted JSON.
+type HTML struct{}
+
+// Detect if the content type is HTML.
+func (h HTML) Detect(contentType string) bool {
+	return false
+}
+
+// Marshal the value to an HTML page.
+func (h HTML) Marshal(value interface{}) ([]byte, error) {
+	value = makeJSONSafe(value)
+
+	var content []byte
+	list, _ := value.([]any)
+	rows, headers, err := objectRows(list)
+	if len(list) > 0 && err == nil {
+		content, err = htmlTable(rows, headers)
+	} else {
+		content, err = htmlJSON(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Restish Response</title>\n<style>\n")
+	buf.WriteString(htmlPageStyle)
+	buf.WriteString("</style>\n</head>\n<body>\n")
+	buf.Write(content)
+	buf.WriteString("</body>\n</html>\n")
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal the value from an HTML page.
+func (h HTML) Unmarshal(data []byte, value interface{}) error {
+	return fmt.Errorf("unimplemented")
+}
+
+// htmlCell returns the escaped text for a table cell. Nested values are shown
+// as compact JSON.
+func htmlCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return html.EscapeString(v), nil
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return html.EscapeString(string(encoded)), nil
+	}
+	return html.EscapeString(fmt.Sprintf("%v", value)), nil
+}
+
+// htmlTable renders a list of objects as a table with a column for every key.
+func htmlTable(rows []map[string]any, headers []string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("<table>\n<thead>\n<tr>")
+	for _, h := range headers {
+		buf.WriteString("<th>" + html.EscapeString(h) + "</th>")
+	}
+	buf.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, row := range rows {
+		buf.WriteString("<tr>")
+		for _, h := range headers {
+			cell, err := htmlCell(row[h])
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString("<td>" + cell + "</td>")
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</tbody>\n</table>\n")
+
+	return buf.Bytes(), nil
+}
+
+// htmlJSON renders a value as JSON highlighted using the same colors as the
+// terminal output.
+func htmlJSON(value any) ([]byte, error) {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := lexers.Get("json").Tokenise(nil, string(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	style, err := themeStyle()
+	if err != nil {
+		return nil, err
+	}
+	if style == nil {
+		// Plain black & white for the `none` theme.
+		style = styles.Get("bw")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := chromahtml.New().Format(buf, style, iterator); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLTable(t *testing.T) {
+	out, err := HTML{}.Marshal([]any{
+		map[string]any{"id": 1, "name": "<b>"},
+		map[string]any{"id": 2, "tags": []any{"a", "b"}},
+	})
+	assert.NoError(t, err)
+
+	page := string(out)
+	assert.Contains(t, page, "<!DOCTYPE html>")
+	assert.Contains(t, page, "<tr><th>id</th><th>name</th><th>tags</th></tr>")
+	assert.Contains(t, page, "<tr><td>1</td><td>&lt;b&gt;</td><td></td></tr>")
+	assert.Contains(t, page, "<tr><td>2</td><td></td><td>[&#34;a&#34;,&#34;b&#34;]</td></tr>")
+	assert.Contains(t, page, "</html>\n")
+}
+
+func TestHTMLJSON(t *testing.T) {
+	out, err := HTML{}.Marshal(map[string]any{"hello": "world"})
+	assert.NoError(t, err)
+
+	// Highlighted with inline styles so the page is self-contained.
+	page := string(out)
+	assert.Contains(t, page, "<pre")
+	assert.Contains(t, page, `<span style=`)
+	assert.Contains(t, page, "&#34;world&#34;")
+	assert.NotContains(t, page, "<table>")
+}
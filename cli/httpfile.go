@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/danielgtaylor/shorthand/v2"
+)
+
+// httpFileVarRegex matches file variable definitions like `@host = example.com`.
+var httpFileVarRegex = regexp.MustCompile(`^@([A-Za-z0-9_.-]+)\s*=\s*(.*)$`)
+
+// httpFileNameRegex matches request names like `# @name login`.
+var httpFileNameRegex = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\S+)`)
+
+// httpFileRefRegex matches variable references like `{{host}}`.
+var httpFileRefRegex = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+
+// httpFileRequest is a single request from an `.http` file.
+type httpFileRequest struct {
+	Name string
+	Line int
+	Text string
+}
+
+// httpFile is a parsed VS Code REST Client / JetBrains HTTP Client file.
+type httpFile struct {
+	Vars     map[string]string
+	Requests []httpFileRequest
+}
+
+// parseHTTPFile splits an `.http` file into its `###` separated requests and
+// collects its `@name = value` file variables.
+func parseHTTPFile(data string) httpFile {
+	file := httpFile{Vars: map[string]string{}}
+
+	var current *httpFileRequest
+	var name string
+	var lines []string
+	var inBody bool
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimRight(strings.Join(lines, "\n"), " \t\r\n") + "\n"
+			file.Requests = append(file.Requests, *current)
+		}
+		current = nil
+		name = ""
+		lines = nil
+		inBody = false
+	}
+
+	for i, line := range strings.Split(data, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "###") {
+			flush()
+			continue
+		}
+
+		if current == nil {
+			// Before the request line: variables, names, comments, blank lines.
+			if m := httpFileVarRegex.FindStringSubmatch(trimmed); m != nil {
+				file.Vars[m[1]] = strings.TrimSpace(m[2])
+				continue
+			}
+
+			if m := httpFileNameRegex.FindStringSubmatch(trimmed); m != nil {
+				name = m[1]
+				continue
+			}
+
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+				continue
+			}
+
+			current = &httpFileRequest{Name: name, Line: i + 1}
+			lines = append(lines, strings.TrimRight(line, "\r"))
+			continue
+		}
+
+		if trimmed == "" {
+			inBody = true
+		} else if !inBody && (strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//")) {
+			// Skip comments between the headers, but never in the body.
+			continue
+		}
+
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	flush()
+
+	return file
+}
+
+// httpFileRunner runs the requests in an `.http` file, keeping track of named
+// responses so later requests can reference them.
+type httpFileRunner struct {
+	vars      map[string]string
+	responses map[string]Response
+}
+
+// expand replaces all `{{...}}` references in the text.
+func (r *httpFileRunner) expand(text string, depth int) (string, error) {
+	if depth > 10 {
+		return "", fmt.Errorf("variable references nested too deeply")
+	}
+
+	var err error
+	result := httpFileRefRegex.ReplaceAllStringFunc(text, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		var value string
+		value, err = r.resolve(httpFileRefRegex.FindStringSubmatch(match)[1], depth)
+		return value
+	})
+
+	return result, err
+}
+
+// resolve returns the value of a single variable reference, which may be a
+// file variable, an environment variable via `$processEnv NAME`, or part of a
+// previous named response like `login.response.body.token`.
+func (r *httpFileRunner) resolve(ref string, depth int) (string, error) {
+	if env, ok := strings.CutPrefix(ref, "$processEnv "); ok {
+		return os.Getenv(strings.TrimSpace(env)), nil
+	}
+
+	if value, ok := r.vars[ref]; ok {
+		return r.expand(value, depth+1)
+	}
+
+	if name, path, ok := strings.Cut(ref, ".response."); ok {
+		resp, ok := r.responses[name]
+		if !ok {
+			return "", fmt.Errorf("no response for request %s in %s, make sure it has a `# @name %s` and runs first", name, ref, name)
+		}
+
+		// Allow REST Client style JSONPath like `body.$.token`.
+		path = strings.Replace(path, "body.$.", "body.", 1)
+
+		result, _, queryErr := shorthand.GetPath(path, makeJSONSafe(resp.Map()), shorthand.GetOptions{})
+		if queryErr != nil {
+			return "", queryErr
+		}
+
+		if s, ok := result.(string); ok {
+			return s, nil
+		}
+
+		encoded, err := json.Marshal(result)
+		return string(encoded), err
+	}
+
+	return "", fmt.Errorf("undefined variable %s", ref)
+}
+
+// runHTTPFile sends each request in a VS Code REST Client / JetBrains HTTP
+// Client style `.http` file in order, printing each response. Requests are
+// separated by `###` lines and may use `{{var}}` references.
+func runHTTPFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	file := parseHTTPFile(string(data))
+	if len(file.Requests) == 0 {
+		return fmt.Errorf("no requests found in %s", filename)
+	}
+
+	runner := &httpFileRunner{
+		vars:      file.Vars,
+		responses: map[string]Response{},
+	}
+
+	for _, fr := range file.Requests {
+		label := fmt.Sprintf("%s:%d", filename, fr.Line)
+		if fr.Name != "" {
+			label += " (" + fr.Name + ")"
+		}
+
+		text, err := runner.expand(fr.Text, 0)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		req, err := ParseRawRequest([]byte(text))
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		if len(file.Requests) > 1 {
+			LogInfo("Running %s %s", req.Method, req.URL)
+		}
+		parsed, err := GetParsedResponse(req)
+		if errors.Is(err, errRequestNotSent) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		if fr.Name != "" {
+			runner.responses[fr.Name] = parsed
+		}
+
+		formatResponse(parsed)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const testHTTPFile = `@base = https://http-file.example.com
+@user = {{$processEnv RSH_TEST_USER}}
+
+# @name login
+POST {{base}}/login
+Content-Type: application/json
+# A header comment
+
+{"user": "{{user}}"}
+
+###
+
+# Fetch the current user
+GET {{base}}/users/{{login.response.body.$.id}}
+Authorization: Bearer {{login.response.body.token}}
+
+###
+`
+
+func TestParseHTTPFile(t *testing.T) {
+	file := parseHTTPFile(testHTTPFile)
+
+	assert.Equal(t, map[string]string{
+		"base": "https://http-file.example.com",
+		"user": "{{$processEnv RSH_TEST_USER}}",
+	}, file.Vars)
+
+	assert.Len(t, file.Requests, 2)
+	assert.Equal(t, "login", file.Requests[0].Name)
+	assert.Equal(t, 5, file.Requests[0].Line)
+	assert.Equal(t, "POST {{base}}/login\nContent-Type: application/json\n\n{\"user\": \"{{user}}\"}\n", file.Requests[0].Text)
+	assert.Equal(t, "", file.Requests[1].Name)
+	assert.Equal(t, "GET {{base}}/users/{{login.response.body.$.id}}\nAuthorization: Bearer {{login.response.body.token}}\n", file.Requests[1].Text)
+}
+
+func TestRunHTTPFile(t *testing.T) {
+	defer gock.Off()
+	reset(false)
+
+	t.Setenv("RSH_TEST_USER", "alice")
+
+	gock.New("https://http-file.example.com").
+		Post("/login").
+		BodyString(`{"user": "alice"}`).
+		Reply(200).
+		JSON(map[string]interface{}{"id": 5, "token": "abc123"})
+
+	gock.New("https://http-file.example.com").
+		Get("/users/5").
+		MatchHeader("Authorization", "Bearer abc123").
+		Reply(200).
+		JSON(map[string]interface{}{"name": "Alice"})
+
+	filename := filepath.Join(t.TempDir(), "requests.http")
+	assert.NoError(t, os.WriteFile(filename, []byte(testHTTPFile), 0600))
+
+	out := runNoReset("request " + filename + " -f body")
+	assert.Contains(t, out, "abc123")
+	assert.Contains(t, out, "Alice")
+	assert.True(t, gock.IsDone())
+}
+
+func TestRunHTTPFileErrors(t *testing.T) {
+	runner := &httpFileRunner{vars: map[string]string{"a": "{{b}}", "b": "{{a}}"}, responses: map[string]Response{}}
+
+	_, err := runner.expand("{{missing}}", 0)
+	assert.ErrorContains(t, err, "undefined variable missing")
+
+	_, err = runner.expand("{{a}}", 0)
+	assert.ErrorContains(t, err, "nested too deeply")
+
+	_, err = runner.expand("{{login.response.body.id}}", 0)
+	assert.ErrorContains(t, err, "no response for request login")
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/eliukblau/pixterm/pkg/ansimage"
+	"github.com/spf13/viper"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	_ "golang.org/x/image/bmp"  // initialize decoder
+	_ "golang.org/x/image/tiff" // initialize decoder
+	"golang.org/x/term"
+)
+
+// Image display modes, see `--rsh-image-mode`.
+const (
+	imageAuto  = "auto"
+	imageITerm = "iterm"
+	imageKitty = "kitty"
+	imageANSI  = "ansi"
+	imageNone  = "none"
+)
+
+// imageTypes are the content types which can be displayed inline.
+var imageTypes = map[string]bool{
+	"image/png":     true,
+	"image/jpeg":    true,
+	"image/webp":    true,
+	"image/gif":     true,
+	"image/bmp":     true,
+	"image/tiff":    true,
+	"image/svg+xml": true,
+}
+
+// SVG documents are text and can be arbitrarily complex, so only rasterize
+// them up to 1MiB and at most this many pixels in either dimension.
+const (
+	maxSVGBytes = 1024 * 1024
+	maxSVGSize  = 1024
+)
+
+// kittyChunkSize is the maximum base64 payload size of a single Kitty
+// graphics protocol escape sequence.
+const kittyChunkSize = 4096
+
+// detectImageMode guesses which inline image protocol the terminal supports
+// from the environment variables terminals set. Multiplexers like tmux hide
+// these, so they fall back to unicode rendering.
+func detectImageMode() string {
+	if os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" || os.Getenv("TERM_PROGRAM") == "ghostty" {
+		return imageKitty
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return imageITerm
+	}
+
+	if os.Getenv("LC_TERMINAL") == "iTerm2" {
+		return imageITerm
+	}
+
+	return imageANSI
+}
+
+// imageMode returns the image display mode to use. Native protocols write
+// escape sequences meant for a terminal, so they are only used with a TTY.
+func imageMode(tty bool) (string, error) {
+	mode := viper.GetString("rsh-image-mode")
+	switch mode {
+	case "", imageAuto:
+		mode = detectImageMode()
+	case imageITerm, imageKitty, imageANSI, imageNone:
+	default:
+		return "", fmt.Errorf("unknown image mode %q", mode)
+	}
+
+	if !tty && (mode == imageITerm || mode == imageKitty) {
+		mode = imageANSI
+	}
+
+	return mode, nil
+}
+
+// isImage returns whether a response with the given content type can be
+// displayed inline as an image.
+func isImage(contentType string) bool {
+	ct, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return imageTypes[ct]
+}
+
+// rasterizeSVG renders an SVG document into a PNG image, scaled to fit within
+// `maxSVGSize` while preserving its aspect ratio.
+func rasterizeSVG(data []byte) ([]byte, error) {
+	if len(data) > maxSVGBytes {
+		return nil, fmt.Errorf("SVG too large to render (%d bytes)", len(data))
+	}
+
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, err
+	}
+
+	vw, vh := icon.ViewBox.W, icon.ViewBox.H
+	if vw <= 0 || vh <= 0 {
+		return nil, fmt.Errorf("SVG has no size")
+	}
+
+	// Keep the image's natural size when it already fits.
+	scale := math.Min(1, math.Min(maxSVGSize/vw, maxSVGSize/vh))
+	w := int(math.Max(1, math.Round(vw*scale)))
+	h := int(math.Max(1, math.Round(vh*scale)))
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	icon.SetTarget(0, 0, float64(w), float64(h))
+	icon.Draw(rasterx.NewDasher(w, h, rasterx.NewScannerGV(w, h, img, img.Bounds())), 1)
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderImage renders image data of the given content type for display in
+// the terminal using the given mode.
+func renderImage(mode, contentType string, data []byte) ([]byte, error) {
+	if ct, _, _ := mime.ParseMediaType(contentType); ct == "image/svg+xml" {
+		// No terminal protocol or decoder handles SVG, so render it first.
+		raster, err := rasterizeSVG(data)
+		if err != nil {
+			return nil, err
+		}
+		data = raster
+	}
+
+	switch mode {
+	case imageITerm:
+		return renderITerm(data), nil
+	case imageKitty:
+		return renderKitty(data)
+	}
+
+	// Get the window size, read and scale the image, and display it using
+	// unicode half-blocks.
+	w, h, err := term.GetSize(0)
+	if err != nil {
+		// Default to standard terminal size
+		w, h = 80, 24
+	}
+
+	img, err := ansimage.NewScaledFromReader(bytes.NewReader(data), h*2, w*1, color.Transparent, ansimage.ScaleModeFit, ansimage.NoDithering)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(img.Render()), nil
+}
+
+// renderITerm uses the iTerm2 inline images protocol, which accepts any image
+// format the terminal can decode.
+func renderITerm(data []byte) []byte {
+	return []byte(fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a", len(data), base64.StdEncoding.EncodeToString(data)))
+}
+
+// renderKitty uses the Kitty graphics protocol. It only accepts PNG, so other
+// formats are converted first. The payload is split into chunks and terminal
+// responses are suppressed so they don't end up in the shell's input.
+func renderKitty(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := bytes.Buffer{}
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	out := strings.Builder{}
+	for i := 0; i < len(payload) || i == 0; i += kittyChunkSize {
+		end := i + kittyChunkSize
+		more := 1
+		if end >= len(payload) {
+			end = len(payload)
+			more = 0
+		}
+
+		out.WriteString("\x1b_G")
+		if i == 0 {
+			out.WriteString("a=T,f=100,q=2,")
+		}
+		fmt.Fprintf(&out, "m=%d;%s\x1b\\", more, payload[i:end])
+	}
+
+	return []byte(out.String()), nil
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+func formatImage(t *testing.T, mode string, tty bool, body []byte) string {
+	return formatImageType(t, mode, tty, "image/png", body)
+}
+
+func formatImageType(t *testing.T, mode string, tty bool, contentType string, body []byte) string {
+	viper.Reset()
+	viper.Set("rsh-output-format", "auto")
+	viper.Set("rsh-image-mode", mode)
+
+	buf := &bytes.Buffer{}
+	Stdout = buf
+	require.NoError(t, NewDefaultFormatter(tty, false).Format(Response{
+		Status:  200,
+		Headers: map[string]string{"Content-Type": contentType},
+		Body:    body,
+	}))
+
+	return buf.String()
+}
+
+func TestImageMode(t *testing.T) {
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("TERM_PROGRAM", "iTerm.app")
+	t.Setenv("LC_TERMINAL", "")
+
+	viper.Reset()
+	mode, err := imageMode(true)
+	require.NoError(t, err)
+	assert.Equal(t, imageITerm, mode)
+
+	// Escape sequences are never written when not attached to a terminal.
+	mode, err = imageMode(false)
+	require.NoError(t, err)
+	assert.Equal(t, imageANSI, mode)
+
+	t.Setenv("TERM", "xterm-kitty")
+	mode, err = imageMode(true)
+	require.NoError(t, err)
+	assert.Equal(t, imageKitty, mode)
+
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("TERM_PROGRAM", "tmux")
+	mode, err = imageMode(true)
+	require.NoError(t, err)
+	assert.Equal(t, imageANSI, mode)
+
+	viper.Set("rsh-image-mode", "sixel")
+	_, err = imageMode(true)
+	assert.Error(t, err)
+}
+
+func TestImageITerm(t *testing.T) {
+	out := formatImage(t, imageITerm, true, img)
+	assert.Equal(t, " 200 OK\nContent-Type: image/png\n\n\x1b]1337;File=inline=1;size=75;preserveAspectRatio=1:"+base64.StdEncoding.EncodeToString(img)+"\a\n", out)
+}
+
+func TestImageKitty(t *testing.T) {
+	out := formatImage(t, imageKitty, true, img)
+	assert.Equal(t, " 200 OK\nContent-Type: image/png\n\n\x1b_Ga=T,f=100,q=2,m=0;"+base64.StdEncoding.EncodeToString(img)+"\x1b\\\n", out)
+}
+
+func TestImageKittyConvert(t *testing.T) {
+	// Large enough to need several chunks.
+	src := image.NewRGBA(image.Rect(0, 0, 128, 128))
+	for x := 0; x < 128; x++ {
+		for y := 0; y < 128; y++ {
+			src.Set(x, y, color.RGBA{uint8(x * 2), uint8(y * 2), uint8(x ^ y), 255})
+		}
+	}
+	buf := bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(&buf, src, nil))
+
+	out, err := renderKitty(buf.Bytes())
+	require.NoError(t, err)
+
+	chunks := strings.Split(strings.TrimSuffix(string(out), "\x1b\\"), "\x1b\\")
+	require.Greater(t, len(chunks), 1)
+
+	payload := ""
+	for i, chunk := range chunks {
+		switch {
+		case i == 0:
+			assert.True(t, strings.HasPrefix(chunk, "\x1b_Ga=T,f=100,q=2,m=1;"))
+		case i == len(chunks)-1:
+			assert.True(t, strings.HasPrefix(chunk, "\x1b_Gm=0;"))
+		default:
+			assert.True(t, strings.HasPrefix(chunk, "\x1b_Gm=1;"))
+		}
+		payload += chunk[strings.Index(chunk, ";")+1:]
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, src.Bounds(), decoded.Bounds())
+}
+
+func TestImageNone(t *testing.T) {
+	out := formatImage(t, imageNone, true, img)
+	assert.Equal(t, " 200 OK\nContent-Type: image/png\n", out)
+}
+
+func TestImageNoTTY(t *testing.T) {
+	out := formatImage(t, imageKitty, false, img)
+	assert.NotContains(t, out, "\x1b_G")
+}
+
+const testSVG = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 4000 2000"><rect width="4000" height="2000" fill="#ff0000"/></svg>`
+
+func TestIsImage(t *testing.T) {
+	assert.True(t, isImage("image/png"))
+	assert.True(t, isImage("image/svg+xml; charset=utf-8"))
+	assert.True(t, isImage("image/tiff"))
+	assert.False(t, isImage("image/x-icon"))
+	assert.False(t, isImage("text/plain"))
+	assert.False(t, isImage(""))
+}
+
+func TestImageFormats(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.RGBA{255, 0, 0, 255})
+
+	bmpData := bytes.Buffer{}
+	require.NoError(t, bmp.Encode(&bmpData, src))
+	tiffData := bytes.Buffer{}
+	require.NoError(t, tiff.Encode(&tiffData, src, nil))
+
+	for ct, data := range map[string][]byte{
+		"image/bmp":  bmpData.Bytes(),
+		"image/tiff": tiffData.Bytes(),
+	} {
+		t.Run(ct, func(t *testing.T) {
+			out, err := renderImage(imageKitty, ct, data)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(out), "\x1b_Ga=T,f=100,q=2,m=0;"))
+		})
+	}
+}
+
+func TestRasterizeSVG(t *testing.T) {
+	data, err := rasterizeSVG([]byte(testSVG))
+	require.NoError(t, err)
+
+	// Large documents are scaled down, preserving the aspect ratio.
+	decoded, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 1024, 512), decoded.Bounds())
+	r, g, b, _ := decoded.At(512, 256).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0, 0}, []uint32{r, g, b})
+
+	_, err = rasterizeSVG([]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+	assert.Error(t, err)
+
+	_, err = rasterizeSVG(bytes.Repeat([]byte(" "), maxSVGBytes+1))
+	assert.Error(t, err)
+}
+
+func TestImageSVG(t *testing.T) {
+	out := formatImageType(t, imageITerm, true, "image/svg+xml", []byte(testSVG))
+	assert.Contains(t, out, "\x1b]1337;File=inline=1;")
+	assert.NotContains(t, out, "<svg")
+}
+
+func TestImageSVGInvalid(t *testing.T) {
+	Stderr = &strings.Builder{}
+
+	// Unrenderable documents fall back to printing the body.
+	out := formatImageType(t, imageANSI, true, "image/svg+xml", []byte("<svg>"))
+	assert.Contains(t, out, "<svg>")
+	assert.Contains(t, Stderr.(*strings.Builder).String(), "Unable to display image")
+}
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/danielgtaylor/shorthand/v2"
 	yaml "gopkg.in/yaml.v2"
@@ -45,22 +46,80 @@ func GetBody(mediaType string, args []string) (string, error) {
 	}
 
 	if input != nil {
-		if strings.Contains(mediaType, "json") {
-			marshalled, err := json.Marshal(input)
-			if err != nil {
-				return "", err
+		return marshalBody(mediaType, input)
+	}
+
+	return body, nil
+}
+
+// GetSeededBody returns the request body using the given JSON seed data as a
+// base. Structured data from stdin is merged on top of it, followed by any
+// shorthand arguments.
+func GetSeededBody(mediaType string, seed string, args []string) (string, error) {
+	var input any
+	if err := json.Unmarshal([]byte(seed), &input); err != nil {
+		return "", err
+	}
+
+	if info, err := Stdin.Stat(); err == nil && (info.Mode()&os.ModeCharDevice) == 0 {
+		b, err := io.ReadAll(Stdin)
+		if err != nil {
+			return "", err
+		}
+		if len(b) > 0 {
+			if !utf8.Valid(b) {
+				return "", fmt.Errorf("cannot merge binary stdin into a seeded body")
 			}
-			body = string(marshalled)
-		} else if strings.Contains(mediaType, "yaml") {
-			marshalled, err := yaml.Marshal(input)
+			input, err = shorthand.Unmarshal(string(b), shorthand.ParseOptions{
+				EnableFileInput: true,
+			}, input)
 			if err != nil {
 				return "", err
 			}
-			body = string(marshalled)
-		} else {
-			return "", fmt.Errorf("not sure how to marshal %s", mediaType)
 		}
 	}
 
-	return body, nil
+	if len(args) > 0 {
+		var err error
+		input, err = shorthand.Unmarshal(strings.Join(args, " "), shorthand.ParseOptions{
+			EnableFileInput:       true,
+			EnableObjectDetection: true,
+		}, input)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return marshalBody(mediaType, input)
+}
+
+// marshalBody encodes structured input for the given request media type.
+func marshalBody(mediaType string, input any) (string, error) {
+	if strings.Contains(mediaType, "json") {
+		marshalled, err := json.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		return string(marshalled), nil
+	} else if strings.Contains(mediaType, "yaml") {
+		marshalled, err := yaml.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		return string(marshalled), nil
+	} else if strings.Contains(mediaType, "toml") {
+		marshalled, err := TOML{}.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		return string(marshalled), nil
+	} else if strings.Contains(mediaType, "xml") {
+		marshalled, err := XML{}.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		return string(marshalled), nil
+	}
+
+	return "", fmt.Errorf("not sure how to marshal %s", mediaType)
 }
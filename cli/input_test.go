@@ -38,6 +38,14 @@ func TestInputStructuredYAML(t *testing.T) {
 	})
 }
 
+func TestInputStructuredTOML(t *testing.T) {
+	WithFakeStdin([]byte{}, fs.ModeCharDevice, func() {
+		body, err := GetBody("application/toml", []string{"foo: 1, bar: false"})
+		assert.NoError(t, err)
+		assert.Equal(t, "bar = false\nfoo = 1\n", body)
+	})
+}
+
 func TestInputBinary(t *testing.T) {
 	WithFakeStdin([]byte("This is not JSON!"), 0, func() {
 		body, err := GetBody("", []string{})
@@ -52,3 +60,25 @@ func TestInputInvalidType(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestInputSeeded(t *testing.T) {
+	WithFakeStdin([]byte{}, fs.ModeCharDevice, func() {
+		body, err := GetSeededBody("application/json", `{"name": "string", "size": 1}`, []string{"name: foo"})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"foo","size":1}`, body)
+	})
+}
+
+func TestInputSeededStdin(t *testing.T) {
+	// Stdin is merged over the seed, then arguments are applied.
+	WithFakeStdin([]byte(`{"size": 2, "tags": ["a"]}`), 0, func() {
+		body, err := GetSeededBody("application/json", `{"name": "string", "size": 1}`, []string{"name: foo"})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"foo","size":2,"tags":["a"]}`, body)
+	})
+
+	WithFakeStdin([]byte("\xff\xfe"), 0, func() {
+		_, err := GetSeededBody("application/json", `{}`, nil)
+		assert.ErrorContains(t, err, "binary")
+	})
+}
@@ -3,7 +3,9 @@ package cli
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
@@ -147,6 +149,8 @@ func askLoadBaseAPI(a asker, config *APIConfig) {
 			}
 		}
 
+		askServerVars(a, config, api.Servers)
+
 		if auth.Name == "" && len(api.Auth) > 0 {
 			// No auto-configuration present or successful, so fall back to the first
 			// available defined security scheme.
@@ -180,6 +184,64 @@ func askLoadBaseAPI(a asker, config *APIConfig) {
 	}
 }
 
+// askServerVars prompts for values of the first templated server's variables,
+// like `{region}`, and stores them in the API's vars so they are used when
+// resolving the server the operations are relative to.
+func askServerVars(a asker, config *APIConfig, servers []APIServer) {
+	var server *APIServer
+	for i := range servers {
+		if len(servers[i].Variables) > 0 {
+			server = &servers[i]
+			break
+		}
+	}
+
+	if server == nil {
+		return
+	}
+
+	fmt.Println("Found server " + server.URL)
+
+	names := make([]string, 0, len(server.Variables))
+	for name := range server.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if config.Vars == nil {
+		config.Vars = map[string]string{}
+	}
+
+	for _, name := range names {
+		v := server.Variables[name]
+
+		def := config.Vars[name]
+		if def == "" && v.Default != nil {
+			def = fmt.Sprintf("%v", v.Default)
+		}
+
+		promptText := name
+		if v.Description != "" {
+			promptText = v.Description
+		}
+
+		if len(v.Enum) > 0 {
+			enumStr := []string{}
+			for _, val := range v.Enum {
+				enumStr = append(enumStr, fmt.Sprintf("%v", val))
+			}
+			config.Vars[name] = a.askSelect(promptText, enumStr, def, "")
+		} else {
+			config.Vars[name] = a.askInput(promptText, def, def == "", "")
+		}
+	}
+
+	// Operations were generated with the previous values, so make sure they
+	// get reloaded on the next run.
+	Cache.Set(config.name+".expires", time.Time{})
+	Cache.WriteConfig()
+}
+
 func askAuth(a asker, auth *APIAuth) {
 	authTypes := []string{}
 	for k := range authHandlers {
@@ -202,7 +264,14 @@ func askAuth(a asker, auth *APIAuth) {
 	auth.Params = map[string]string{}
 
 	for _, p := range authHandlers[choice].Parameters() {
-		auth.Params[p.Name] = a.askInput("Auth parameter "+p.Name, prev[p.Name], p.Required, p.Help)
+		help := p.Help
+		if format := prev[p.Name+"_format"]; format != "" {
+			// Surface format hints from the API description, e.g. a bearer
+			// token's `bearerFormat`, and keep them for next time.
+			help += " (format: " + format + ")"
+			auth.Params[p.Name+"_format"] = format
+		}
+		auth.Params[p.Name] = a.askInput("Auth parameter "+p.Name, prev[p.Name], p.Required, help)
 	}
 
 	for {
@@ -216,35 +285,100 @@ func askAuth(a asker, auth *APIAuth) {
 	}
 }
 
-func askEditProfile(a asker, name string, profile *APIProfile) {
-	if profile.Headers == nil {
-		profile.Headers = map[string]string{}
+// headerQueryOptions returns the menu options to edit persistent headers and
+// query params.
+func headerQueryOptions(headers, query map[string]string) []string {
+	options := []string{
+		"Add header",
 	}
 
-	if profile.Query == nil {
-		profile.Query = map[string]string{}
+	for k := range headers {
+		options = append(options, "Edit header "+k)
+	}
+	for k := range headers {
+		options = append(options, "Delete header "+k)
 	}
 
-	for {
-		options := []string{
-			"Add header",
-		}
+	options = append(options, "Add query param")
+
+	for k := range query {
+		options = append(options, "Edit query param "+k)
+	}
+	for k := range query {
+		options = append(options, "Delete query param "+k)
+	}
 
-		for k := range profile.Headers {
-			options = append(options, "Edit header "+k)
+	return options
+}
+
+// askHeaderQuery handles a menu choice from `headerQueryOptions`, returning
+// false if the choice was not for a header or query param.
+func askHeaderQuery(a asker, choice string, headers, query map[string]string) bool {
+	switch {
+	case choice == "Add header":
+		key := a.askInput("Header name", "", true, "")
+		headers[key] = a.askInput("Header value", "", false, "")
+	case strings.HasPrefix(choice, "Edit header"):
+		h := strings.SplitN(choice, " ", 3)[2]
+		key := a.askInput("Header name", h, true, "")
+		headers[key] = a.askInput("Header value", headers[key], false, "")
+	case strings.HasPrefix(choice, "Delete header"):
+		h := strings.SplitN(choice, " ", 3)[2]
+		if a.askConfirm("Are you sure you want to delete the "+h+" header?", false, "") {
+			delete(headers, h)
 		}
-		for k := range profile.Headers {
-			options = append(options, "Delete header "+k)
+	case choice == "Add query param":
+		key := a.askInput("Query param name", "", true, "")
+		query[key] = a.askInput("Query param value", "", false, "")
+	case strings.HasPrefix(choice, "Edit query param"):
+		q := strings.SplitN(choice, " ", 4)[3]
+		key := a.askInput("Query param name", q, true, "")
+		query[key] = a.askInput("Query param value", query[key], false, "")
+	case strings.HasPrefix(choice, "Delete query param"):
+		q := strings.SplitN(choice, " ", 4)[3]
+		if a.askConfirm("Are you sure you want to delete the "+q+" query param?", false, "") {
+			delete(query, q)
 		}
+	default:
+		return false
+	}
+	return true
+}
 
-		options = append(options, "Add query param")
+// askEditShared edits the headers and query params sent with every request
+// to the API, regardless of the profile.
+func askEditShared(a asker, config *APIConfig) {
+	if config.Headers == nil {
+		config.Headers = map[string]string{}
+	}
 
-		for k := range profile.Query {
-			options = append(options, "Edit query param "+k)
-		}
-		for k := range profile.Query {
-			options = append(options, "Delete query param "+k)
+	if config.Query == nil {
+		config.Query = map[string]string{}
+	}
+
+	for {
+		options := headerQueryOptions(config.Headers, config.Query)
+		options = append(options, "Finished with shared headers & query params")
+
+		choice := a.askSelect("Select option for all profiles", options, nil, "")
+
+		if !askHeaderQuery(a, choice, config.Headers, config.Query) {
+			return
 		}
+	}
+}
+
+func askEditProfile(a asker, name string, profile *APIProfile) {
+	if profile.Headers == nil {
+		profile.Headers = map[string]string{}
+	}
+
+	if profile.Query == nil {
+		profile.Query = map[string]string{}
+	}
+
+	for {
+		options := headerQueryOptions(profile.Headers, profile.Query)
 
 		options = append(options, "Add custom base URL")
 		if profile.Base != "" {
@@ -255,31 +389,11 @@ func askEditProfile(a asker, name string, profile *APIProfile) {
 
 		choice := a.askSelect("Select option for profile `"+name+"`", options, nil, "")
 
+		if askHeaderQuery(a, choice, profile.Headers, profile.Query) {
+			continue
+		}
+
 		switch {
-		case choice == "Add header":
-			key := a.askInput("Header name", "", true, "")
-			profile.Headers[key] = a.askInput("Header value", "", false, "")
-		case strings.HasPrefix(choice, "Edit header"):
-			h := strings.SplitN(choice, " ", 3)[2]
-			key := a.askInput("Header name", h, true, "")
-			profile.Headers[key] = a.askInput("Header value", profile.Headers[key], false, "")
-		case strings.HasPrefix(choice, "Delete header"):
-			h := strings.SplitN(choice, " ", 3)[2]
-			if a.askConfirm("Are you sure you want to delete the "+h+" header?", false, "") {
-				delete(profile.Headers, h)
-			}
-		case choice == "Add query param":
-			key := a.askInput("Query param name", "", true, "")
-			profile.Query[key] = a.askInput("Query param value", "", false, "")
-		case strings.HasPrefix(choice, "Edit query param"):
-			q := strings.SplitN(choice, " ", 4)[3]
-			key := a.askInput("Query param name", q, true, "")
-			profile.Headers[key] = a.askInput("Query param value", profile.Query[key], false, "")
-		case strings.HasPrefix(choice, "Delete query param"):
-			q := strings.SplitN(choice, " ", 4)[3]
-			if a.askConfirm("Are you sure you want to delete the "+q+" query param?", false, "") {
-				delete(profile.Query, q)
-			}
 		case choice == "Set up auth":
 			if profile.Auth == nil {
 				profile.Auth = &APIAuth{}
@@ -405,6 +519,7 @@ func askInitAPI(a asker, cmd *cobra.Command, args []string) {
 	for {
 		options := []string{
 			"Change base URI (" + config.Base + ")",
+			"Edit shared headers & query params",
 			"Add profile",
 		}
 
@@ -423,6 +538,8 @@ func askInitAPI(a asker, cmd *cobra.Command, args []string) {
 		switch {
 		case strings.HasPrefix(choice, "Change base URI"):
 			askBaseURI(a, config)
+		case choice == "Edit shared headers & query params":
+			askEditShared(a, config)
 		case choice == "Add profile":
 			askAddProfile(a, config)
 		case strings.HasPrefix(choice, "Edit profile"):
@@ -440,3 +557,85 @@ func askInitAPI(a asker, cmd *cobra.Command, args []string) {
 func askInitAPIDefault(cmd *cobra.Command, args []string) {
 	askInitAPI(defaultAsker{}, cmd, args)
 }
+
+// askParam prompts for a single operation parameter value, using a select
+// for enums. Returns an empty string if an optional param is skipped.
+func askParam(a asker, p *Param, required bool) string {
+	help := p.Description
+	if p.Type != "" {
+		help = strings.TrimSpace(help + "\nType: " + p.Type)
+	}
+
+	def := ""
+	if p.Default != nil {
+		def = fmt.Sprintf("%v", p.Default)
+	}
+
+	if len(p.Enum) > 0 {
+		options := []string{}
+		if !required {
+			options = append(options, "(none)")
+		}
+		for _, v := range p.Enum {
+			options = append(options, fmt.Sprintf("%v", v))
+		}
+
+		var defOption interface{}
+		if def != "" {
+			defOption = def
+		}
+
+		resp := a.askSelect(p.OptionName(), options, defOption, help)
+		if resp == "(none)" {
+			return ""
+		}
+		return resp
+	}
+
+	return a.askInput(p.OptionName(), def, required, help)
+}
+
+// askOperation prompts for any path params not given as arguments, any
+// query & header params not set via flags, and the request body. Returns the
+// complete arguments for the operation command.
+func askOperation(a asker, o Operation, cmd *cobra.Command, args []string) ([]string, error) {
+	result := append([]string{}, args...)
+
+	for i, p := range o.PathParams {
+		if i < len(args) {
+			continue
+		}
+		result = append(result, askParam(a, p, true))
+	}
+
+	for _, params := range [][]*Param{o.QueryParams, o.HeaderParams} {
+		for _, p := range params {
+			if cmd.Flags().Changed(p.OptionName()) {
+				continue
+			}
+
+			if v := askParam(a, p, p.Required); v != "" {
+				if err := cmd.Flags().Set(p.OptionName(), v); err != nil {
+					return nil, fmt.Errorf("invalid value for %s: %w", p.OptionName(), err)
+				}
+			}
+		}
+	}
+
+	if o.BodyMediaType != "" && len(args) <= len(o.PathParams) {
+		def := ""
+		if len(o.Examples) > 0 && !strings.HasPrefix(o.Examples[0], "<") {
+			def = o.Examples[0]
+		}
+
+		if body := a.askInput("Request body", def, false, "Request body in CLI shorthand syntax, e.g. `name: foo, tags: [a, b]`"); body != "" {
+			result = append(result, body)
+		}
+	}
+
+	return result, nil
+}
+
+func askOperationDefault(o Operation, cmd *cobra.Command, args []string) ([]string, error) {
+	return askOperation(defaultAsker{}, o, cmd, args)
+}
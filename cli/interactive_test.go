@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/h2non/gock.v1"
 )
 
@@ -198,3 +199,117 @@ func TestInteractiveAutoConfig(t *testing.T) {
 
 	askInitAPI(mock, Root, []string{"autoconfig", "http://api2.example.com"})
 }
+
+func TestAskOperation(t *testing.T) {
+	reset(false)
+
+	op := Operation{
+		Name:        "create-item",
+		Method:      http.MethodPut,
+		URITemplate: "http://example.com/items/{id}",
+		PathParams: []*Param{
+			{Type: "string", Name: "id"},
+		},
+		QueryParams: []*Param{
+			{Type: "string", Name: "sort", Enum: []any{"asc", "desc"}},
+			{Type: "string", Name: "search"},
+		},
+		HeaderParams: []*Param{
+			{Type: "string", Name: "X-Token", Required: true},
+		},
+		BodyMediaType: "application/json",
+		Examples:      []string{"name: example"},
+	}
+
+	cmd := op.command()
+	assert.NoError(t, cmd.Flags().Set("search", "foo"))
+
+	mock := &mockAsker{
+		t: t,
+		responses: []string{
+			"item1",
+			"desc",
+			"abc123",
+			"name: foo",
+		},
+	}
+
+	args, err := askOperation(mock, op, cmd, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"item1", "name: foo"}, args)
+
+	sort, _ := cmd.Flags().GetString("sort")
+	assert.Equal(t, "desc", sort)
+
+	token, _ := cmd.Flags().GetString("x-token")
+	assert.Equal(t, "abc123", token)
+
+	// Already-given arguments and skipped optional params are not set.
+	cmd = op.command()
+	mock = &mockAsker{
+		t: t,
+		responses: []string{
+			"(none)",
+			"",
+			"abc123",
+		},
+	}
+
+	args, err = askOperation(mock, op, cmd, []string{"item1", "name: bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"item1", "name: bar"}, args)
+	assert.False(t, cmd.Flags().Changed("sort"))
+	assert.False(t, cmd.Flags().Changed("search"))
+}
+
+func TestAskServerVars(t *testing.T) {
+	reset(false)
+
+	config := &APIConfig{name: "servervars", Vars: map[string]string{"version": "v1"}}
+
+	mock := &mockAsker{
+		t: t,
+		responses: []string{
+			"eu",
+			"v2",
+		},
+	}
+
+	askServerVars(mock, config, []APIServer{
+		{URL: "https://api.example.com"},
+		{
+			URL: "https://{region}.example.com/{version}",
+			Variables: map[string]AutoConfigVar{
+				"region":  {Description: "Region", Enum: []any{"us", "eu"}},
+				"version": {Default: "v1"},
+			},
+		},
+	})
+
+	assert.Equal(t, map[string]string{"region": "eu", "version": "v2"}, config.Vars)
+	assert.Equal(t, 2, mock.pos)
+}
+
+func TestAskEditShared(t *testing.T) {
+	config := &APIConfig{
+		Query: map[string]string{"region": "us"},
+	}
+
+	mock := &mockAsker{
+		t: t,
+		responses: []string{
+			"Add header",
+			"X-Api-Version",
+			"2",
+			"Edit query param region",
+			"region",
+			"eu",
+			"Finished with shared headers & query params",
+		},
+	}
+
+	askEditShared(mock, config)
+
+	assert.Equal(t, map[string]string{"X-Api-Version": "2"}, config.Headers)
+	assert.Equal(t, map[string]string{"region": "eu"}, config.Query)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrInterrupted is returned when a request is canceled by the user, e.g. by
+// pressing Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+var (
+	// interrupted is closed when the user asks to cancel in-flight requests.
+	interrupted     = make(chan struct{})
+	interruptedOnce = &sync.Once{}
+
+	// activeRequests counts operations which can be canceled. When there are
+	// none, an interrupt exits immediately like it would by default.
+	activeRequests int32
+)
+
+// interrupt cancels all in-flight requests. It is safe to call many times.
+func interrupt() {
+	interruptedOnce.Do(func() {
+		close(interrupted)
+	})
+}
+
+// isInterrupted returns whether the user has canceled requests.
+func isInterrupted() bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+// resetInterrupt clears any previous interrupt.
+func resetInterrupt() {
+	interrupted = make(chan struct{})
+	interruptedOnce = &sync.Once{}
+}
+
+// handleInterrupts listens for SIGINT and cancels in-flight requests so they
+// can abort cleanly. A second interrupt exits immediately. The returned
+// function stops listening.
+func handleInterrupts() func() {
+	resetInterrupt()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if isInterrupted() || atomic.LoadInt32(&activeRequests) == 0 {
+					fmt.Fprintln(Stderr)
+					os.Exit(130)
+				}
+				LogWarning("Interrupted, canceling request. Press Ctrl-C again to exit immediately.")
+				interrupt()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// withInterrupt returns a context which is canceled when the user interrupts
+// the running operation. The cancel function must be called when done.
+func withInterrupt(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := interrupted
+
+	atomic.AddInt32(&activeRequests, 1)
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		atomic.AddInt32(&activeRequests, -1)
+		cancel()
+	}
+}
+
+// interruptError marks errors caused by the user interrupting a request.
+func interruptError(err error) error {
+	if err != nil && isInterrupted() && errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%w: %v", ErrInterrupted, err)
+	}
+	return err
+}
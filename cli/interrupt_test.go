@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestInterrupt(t *testing.T) {
+	defer reset(false)
+
+	reset(false)
+	viper.Set("rsh-retry", 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate the user pressing Ctrl-C while waiting on the response.
+		interrupt()
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/slow", nil)
+	_, err := GetParsedResponse(req)
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRequestInterruptPagination(t *testing.T) {
+	defer reset(false)
+
+	reset(false)
+	viper.Set("rsh-retry", 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pages" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Link", "</pages2>; rel=\"next\"")
+			w.Write([]byte(`[1, 2]`))
+			return
+		}
+
+		interrupt()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	// Pages fetched before the interrupt are kept.
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/pages", nil)
+	parsed, err := GetParsedResponse(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0}, parsed.Body)
+}
+
+func TestInterruptError(t *testing.T) {
+	defer resetInterrupt()
+
+	// Errors are untouched until the user interrupts.
+	assert.NotErrorIs(t, interruptError(context.Canceled), ErrInterrupted)
+
+	interrupt()
+	assert.ErrorIs(t, interruptError(context.Canceled), ErrInterrupted)
+	assert.NotErrorIs(t, interruptError(assert.AnError), ErrInterrupted)
+	assert.Nil(t, interruptError(nil))
+}
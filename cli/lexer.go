@@ -5,6 +5,38 @@ import (
 	"github.com/alecthomas/chroma/lexers"
 )
 
+// readableScalars matches scalar values in readable output.
+var readableScalars = []chroma.Rule{
+	{
+		Pattern: `(true|false|null)\b`,
+		Type:    chroma.KeywordConstant,
+	},
+	{
+		Pattern: `"?0x[0-9a-f]+(\\.\\.\\.)?"?`,
+		Type:    chroma.LiteralNumberHex,
+	},
+	{
+		Pattern: `"?[0-9]{4}-[0-9]{2}-[0-9]{2}(T[0-9:+-.]+Z?)?"?`,
+		Type:    chroma.LiteralDate,
+	},
+	{
+		Pattern: `-?(0|[1-9]\d*)(\.\d+[eE](\+|-)?\d+|[eE](\+|-)?\d+|\.\d+)`,
+		Type:    chroma.LiteralNumberFloat,
+	},
+	{
+		Pattern: `-?(0|[1-9]\d*)`,
+		Type:    chroma.LiteralNumberInteger,
+	},
+	{
+		Pattern: `"([a-z]+://|/)(\\\\|\\"|[^"])+"`,
+		Type:    chroma.LiteralStringSymbol,
+	},
+	{
+		Pattern: `"(\\\\|\\"|[^"])*"`,
+		Type:    chroma.LiteralStringDouble,
+	},
+}
+
 // ReadableLexer colorizes the output of the Readable marshaller.
 var ReadableLexer = lexers.Register(chroma.MustNewLazyLexer(
 	&chroma.Config{
@@ -21,36 +53,7 @@ var ReadableLexer = lexers.Register(chroma.MustNewLazyLexer(
 					Type:    chroma.Text,
 				},
 			},
-			"scalar": {
-				{
-					Pattern: `(true|false|null)\b`,
-					Type:    chroma.KeywordConstant,
-				},
-				{
-					Pattern: `"?0x[0-9a-f]+(\\.\\.\\.)?"?`,
-					Type:    chroma.LiteralNumberHex,
-				},
-				{
-					Pattern: `"?[0-9]{4}-[0-9]{2}-[0-9]{2}(T[0-9:+-.]+Z?)?"?`,
-					Type:    chroma.LiteralDate,
-				},
-				{
-					Pattern: `-?(0|[1-9]\d*)(\.\d+[eE](\+|-)?\d+|[eE](\+|-)?\d+|\.\d+)`,
-					Type:    chroma.LiteralNumberFloat,
-				},
-				{
-					Pattern: `-?(0|[1-9]\d*)`,
-					Type:    chroma.LiteralNumberInteger,
-				},
-				{
-					Pattern: `"([a-z]+://|/)(\\\\|\\"|[^"])+"`,
-					Type:    chroma.LiteralStringSymbol,
-				},
-				{
-					Pattern: `"(\\\\|\\"|[^"])*"`,
-					Type:    chroma.LiteralStringDouble,
-				},
-			},
+			"scalar": readableScalars,
 			"objectrow": {
 				{
 					Pattern: `:`,
@@ -197,3 +200,71 @@ var SchemaLexer = lexers.Register(chroma.MustNewLazyLexer(
 		}
 	},
 ))
+
+// treeGuides emits indent level tokens for each column of the tree outline
+// guides so that each nesting level gets its own color.
+func treeGuides(groups []string, state *chroma.LexerState) chroma.Iterator {
+	columns := []rune(groups[1])
+	tokens := []chroma.Token{}
+	level := 0
+	for i := 0; i+4 <= len(columns); i += 4 {
+		tokens = append(tokens, chroma.Token{Type: chroma.TokenType(9000 + (level % 3)), Value: string(columns[i : i+4])})
+		level++
+	}
+	tokens = append(tokens, chroma.Token{Type: chroma.TokenType(9000 + (level % 3)), Value: groups[2]})
+	return chroma.Literator(tokens...)
+}
+
+// TreeLexer colorizes the output of the Tree marshaller.
+var TreeLexer = lexers.Register(chroma.MustNewLazyLexer(
+	&chroma.Config{
+		Name:         "CLI Tree",
+		Aliases:      []string{"tree"},
+		NotMultiline: true,
+		DotAll:       true,
+	},
+	func() chroma.Rules {
+		return chroma.Rules{
+			"summary": {
+				{
+					Pattern: `(\{\d*\}|\[\d*\])( …)?`,
+					Type:    chroma.Comment,
+				},
+			},
+			"value": {
+				{
+					Pattern: `\n`,
+					Type:    chroma.Text,
+					Mutator: chroma.Pop(2),
+				},
+				{
+					Pattern: `[ :]+`,
+					Type:    chroma.Punctuation,
+				},
+				chroma.Include("summary"),
+				chroma.Include("scalar"),
+			},
+			"scalar": readableScalars,
+			"label": {
+				{
+					Pattern: `\[\d+\]|"(\\\\|\\"|[^"])*"|[^:\n ]+`,
+					Type:    chroma.NameTag,
+					Mutator: chroma.Push("value"),
+				},
+			},
+			"root": {
+				{
+					Pattern: `((?:│   |    )*)(├── |└── )`,
+					Type:    chroma.EmitterFunc(treeGuides),
+					Mutator: chroma.Push("label"),
+				},
+				chroma.Include("summary"),
+				chroma.Include("scalar"),
+				{
+					Pattern: `\s+`,
+					Type:    chroma.Text,
+				},
+			},
+		}
+	},
+))
@@ -174,33 +174,71 @@ type sirenLink struct {
 }
 
 type sirenBody struct {
+	Links    []sirenLink   `mapstructure:"links"`
+	Entities []interface{} `mapstructure:"entities"`
+}
+
+// sirenEntity is a sub-entity, which is either an embedded link with its own
+// `href` or an embedded representation with its own links.
+type sirenEntity struct {
+	Rel   []string    `mapstructure:"rel"`
+	Href  string      `mapstructure:"href"`
 	Links []sirenLink `mapstructure:"links"`
 }
 
-// SirenParser parses Siren hypermedia links.
+// SirenParser parses Siren hypermedia links, including those of sub-entities.
 type SirenParser struct{}
 
 // ParseLinks processes the links in a parsed response.
 func (s SirenParser) ParseLinks(resp *Response) error {
 	siren := sirenBody{}
 	if err := mapstructure.Decode(resp.Body, &siren); err == nil {
-		for _, link := range siren.Links {
-			if link.Href == "" {
+		getSirenLinks(siren.Links, resp, false)
+
+		for _, e := range siren.Entities {
+			entity := sirenEntity{}
+			if err := mapstructure.Decode(e, &entity); err != nil {
 				continue
 			}
 
-			for _, rel := range link.Rel {
-				resp.Links[rel] = append(resp.Links[rel], &Link{
-					Rel: rel,
-					URI: link.Href,
-				})
+			if entity.Href != "" {
+				// Embedded link, the rels describe its relation to the parent.
+				for _, rel := range entity.Rel {
+					resp.Links[rel] = append(resp.Links[rel], &Link{
+						Rel: rel,
+						URI: entity.Href,
+					})
+				}
 			}
+
+			getSirenLinks(entity.Links, resp, true)
 		}
 	}
 
 	return nil
 }
 
+// getSirenLinks adds Siren links to the response. Sub-entity `self` links are
+// treated as collection items.
+func getSirenLinks(links []sirenLink, resp *Response, isItem bool) {
+	for _, link := range links {
+		if link.Href == "" {
+			continue
+		}
+
+		for _, rel := range link.Rel {
+			if isItem && rel == "self" {
+				rel = "item"
+			}
+
+			resp.Links[rel] = append(resp.Links[rel], &Link{
+				Rel: rel,
+				URI: link.Href,
+			})
+		}
+	}
+}
+
 func getJSONAPIlinks(links map[string]interface{}, resp *Response, isItem bool) {
 	for k, v := range links {
 		rel := k
@@ -251,3 +289,42 @@ func (j JSONAPIParser) ParseLinks(resp *Response) error {
 
 	return nil
 }
+
+// ODataParser parses OData `@odata.nextLink` pagination and `@odata.id`
+// entity links.
+type ODataParser struct{}
+
+// ParseLinks processes the links in a parsed response.
+func (o ODataParser) ParseLinks(resp *Response) error {
+	if b, ok := resp.Body.(map[string]interface{}); ok {
+		if s, ok := b["@odata.nextLink"].(string); ok {
+			resp.Links["next"] = append(resp.Links["next"], &Link{
+				Rel: "next",
+				URI: s,
+			})
+		}
+
+		if s, ok := b["@odata.id"].(string); ok {
+			resp.Links["self"] = append(resp.Links["self"], &Link{
+				Rel: "self",
+				URI: s,
+			})
+		}
+
+		// Find collection item links
+		if v, ok := b["value"].([]interface{}); ok {
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					if s, ok := m["@odata.id"].(string); ok {
+						resp.Links["item"] = append(resp.Links["item"], &Link{
+							Rel: "item",
+							URI: s,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
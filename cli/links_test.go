@@ -159,6 +159,62 @@ func TestSirenParser(t *testing.T) {
 	assert.Equal(t, r.Links["two"][0].URI, "/multi")
 }
 
+func TestSirenParserEntities(t *testing.T) {
+	r := &Response{
+		Links: Links{},
+		Body: map[string]interface{}{
+			"links": []interface{}{
+				map[string]interface{}{"rel": []interface{}{"next"}, "href": "/items?page=2"},
+			},
+			"entities": []interface{}{
+				map[string]interface{}{
+					"rel":  []interface{}{"author"},
+					"href": "/people/1",
+				},
+				map[string]interface{}{
+					"rel": []interface{}{"item"},
+					"links": []interface{}{
+						map[string]interface{}{"rel": []interface{}{"self"}, "href": "/items/1"},
+						map[string]interface{}{"rel": []interface{}{"edit"}, "href": "/items/1/edit"},
+					},
+				},
+				"invalid",
+			},
+		},
+	}
+
+	s := SirenParser{}
+	err := s.ParseLinks(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "/items?page=2", r.Links["next"][0].URI)
+	assert.Equal(t, "/people/1", r.Links["author"][0].URI)
+	assert.Equal(t, "/items/1", r.Links["item"][0].URI)
+	assert.Equal(t, "/items/1/edit", r.Links["edit"][0].URI)
+	assert.Empty(t, r.Links["self"])
+}
+
+func TestODataParser(t *testing.T) {
+	r := &Response{
+		Links: Links{},
+		Body: map[string]interface{}{
+			"@odata.id":       "/People",
+			"@odata.nextLink": "/People?$skiptoken=2",
+			"value": []interface{}{
+				map[string]interface{}{
+					"@odata.id": "/People('a')",
+				},
+			},
+		},
+	}
+
+	o := ODataParser{}
+	err := o.ParseLinks(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "/People", r.Links["self"][0].URI)
+	assert.Equal(t, "/People?$skiptoken=2", r.Links["next"][0].URI)
+	assert.Equal(t, "/People('a')", r.Links["item"][0].URI)
+}
+
 func TestJSONAPIParser(t *testing.T) {
 	r := &Response{
 		Links: Links{},
@@ -6,8 +6,6 @@ import (
 	"net/http/httputil"
 	"strings"
 	"time"
-
-	"github.com/alecthomas/chroma/quick"
 )
 
 var enableVerbose bool
@@ -30,7 +28,7 @@ func LogDebugRequest(req *http.Request) {
 
 		if useColor {
 			sb := &strings.Builder{}
-			quick.Highlight(sb, string(dumped), "http", "terminal256", "cli-dark")
+			highlight(sb, string(dumped), "http")
 			dumped = []byte(sb.String())
 		}
 
@@ -38,18 +36,44 @@ func LogDebugRequest(req *http.Request) {
 	}
 }
 
+// dumpDryRun returns the request as it would be sent over the wire, like in
+// the verbose request log, but with the full URL in the request line. The
+// body is read and reset so the request could still be sent.
+func dumpDryRun(req *http.Request) ([]byte, error) {
+	out := req.Clone(req.Context())
+	out.RequestURI = req.URL.String()
+	dumped, err := httputil.DumpRequest(out, true)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = out.Body
+
+	if len(dumped) > 0 && dumped[len(dumped)-1] != '\n' {
+		dumped = append(dumped, '\n')
+	}
+
+	if useColor {
+		sb := &strings.Builder{}
+		highlight(sb, string(dumped), "http")
+		dumped = []byte(sb.String())
+	}
+
+	return dumped, nil
+}
+
 // LogDebugResponse logs the response in a debug message if verbose output
 // is enabled.
 func LogDebugResponse(start time.Time, resp *http.Response) {
 	if enableVerbose {
-		dumped, err := httputil.DumpResponse(resp, true)
+		// Event streams may never end, so their body isn't dumped.
+		dumped, err := httputil.DumpResponse(resp, !isEventStream(resp))
 		if err != nil {
 			return
 		}
 
 		if useColor {
 			sb := &strings.Builder{}
-			quick.Highlight(sb, string(dumped), "http", "terminal256", "cli-dark")
+			highlight(sb, string(dumped), "http")
 			dumped = []byte(sb.String())
 		}
 
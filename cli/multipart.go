@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/shorthand/v2"
+)
+
+// multipartFileRegex matches file upload arguments like `file@./photo.png`.
+var multipartFileRegex = regexp.MustCompile(`^([A-Za-z0-9_.\[\]-]+)@(.+)$`)
+
+// quoteEscaper escapes quotes and backslashes in part header values.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartFile is a file to upload as part of a multipart/form-data body.
+type multipartFile struct {
+	field string
+	path  string
+}
+
+// splitMultipartArgs separates file upload arguments like `file@photo.png`
+// from the remaining shorthand arguments. Values like the email address in
+// `email: me@example.com` are not treated as files.
+func splitMultipartArgs(args []string) ([]multipartFile, []string) {
+	var files []multipartFile
+	var rest []string
+
+	for i, arg := range args {
+		if i == 0 || !strings.HasSuffix(args[i-1], ":") {
+			if m := multipartFileRegex.FindStringSubmatch(arg); m != nil {
+				files = append(files, multipartFile{field: m[1], path: m[2]})
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+
+	return files, rest
+}
+
+// hasMultipartFiles returns whether any arguments are file uploads.
+func hasMultipartFiles(args []string) bool {
+	files, _ := splitMultipartArgs(args)
+	return len(files) > 0
+}
+
+// writeMultipart writes the text fields and then the files as parts of a
+// multipart/form-data body. Files are streamed from disk.
+func writeMultipart(w *multipart.Writer, fields map[string]any, files []multipartFile) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values, ok := fields[name].([]any)
+		if !ok {
+			values = []any{fields[name]}
+		}
+
+		// Arrays are sent as repeated fields, e.g. `tags[]: a, b`.
+		for _, value := range values {
+			text, ok := value.(string)
+			if !ok {
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return err
+				}
+				text = string(encoded)
+			}
+
+			if err := w.WriteField(name, text); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file.path)
+		if err != nil {
+			return err
+		}
+
+		contentType := mime.TypeByExtension(filepath.Ext(file.path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(file.field), quoteEscaper.Replace(filepath.Base(file.path))))
+		h.Set("Content-Type", contentType)
+
+		part, err := w.CreatePart(h)
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// newMultipartRequest creates a request with a multipart/form-data body built
+// from the arguments. File uploads like `file@./photo.png` are streamed from
+// disk rather than buffered in memory, and other shorthand arguments become
+// text fields, e.g. `caption: hello`.
+func newMultipartRequest(method, uri string, args []string) (*http.Request, error) {
+	files, rest := splitMultipartArgs(args)
+
+	for _, file := range files {
+		// Fail early rather than partway through sending the body.
+		info, err := os.Stat(file.path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("cannot upload directory %s", file.path)
+		}
+	}
+
+	fields := map[string]any{}
+	if len(rest) > 0 {
+		input, err := shorthand.Unmarshal(strings.Join(rest, " "), shorthand.ParseOptions{
+			EnableFileInput:       true,
+			EnableObjectDetection: true,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := makeJSONSafe(input).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("multipart form fields must be an object, e.g. `caption: hello`")
+		}
+		fields = m
+	}
+
+	// Each call gets a fresh stream so the body can be sent again, e.g. for
+	// retries or redirects. The boundary stays the same for all of them.
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	getBody := func() (io.ReadCloser, error) {
+		r, w := io.Pipe()
+		go func() {
+			mw := multipart.NewWriter(w)
+			mw.SetBoundary(boundary)
+			w.CloseWithError(writeMultipart(mw, fields, files))
+		}()
+		return r, nil
+	}
+
+	body, _ := getBody()
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.GetBody = getBody
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+
+	return req, nil
+}
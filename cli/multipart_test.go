@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestSplitMultipartArgs(t *testing.T) {
+	files, rest := splitMultipartArgs([]string{"file@./photo.png", "caption:", "hello,", "email:", "me@example.com"})
+	assert.Equal(t, []multipartFile{{field: "file", path: "./photo.png"}}, files)
+	assert.Equal(t, []string{"caption:", "hello,", "email:", "me@example.com"}, rest)
+
+	assert.False(t, hasMultipartFiles([]string{"email:", "me@example.com"}))
+}
+
+// readMultipart parses a multipart/form-data request body into a map of field
+// name to value, including file contents. Repeated fields are comma-separated.
+func readMultipart(t *testing.T, req *http.Request) map[string]string {
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(req.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		b, _ := io.ReadAll(part)
+		key := part.FormName()
+		if part.FileName() != "" {
+			key += ":" + part.FileName() + ":" + part.Header.Get("Content-Type")
+		}
+		if parts[key] != "" {
+			parts[key] += ","
+		}
+		parts[key] += string(b)
+	}
+
+	return parts
+}
+
+func TestMultipartUpload(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "notes.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"hello": "world"}`), 0600))
+
+	var parts map[string]string
+	gock.New("http://example.com").
+		Post("/files").
+		MatchHeader("Content-Type", "^multipart/form-data; boundary=").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			parts = readMultipart(t, req)
+			return true, nil
+		}).
+		Reply(http.StatusCreated)
+
+	run("post http://example.com/files file@" + filename + " caption: hello, tags[]: a, tags[]: b, count: 5")
+	expectExitCode(t, 0)
+
+	assert.Equal(t, map[string]string{
+		"file:notes.json:application/json": `{"hello": "world"}`,
+		"caption":                          "hello",
+		"tags":                             "a,b",
+		"count":                            "5",
+	}, parts)
+}
+
+func TestMultipartUploadRetry(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "data.bin")
+	assert.NoError(t, os.WriteFile(filename, []byte{0, 1, 2}, 0600))
+
+	gock.New("http://example.com").
+		Post("/files").
+		Reply(http.StatusServiceUnavailable).
+		SetHeader("Retry-After", "0")
+
+	// The retry must send the full body again, re-read from disk.
+	var parts map[string]string
+	gock.New("http://example.com").
+		Post("/files").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			parts = readMultipart(t, req)
+			return true, nil
+		}).
+		Reply(http.StatusCreated)
+
+	viper.Set("rsh-retry", 1)
+	req, err := newMultipartRequest(http.MethodPost, "http://example.com/files", []string{"upload@" + filename})
+	assert.NoError(t, err)
+
+	resp, err := MakeRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, map[string]string{
+		"upload:data.bin:application/octet-stream": "\x00\x01\x02",
+	}, parts)
+}
+
+func TestMultipartMissingFile(t *testing.T) {
+	_, err := newMultipartRequest(http.MethodPost, "http://example.com/files", []string{"file@does-not-exist.png"})
+	assert.Error(t, err)
+}
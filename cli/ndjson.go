@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// NDJSON describes newline-delimited JSON content types like
+// `application/x-ndjson` or `application/jsonlines`, where each line is a
+// separate JSON value. Lines are combined into a single array so they work
+// with filtering & output formatting like any other list.
+type NDJSON struct{}
+
+// Detect if the content type is newline-delimited JSON.
+func (n NDJSON) Detect(contentType string) bool {
+	switch strings.TrimSpace(strings.Split(contentType, ";")[0]) {
+	case "application/x-ndjson", "application/ndjson", "application/jsonlines", "application/x-jsonlines", "application/jsonl":
+		return true
+	}
+
+	return false
+}
+
+// Marshal the value to newline-delimited JSON, one line per array item.
+func (n NDJSON) Marshal(value interface{}) ([]byte, error) {
+	items := []any{value}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		items = make([]any, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).Interface()
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, item := range items {
+		if err := enc.Encode(makeJSONSafe(item)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal the lines into an array of values.
+func (n NDJSON) Unmarshal(data []byte, value interface{}) error {
+	items := []any{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var item any
+		if err := dec.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		items = append(items, item)
+	}
+
+	if p, ok := value.(*any); ok {
+		*p = items
+		return nil
+	}
+
+	// Decode into other types via JSON, e.g. `[]map[string]any`.
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, value)
+}
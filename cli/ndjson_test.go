@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestNDJSONDetect(t *testing.T) {
+	for _, ct := range []string{"application/x-ndjson", "application/jsonlines; charset=utf-8", "application/ndjson"} {
+		assert.True(t, NDJSON{}.Detect(ct), ct)
+	}
+	assert.False(t, NDJSON{}.Detect("application/json"))
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	var data any
+	assert.NoError(t, NDJSON{}.Unmarshal([]byte("{\"id\": 1}\n\n[true]\n\"done\"\n"), &data))
+	assert.Equal(t, []any{map[string]any{"id": 1.0}, []any{true}, "done"}, data)
+
+	b, err := NDJSON{}.Marshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":1}\n[true]\n\"done\"\n", string(b))
+
+	var typed []map[string]int
+	assert.NoError(t, NDJSON{}.Unmarshal([]byte("{\"id\": 1}\n{\"id\": 2}\n"), &typed))
+	assert.Equal(t, []map[string]int{{"id": 1}, {"id": 2}}, typed)
+
+	assert.Error(t, NDJSON{}.Unmarshal([]byte("{\"id\": 1}\n{bad\n"), &data))
+}
+
+func TestNDJSONResponse(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/events").Reply(http.StatusOK).
+		SetHeader("Content-Type", "application/x-ndjson").
+		BodyString("{\"id\": 1}\n{\"id\": 2}\n")
+
+	captured := run("http://example.com/events -f body[].id -o json")
+	assert.Equal(t, "[\n  1,\n  2\n]\n", captured)
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// OpenBrowser opens the specified URL in the default browser regardless of
+// OS. It is a variable so it can be replaced, e.g. for testing.
+var OpenBrowser = func(url string) error {
+	var cmd string
+	var args []string
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = "cmd"
+		args = []string{"/c", "start"}
+	case "darwin": // mac, ios
+		cmd = "open"
+	default: // "linux", "freebsd", "openbsd", "netbsd"
+		cmd = "xdg-open"
+	}
+	args = append(args, url)
+	return exec.Command(cmd, args...).Start()
+}
+
+// docsURL returns the documentation URL for an API. Configured URLs take
+// precedence over those from the API description. Falls back to the API
+// base URL if no docs are available.
+func docsURL(config *APIConfig, addr string) (string, error) {
+	if config == nil {
+		return addr, nil
+	}
+
+	if config.DocsURL != "" {
+		return config.DocsURL, nil
+	}
+
+	base, err := config.baseFor(viper.GetString("rsh-profile"))
+	if err != nil {
+		return "", err
+	}
+
+	api, err := Load(base, &cobra.Command{Version: Root.Version})
+	if err != nil {
+		return "", err
+	}
+
+	if api.DocsURL != "" {
+		return api.DocsURL, nil
+	}
+
+	return base, nil
+}
+
+// openDocs opens an API's documentation in the browser, printing the URL
+// instead if the browser cannot be opened.
+func openDocs(arg string) error {
+	addr := fixAddress(arg)
+	var config *APIConfig
+	if c, ok := configs[arg]; ok {
+		config = c
+	}
+
+	target, err := docsURL(config, addr)
+	if err != nil {
+		return err
+	}
+
+	if err := OpenBrowser(target); err != nil {
+		LogWarning("Unable to open browser: %v", err)
+		fmt.Fprintln(Stdout, target)
+	}
+
+	return nil
+}
@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/gosimple/slug"
@@ -27,8 +30,18 @@ type Operation struct {
 	HeaderParams  []*Param `json:"header_params,omitempty" yaml:"header_params,omitempty"`
 	BodyMediaType string   `json:"body_media_type,omitempty" yaml:"body_media_type,omitempty"`
 	Examples      []string `json:"examples,omitempty" yaml:"examples,omitempty"`
+	BodySchema    string   `json:"body_schema,omitempty" yaml:"body_schema,omitempty"`
+	Seed          string   `json:"seed,omitempty" yaml:"seed,omitempty"`
+	SeedAll       string   `json:"seed_all,omitempty" yaml:"seed_all,omitempty"`
 	Hidden        bool     `json:"hidden,omitempty" yaml:"hidden,omitempty"`
 	Deprecated    string   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	RateLimit     string   `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	// GraphQL is the query or mutation document to send, if any. Its variables
+	// come from the query params and the body input.
+	GraphQL string `json:"graphql,omitempty" yaml:"graphql,omitempty"`
+	// ResponseSchemas maps status codes like `200`, `2XX`, or `default` to the
+	// JSON Schema of the response body, used by `rsh-validate-response`.
+	ResponseSchemas map[string]string `json:"response_schemas,omitempty" yaml:"response_schemas,omitempty"`
 }
 
 // command returns a Cobra command instance for this operation.
@@ -45,6 +58,15 @@ func (o Operation) command() *cobra.Command {
 		argSpec = cobra.MinimumNArgs(len(o.PathParams))
 	}
 
+	checkArgs := argSpec
+	argSpec = func(cmd *cobra.Command, args []string) error {
+		if interactive, _ := cmd.Flags().GetBool("rsh-interactive"); interactive {
+			// Missing arguments will be prompted for.
+			return nil
+		}
+		return checkArgs(cmd, args)
+	}
+
 	long := o.Long
 
 	examples := ""
@@ -53,16 +75,33 @@ func (o Operation) command() *cobra.Command {
 	}
 
 	sub := &cobra.Command{
-		Use:        use,
-		GroupID:    o.Group,
-		Aliases:    o.Aliases,
-		Short:      o.Short,
-		Long:       long,
-		Example:    examples,
-		Args:       argSpec,
-		Hidden:     o.Hidden,
-		Deprecated: o.Deprecated,
+		Use:     use,
+		GroupID: o.Group,
+		Aliases: o.Aliases,
+		Short:   o.Short,
+		Long:    long,
+		Example: examples,
+		Args:    argSpec,
+		// Deprecated operations are hidden from help but still work, with a
+		// warning when used.
+		Hidden: o.Hidden || o.Deprecated != "",
 		Run: func(cmd *cobra.Command, args []string) {
+			if o.Deprecated != "" {
+				LogWarning("Operation %s is deprecated: %s", o.Name, o.Deprecated)
+			}
+
+			if interactive, _ := cmd.Flags().GetBool("rsh-interactive"); interactive {
+				var err error
+				if args, err = askOperationDefault(o, cmd, args); err != nil {
+					panic(err)
+				}
+			}
+
+			if o.RateLimit != "" && viper.GetString("rsh-rate-limit") == "" {
+				// Pace requests, including for pagination, to the documented limit.
+				viper.Set("rsh-rate-limit", o.RateLimit)
+			}
+
 			uri := o.URITemplate
 
 			for i, param := range o.PathParams {
@@ -75,6 +114,13 @@ func (o Operation) command() *cobra.Command {
 				uri = strings.Replace(uri, "{"+param.Name+"}", fmt.Sprintf("%v", value), 1)
 			}
 
+			if o.GraphQL != "" {
+				if err := o.graphQLOperation(cmd, flags, uri, args[len(o.PathParams):]); err != nil {
+					panic(err)
+				}
+				return
+			}
+
 			query := url.Values{}
 			for _, param := range o.QueryParams {
 				if !cmd.Flags().Changed(param.OptionName()) {
@@ -127,18 +173,55 @@ func (o Operation) command() *cobra.Command {
 				}
 			}
 
+			// The body is skipped entirely when asked not to send one, so
+			// nothing is read from stdin or disk.
+			noBody := viper.GetBool("rsh-no-body")
+
+			if strings.HasPrefix(o.BodyMediaType, "multipart/form-data") && !noBody {
+				req, err := newMultipartRequest(o.Method, uri, args[len(o.PathParams):])
+				if err != nil {
+					panic(err)
+				}
+				for k, v := range headers {
+					req.Header[k] = v
+				}
+				MakeRequestAndFormat(req)
+				return
+			}
+
 			var body io.Reader
 
-			if o.BodyMediaType != "" {
-				b, err := GetBody(o.BodyMediaType, args[len(o.PathParams):])
+			if o.BodyMediaType != "" && !noBody {
+				seed := ""
+				if viper.GetBool("rsh-seed-all") {
+					seed = o.SeedAll
+				} else if viper.GetBool("rsh-seed") {
+					seed = o.Seed
+				}
+
+				var b string
+				var err error
+				if seed != "" {
+					b, err = GetSeededBody(o.BodyMediaType, seed, args[len(o.PathParams):])
+				} else {
+					b, err = GetBody(o.BodyMediaType, args[len(o.PathParams):])
+				}
 				if err != nil {
 					panic(err)
 				}
+				if viper.GetBool("rsh-validate") && o.BodySchema != "" && b != "" {
+					if err := validateBody(o.BodyMediaType, o.BodySchema, b); err != nil {
+						panic(err)
+					}
+				}
 				body = strings.NewReader(b)
 			}
 
 			req, _ := http.NewRequest(o.Method, uri, body)
 			req.Header = headers
+			if viper.GetBool("rsh-validate-response") && len(o.ResponseSchemas) > 0 {
+				req = req.WithContext(context.WithValue(req.Context(), responseSchemasContextKey{}, o.ResponseSchemas))
+			}
 			MakeRequestAndFormat(req)
 		},
 	}
@@ -151,5 +234,135 @@ func (o Operation) command() *cobra.Command {
 		flags[p.Name] = p.AddFlag(sub.Flags())
 	}
 
+	sub.Flags().BoolP("rsh-interactive", "i", false, "Interactively prompt for params and the request body")
+
 	return sub
 }
+
+// reTemplateParam matches URI template params like `{id}`.
+var reTemplateParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// operationPath returns the unescaped URL path of an operation's template.
+func operationPath(op Operation) string {
+	if parsed, err := url.Parse(op.URITemplate); err == nil {
+		return parsed.Path
+	}
+	return op.URITemplate
+}
+
+// findOperation returns the operation matching an HTTP method and path, e.g.
+// `GET /users/{id}`. Path param names are ignored when matching, and the path
+// may omit the API base path prefix.
+func findOperation(ops []Operation, spec string) (Operation, error) {
+	method, path, _ := strings.Cut(strings.TrimSpace(spec), " ")
+	method = strings.ToUpper(method)
+	path = reTemplateParam.ReplaceAllString(strings.TrimSpace(path), "{}")
+	if path == "" {
+		return Operation{}, fmt.Errorf("expected an operation like 'GET /path' but got '%s'", spec)
+	}
+
+	var matches []Operation
+	for _, op := range ops {
+		if op.Method != method {
+			continue
+		}
+
+		opPath := reTemplateParam.ReplaceAllString(operationPath(op), "{}")
+		if opPath == path {
+			// Exact matches always win.
+			return op, nil
+		}
+
+		if strings.HasSuffix(opPath, path) && strings.HasPrefix(path, "/") {
+			matches = append(matches, op)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	if len(matches) > 1 {
+		return Operation{}, fmt.Errorf("operation '%s' is ambiguous, please use a longer path", spec)
+	}
+
+	return Operation{}, fmt.Errorf("no operation found for '%s', use '--op list' to see available operations", spec)
+}
+
+// extractOpFlag finds and removes an `--op` flag and its value from the
+// arguments.
+func extractOpFlag(args []string) (string, []string, bool) {
+	for i, arg := range args {
+		if arg == "--op" && i+1 < len(args) {
+			return args[i+1], append(append([]string{}, args[:i]...), args[i+2:]...), true
+		}
+
+		if strings.HasPrefix(arg, "--op=") {
+			return strings.TrimPrefix(arg, "--op="), append(append([]string{}, args[:i]...), args[i+1:]...), true
+		}
+	}
+
+	return "", args, false
+}
+
+// operationArgs rewrites an `--op 'METHOD /path'` invocation of an API
+// command into the arguments for the generated operation command. Path
+// params can be passed as `name=value` and are moved into position.
+func operationArgs(api API, apiName, spec string, args []string) ([]string, error) {
+	op, err := findOperation(api.Operations, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if op.Name == "" {
+		return nil, fmt.Errorf("operation '%s' has no command name", spec)
+	}
+
+	named := map[string]string{}
+	rest := []string{}
+	for _, arg := range args {
+		if k, v, ok := strings.Cut(arg, "="); ok && !strings.HasPrefix(arg, "-") {
+			matched := false
+			for _, p := range op.PathParams {
+				if k == p.Name || k == p.OptionName() {
+					named[p.Name] = v
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+
+	positional := []string{slug.Make(op.Name)}
+	for _, p := range op.PathParams {
+		if v, ok := named[p.Name]; ok {
+			positional = append(positional, v)
+		}
+	}
+
+	// Insert the operation name and path params right after the API name.
+	for i, arg := range rest {
+		if arg == apiName {
+			return append(append(append([]string{}, rest[:i+1]...), positional...), rest[i+1:]...), nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find API %s in arguments", apiName)
+}
+
+// printOperations writes a list of operations by HTTP method and path.
+func printOperations(api API) {
+	lines := []string{}
+	for _, op := range api.Operations {
+		lines = append(lines, fmt.Sprintf("%-7s %s\t%s", op.Method, operationPath(op), slug.Make(op.Name)))
+	}
+	sort.Strings(lines)
+
+	for _, line := range lines {
+		fmt.Fprintln(Stdout, line)
+	}
+}
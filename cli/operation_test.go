@@ -2,9 +2,11 @@ package cli
 
 import (
 	"net/http"
+	"os"
 	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/h2non/gock.v1"
@@ -101,3 +103,194 @@ func TestOperation(t *testing.T) {
 
 	assert.Equal(t, "HTTP/1.1 200 OK\nContent-Type: application/json\n\n{\n  hello: \"world\"\n}\n", capture.String())
 }
+
+func TestFindOperation(t *testing.T) {
+	ops := []Operation{
+		{Name: "list-users", Method: http.MethodGet, URITemplate: "https://api.example.com/v1/users"},
+		{Name: "get-user", Method: http.MethodGet, URITemplate: "https://api.example.com/v1/users/{user-id}"},
+		{Name: "delete-user", Method: http.MethodDelete, URITemplate: "https://api.example.com/v1/users/{user-id}"},
+		{Name: "get-item", Method: http.MethodGet, URITemplate: "https://api.example.com/v1/items/{id}"},
+		{Name: "get-legacy-item", Method: http.MethodGet, URITemplate: "https://api.example.com/v0/items/{id}"},
+	}
+
+	op, err := findOperation(ops, "get /v1/users/{id}")
+	assert.NoError(t, err)
+	assert.Equal(t, "get-user", op.Name)
+
+	op, err = findOperation(ops, "DELETE /users/{x}")
+	assert.NoError(t, err)
+	assert.Equal(t, "delete-user", op.Name)
+
+	_, err = findOperation(ops, "GET /items/{id}")
+	assert.ErrorContains(t, err, "ambiguous")
+
+	_, err = findOperation(ops, "POST /users")
+	assert.ErrorContains(t, err, "no operation found")
+
+	_, err = findOperation(ops, "GET")
+	assert.Error(t, err)
+}
+
+func TestOperationByMethodAndPath(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("https://op-flag.example.com").Get("/users/5").Reply(200).JSON(map[string]any{
+		"id": 5,
+	})
+
+	reset(false)
+	viper.Set("rsh-no-cache", true)
+
+	configs["op-flag"] = &APIConfig{
+		name:      "op-flag",
+		Base:      "https://op-flag.example.com",
+		SpecFiles: []string{"testdata/petstore.json"},
+	}
+	cmd := &cobra.Command{Use: "op-flag"}
+	Root.AddCommand(cmd)
+
+	AddLoader(&testLoader{
+		API: API{
+			Operations: []Operation{
+				{
+					Name:        "get-user",
+					Method:      http.MethodGet,
+					URITemplate: "https://op-flag.example.com/users/{user-id}",
+					PathParams:  []*Param{{Type: "string", Name: "user-id"}},
+				},
+			},
+		},
+	})
+
+	capture := &strings.Builder{}
+	Stdout = capture
+	Stderr = &strings.Builder{}
+	os.Args = []string{"restish", "op-flag", "--op", "GET /users/{id}", "user-id=5", "-o", "json", "-f", "body"}
+	assert.NoError(t, Run())
+	assert.JSONEq(t, `{"id": 5}`, capture.String())
+
+	capture.Reset()
+	os.Args = []string{"restish", "op-flag", "--op", "list"}
+	assert.NoError(t, Run())
+	assert.Equal(t, "GET     /users/{user-id}\tget-user\n", capture.String())
+}
+
+func TestOperationValidate(t *testing.T) {
+	defer gock.Off()
+
+	op := Operation{
+		Name:          "create",
+		Method:        http.MethodPost,
+		URITemplate:   "http://example.com/items",
+		BodyMediaType: "application/json",
+		BodySchema:    `{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "size": {"type": "string", "enum": ["small", "large"]}}}`,
+	}
+
+	viper.Reset()
+	viper.Set("nocolor", true)
+	Init("test", "1.0.0")
+	Defaults()
+	capture := &strings.Builder{}
+	Stdout = capture
+	Stderr = capture
+	viper.Set("rsh-validate", true)
+
+	// Invalid bodies are not sent.
+	cmd := op.command()
+	assert.PanicsWithError(t, "request body does not match schema (2 errors), not sending", func() {
+		cmd.Run(cmd, []string{"size: medium"})
+	})
+	assert.Contains(t, capture.String(), "body.name: required property is missing")
+	assert.Contains(t, capture.String(), "body.size: expected one of [small large] but got medium")
+
+	gock.New("http://example.com").Post("/items").Reply(http.StatusCreated)
+
+	cmd = op.command()
+	cmd.Run(cmd, []string{"name: foo, size: small"})
+	assert.True(t, gock.IsDone())
+}
+
+func TestOperationValidateResponse(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/items/1").Reply(http.StatusOK).JSON(map[string]any{
+		"id": "one",
+	})
+
+	gock.New("http://example.com").Get("/items/2").Reply(http.StatusNotFound).JSON(map[string]any{
+		"title": "Not Found",
+	})
+
+	op := Operation{
+		Name:        "get-item",
+		Method:      http.MethodGet,
+		URITemplate: "http://example.com/items/{id}",
+		PathParams:  []*Param{{Type: "string", Name: "id"}},
+		ResponseSchemas: map[string]string{
+			"200":     `{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}`,
+			"4XX":     `{"type": "object", "required": ["title"], "properties": {"title": {"type": "string"}}}`,
+			"default": `{"type": "string"}`,
+		},
+	}
+
+	viper.Reset()
+	viper.Set("nocolor", true)
+	Init("test", "1.0.0")
+	Defaults()
+	capture := &strings.Builder{}
+	Stdout = capture
+	Stderr = capture
+	viper.Set("rsh-validate-response", true)
+
+	// Mismatches are warnings, and the response is still shown.
+	cmd := op.command()
+	cmd.Run(cmd, []string{"1"})
+	assert.Contains(t, capture.String(), "WARN: body.id: expected integer but got string")
+	assert.Contains(t, capture.String(), "WARN: body.name: required property is missing")
+	assert.Contains(t, capture.String(), "WARN: Response does not match the 200 schema (2 errors)")
+	assert.Contains(t, capture.String(), `"id": "one"`)
+
+	// Status code ranges are matched before the default response.
+	capture.Reset()
+	cmd = op.command()
+	cmd.Run(cmd, []string{"2"})
+	assert.NotContains(t, capture.String(), "WARN")
+	assert.True(t, gock.IsDone())
+}
+
+func TestResponseSchema(t *testing.T) {
+	schemas := map[string]string{"200": "a", "2xx": "b", "default": "c"}
+	assert.Equal(t, "a", responseSchema(schemas, 200))
+	assert.Equal(t, "b", responseSchema(schemas, 201))
+	assert.Equal(t, "c", responseSchema(schemas, 500))
+	assert.Equal(t, "", responseSchema(map[string]string{"200": "a"}, 404))
+}
+
+func TestOperationDeprecated(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/old").Reply(http.StatusNoContent)
+
+	op := Operation{
+		Name:        "get-old",
+		Method:      http.MethodGet,
+		URITemplate: "http://example.com/old",
+		Deprecated:  "use get-new instead",
+	}
+
+	viper.Reset()
+	viper.Set("nocolor", true)
+	Init("test", "1.0.0")
+	Defaults()
+	capture := &strings.Builder{}
+	Stdout = capture
+	Stderr = capture
+
+	cmd := op.command()
+	assert.True(t, cmd.Hidden)
+
+	cmd.Run(cmd, []string{})
+	assert.Contains(t, capture.String(), "WARN: Operation get-old is deprecated: use get-new instead")
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+// pageParams are query parameter names commonly used for page numbers, used
+// to guess the total number of pages from a `last` link.
+var pageParams = []string{"page", "page[number]", "p", "pageNumber", "page_number"}
+
+// pageLink returns the first link for a pagination relation, also accepting
+// the long form `previous` for `prev`.
+func pageLink(links Links, rel string) *Link {
+	rels := []string{rel}
+	if rel == "prev" {
+		rels = append(rels, "previous")
+	}
+	for _, r := range rels {
+		if len(links[r]) > 0 {
+			return links[r][0]
+		}
+	}
+	return nil
+}
+
+// totalPages guesses the total number of pages from the page number in the
+// `last` link, if present.
+func totalPages(links Links) (int, bool) {
+	last := pageLink(links, "last")
+	if last == nil {
+		return 0, false
+	}
+
+	u, err := url.Parse(last.URI)
+	if err != nil {
+		return 0, false
+	}
+
+	query := u.Query()
+	for _, name := range pageParams {
+		if n, err := strconv.Atoi(query.Get(name)); err == nil && n > 0 {
+			return n, true
+		}
+	}
+
+	return 0, false
+}
+
+// getPage fetches a single page without auto-pagination.
+func getPage(uri string) (Response, error) {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return Response{}, err
+	}
+	return GetParsedResponse(req)
+}
+
+// paginate fetches a page and then navigates from it using the given link
+// relation, one of `first`, `prev`, `next`, or `last`. When there is no
+// direct `first` or `last` link, `prev` or `next` links are walked instead
+// until the end is reached. The resulting page is printed.
+func paginate(uri, to string) error {
+	walk := ""
+	switch to {
+	case "first":
+		walk = "prev"
+	case "last":
+		walk = "next"
+	case "prev", "next":
+	default:
+		return fmt.Errorf("invalid link relation %s, expected one of first, prev, next, or last", to)
+	}
+
+	// Each page is shown on its own rather than merged into a single list.
+	viper.Set("rsh-no-paginate", true)
+
+	parsed, err := getPage(uri)
+	if err != nil {
+		return err
+	}
+
+	if total, ok := totalPages(parsed.Links); ok {
+		LogInfo("Total pages: %d", total)
+	}
+
+	link := pageLink(parsed.Links, to)
+	if link == nil && walk != "" {
+		link = pageLink(parsed.Links, walk)
+	} else {
+		walk = ""
+	}
+	if link == nil {
+		return fmt.Errorf("no %s link found", to)
+	}
+
+	seen := map[string]bool{parsed.URL: true}
+	for link != nil {
+		if seen[link.URI] {
+			return fmt.Errorf("pagination loop detected at %s", link.URI)
+		}
+		seen[link.URI] = true
+
+		LogDebug("Following rel=%s link %s", link.Rel, link.URI)
+		if parsed, err = getPage(link.URI); err != nil {
+			return err
+		}
+
+		link = nil
+		if walk != "" {
+			link = pageLink(parsed.Links, walk)
+		}
+	}
+
+	formatResponse(parsed)
+	return nil
+}
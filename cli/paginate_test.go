@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestTotalPages(t *testing.T) {
+	total, ok := totalPages(Links{"last": {{Rel: "last", URI: "https://example.com/items?page=7&size=10"}}})
+	assert.True(t, ok)
+	assert.Equal(t, 7, total)
+
+	_, ok = totalPages(Links{"last": {{Rel: "last", URI: "https://example.com/items?cursor=abc"}}})
+	assert.False(t, ok)
+
+	_, ok = totalPages(Links{})
+	assert.False(t, ok)
+}
+
+func TestPaginateLast(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/items").MatchParam("page", "1").Reply(200).
+		SetHeader("Link", `</items?page=2>; rel="next", </items?page=3>; rel="last"`).
+		JSON([]any{1})
+	gock.New("http://example.com").Get("/items").MatchParam("page", "3").Reply(200).
+		SetHeader("Link", `</items?page=1>; rel="first", </items?page=2>; rel="prev"`).
+		JSON([]any{3})
+
+	captured := run("paginate http://example.com/items?page=1 --to last -f body")
+	assert.Contains(t, captured, "Total pages: 3")
+	assert.Contains(t, captured, "[3]")
+	assert.True(t, gock.IsDone())
+}
+
+func TestPaginateWalk(t *testing.T) {
+	defer gock.Off()
+
+	// Without a `first` link the `prev` links are walked back to the start.
+	gock.New("http://example.com").Get("/items").MatchParam("page", "3").Reply(200).
+		SetHeader("Link", `</items?page=2>; rel="previous"`).
+		JSON([]any{3})
+	gock.New("http://example.com").Get("/items").MatchParam("page", "2").Reply(200).
+		SetHeader("Link", `</items?page=1>; rel="prev", </items?page=3>; rel="next"`).
+		JSON([]any{2})
+	gock.New("http://example.com").Get("/items").MatchParam("page", "1").Reply(200).
+		SetHeader("Link", `</items?page=2>; rel="next"`).
+		JSON([]any{1})
+
+	captured := run("paginate http://example.com/items?page=3 --to first -f body")
+	assert.Equal(t, "[1]\n", captured)
+	assert.True(t, gock.IsDone())
+}
+
+func TestPaginateMissing(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/items").Reply(200).JSON([]any{1})
+
+	captured := run("paginate http://example.com/items --to prev")
+	assert.Contains(t, captured, "no prev link found")
+
+	captured = run("paginate http://example.com/items --to middle")
+	assert.Contains(t, captured, "invalid link relation middle")
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/shorthand/v2"
+	"github.com/spf13/viper"
+)
+
+// PaginationConfig describes how an API paginates collections. Items may be
+// wrapped in an envelope like `{"data": [...]}`, and APIs which don't return
+// `next` links can use query parameters, e.g. `?offset=20&limit=10` or
+// `?page=3`.
+type PaginationConfig struct {
+	// Items is a shorthand path to the list of items in each page, e.g. `data`.
+	// Defaults to the body itself.
+	Items string `json:"items,omitempty" yaml:"items,omitempty"`
+	// Strategy is either `offset` or `page`, or empty to only follow links.
+	Strategy    string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	OffsetParam string `json:"offset_param,omitempty" yaml:"offset_param,omitempty" mapstructure:"offset_param,omitempty"`
+	LimitParam  string `json:"limit_param,omitempty" yaml:"limit_param,omitempty" mapstructure:"limit_param,omitempty"`
+	PageParam   string `json:"page_param,omitempty" yaml:"page_param,omitempty" mapstructure:"page_param,omitempty"`
+	// FirstPage is the number of the first page, e.g. `0` for zero-based page
+	// APIs. Defaults to `1`.
+	FirstPage *int `json:"first_page,omitempty" yaml:"first_page,omitempty" mapstructure:"first_page,omitempty"`
+	// TotalHeader is a response header with the total number of items, e.g.
+	// `X-Total-Count`, used to stop without requesting an empty page.
+	TotalHeader string `json:"total_header,omitempty" yaml:"total_header,omitempty" mapstructure:"total_header,omitempty"`
+}
+
+// queryPager generates the next page URL for query parameter pagination.
+type queryPager struct {
+	config    PaginationConfig
+	url       *url.URL
+	limit     int
+	firstPage int
+}
+
+// paginationFor returns the pagination config for the request's API, if any.
+// The profile's config takes precedence over the API's.
+func paginationFor(req *http.Request) *PaginationConfig {
+	_, config := findAPI(req.URL.String())
+	if config == nil {
+		return nil
+	}
+
+	if profile := config.Profiles[viper.GetString("rsh-profile")]; profile != nil && profile.Pagination != nil {
+		return profile.Pagination
+	}
+	return config.Pagination
+}
+
+// defaultItemsPath returns the items path for well-known envelopes when the
+// API has none configured, e.g. the `value` list of OData collections.
+func defaultItemsPath(body any) string {
+	if m, ok := body.(map[string]any); ok {
+		if _, ok := m["value"].([]any); ok {
+			for k := range m {
+				if strings.HasPrefix(k, "@odata.") {
+					return "value"
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// pageItems returns the list of items in a page body.
+func pageItems(body any, path string) ([]any, bool) {
+	if path == "" {
+		items, ok := body.([]any)
+		return items, ok
+	}
+
+	found, ok, err := shorthand.GetPath(path, body, shorthand.GetOptions{})
+	if err != nil || !ok {
+		return nil, false
+	}
+	items, ok := found.([]any)
+	return items, ok
+}
+
+// setPageItems returns the page body with its list of items replaced, keeping
+// the rest of the envelope as-is.
+func setPageItems(body any, path string, items []any) (any, error) {
+	if path == "" {
+		return items, nil
+	}
+
+	doc := shorthand.NewDocument(shorthand.ParseOptions{ForceStringKeys: true})
+	doc.Operations = []shorthand.Operation{{Kind: shorthand.OpSet, Path: path, Value: items}}
+	result, err := doc.Apply(body)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// newQueryPager returns a pager for the API's pagination config, or nil if it
+// doesn't use query parameter pagination.
+func newQueryPager(req *http.Request, pagination *PaginationConfig) *queryPager {
+	if pagination == nil || pagination.Strategy == "" {
+		return nil
+	}
+
+	p := &queryPager{config: *pagination, url: req.URL}
+	if p.config.OffsetParam == "" {
+		p.config.OffsetParam = "offset"
+	}
+	if p.config.LimitParam == "" {
+		p.config.LimitParam = "limit"
+	}
+	if p.config.PageParam == "" {
+		p.config.PageParam = "page"
+	}
+	p.firstPage = 1
+	if p.config.FirstPage != nil {
+		p.firstPage = *p.config.FirstPage
+	}
+
+	switch p.config.Strategy {
+	case "offset", "page":
+	default:
+		LogWarning("Skipping auto-pagination: unknown pagination strategy %s", p.config.Strategy)
+		return nil
+	}
+
+	p.limit, _ = strconv.Atoi(req.URL.Query().Get(p.config.LimitParam))
+
+	return p
+}
+
+// next returns the URL of the page after the given one, or nil if it was the
+// last page. The end is reached on an empty or short page, or once the total
+// from the `TotalHeader` has been fetched.
+func (p *queryPager) next(page Response) *url.URL {
+	items, ok := pageItems(page.Body, p.config.Items)
+	if !ok {
+		// Not a collection, e.g. a single item from the same API.
+		return nil
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	if p.limit == 0 {
+		// Without an explicit limit, assume the first page is full.
+		p.limit = len(items)
+	}
+	if len(items) < p.limit {
+		return nil
+	}
+
+	query := p.url.Query()
+	offset, _ := strconv.Atoi(query.Get(p.config.OffsetParam))
+	pageNum, err := strconv.Atoi(query.Get(p.config.PageParam))
+	if err != nil {
+		pageNum = p.firstPage
+	}
+
+	// Number of items up to and including this page.
+	seen := offset + len(items)
+	if p.config.Strategy == "page" {
+		seen = (pageNum-p.firstPage)*p.limit + len(items)
+	}
+
+	if p.config.TotalHeader != "" {
+		if total, err := strconv.Atoi(page.Headers[http.CanonicalHeaderKey(p.config.TotalHeader)]); err == nil && seen >= total {
+			return nil
+		}
+	}
+
+	if p.config.Strategy == "offset" {
+		query.Set(p.config.OffsetParam, strconv.Itoa(offset+len(items)))
+	} else {
+		query.Set(p.config.PageParam, strconv.Itoa(pageNum+1))
+	}
+
+	next := *p.url
+	next.RawQuery = query.Encode()
+	p.url = &next
+
+	return &next
+}
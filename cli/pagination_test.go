@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestPaginationOffset(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "paged")
+
+	reset(false)
+	configs["paged"] = &APIConfig{
+		Base:       "http://paged.example.com",
+		Pagination: &PaginationConfig{Strategy: "offset"},
+	}
+
+	gock.New("http://paged.example.com").Get("/items").MatchParam("offset", "4").MatchParam("limit", "2").
+		Reply(http.StatusOK).JSON([]any{5})
+	gock.New("http://paged.example.com").Get("/items").MatchParam("offset", "2").MatchParam("limit", "2").
+		Reply(http.StatusOK).JSON([]any{3, 4})
+	gock.New("http://paged.example.com").Get("/items").MatchParam("limit", "2").
+		Reply(http.StatusOK).JSON([]any{1, 2})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://paged.example.com/items?limit=2", nil)
+	resp, err := GetParsedResponse(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0, 3.0, 4.0, 5.0}, resp.Body)
+	assert.True(t, gock.IsDone())
+}
+
+func TestPaginationPageTotal(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "paged")
+
+	reset(false)
+	configs["paged"] = &APIConfig{
+		Base: "http://paged.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				// The profile config is used over the API's.
+				Pagination: &PaginationConfig{Strategy: "page", PageParam: "p", TotalHeader: "x-total-count"},
+			},
+		},
+		Pagination: &PaginationConfig{Strategy: "offset"},
+	}
+
+	gock.New("http://paged.example.com").Get("/items").MatchParam("p", "2").
+		Reply(http.StatusOK).SetHeader("X-Total-Count", "4").JSON([]any{3, 4})
+	gock.New("http://paged.example.com").Get("/items").
+		Reply(http.StatusOK).SetHeader("X-Total-Count", "4").JSON([]any{1, 2})
+
+	// No third request is made since the total has been reached.
+	req, _ := http.NewRequest(http.MethodGet, "http://paged.example.com/items", nil)
+	resp, err := GetParsedResponse(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0, 3.0, 4.0}, resp.Body)
+	assert.True(t, gock.IsDone())
+}
+
+func TestPaginationZeroBased(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "zero")
+
+	reset(false)
+
+	// Decode like `apis.json` so an explicit zero is kept.
+	tmp := viper.New()
+	tmp.Set("zero", map[string]any{
+		"base":       "http://zero.example.com",
+		"pagination": map[string]any{"strategy": "page", "first_page": 0},
+	})
+	loaded := apiConfigs{}
+	assert.NoError(t, tmp.Unmarshal(&loaded))
+	assert.Equal(t, 0, *loaded["zero"].Pagination.FirstPage)
+	configs["zero"] = loaded["zero"]
+
+	gock.New("http://zero.example.com").Get("/items").MatchParam("page", "1").
+		Reply(http.StatusOK).JSON([]any{3, 4})
+	gock.New("http://zero.example.com").Get("/items").MatchParam("page", "2").
+		Reply(http.StatusOK).JSON([]any{5})
+	gock.New("http://zero.example.com").Get("/items").
+		Reply(http.StatusOK).JSON([]any{1, 2})
+
+	// The unparameterized first request is page 0, so page 1 is next.
+	req, _ := http.NewRequest(http.MethodGet, "http://zero.example.com/items", nil)
+	resp, err := GetParsedResponse(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0, 3.0, 4.0, 5.0}, resp.Body)
+	assert.True(t, gock.IsDone())
+}
+
+func TestPaginationMaxPages(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "paged")
+
+	reset(false)
+	viper.Set("rsh-max-pages", 2)
+	configs["paged"] = &APIConfig{
+		Base:       "http://paged.example.com",
+		Pagination: &PaginationConfig{Strategy: "page"},
+	}
+
+	gock.New("http://paged.example.com").Get("/items").MatchParam("page", "2").
+		Reply(http.StatusOK).JSON([]any{3, 4})
+	gock.New("http://paged.example.com").Get("/items").
+		Reply(http.StatusOK).JSON([]any{1, 2})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://paged.example.com/items", nil)
+	resp, err := GetParsedResponse(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0, 3.0, 4.0}, resp.Body)
+	assert.True(t, gock.IsDone())
+
+	// Disabling pagination makes a single request.
+	gock.New("http://paged.example.com").Get("/items").
+		Reply(http.StatusOK).JSON([]any{1, 2})
+	viper.Set("rsh-no-paginate", true)
+
+	resp, err = GetParsedResponse(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0}, resp.Body)
+}
+
+func TestPaginationEnvelope(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "paged")
+
+	reset(false)
+	configs["paged"] = &APIConfig{
+		Base:       "http://paged.example.com",
+		Pagination: &PaginationConfig{Items: "data"},
+	}
+
+	// JSON:API style links in the body.
+	gock.New("http://paged.example.com").Get("/items").MatchParam("page", "2").
+		Reply(http.StatusOK).JSON(map[string]any{
+		"data":  []any{3},
+		"meta":  map[string]any{"page": 2},
+		"links": map[string]any{},
+	})
+	gock.New("http://paged.example.com").Get("/items").
+		Reply(http.StatusOK).JSON(map[string]any{
+		"data":  []any{1, 2},
+		"meta":  map[string]any{"page": 1},
+		"links": map[string]any{"next": "/items?page=2"},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://paged.example.com/items", nil)
+	resp, err := GetParsedResponse(req)
+
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	// Items are merged and the rest of the final page's envelope is kept.
+	assert.Equal(t, map[string]any{
+		"data":  []any{1.0, 2.0, 3.0},
+		"meta":  map[string]any{"page": 2.0},
+		"links": map[string]any{},
+	}, resp.Body)
+}
+
+func TestPageItems(t *testing.T) {
+	items, ok := pageItems([]any{1, 2}, "")
+	assert.True(t, ok)
+	assert.Equal(t, []any{1, 2}, items)
+
+	items, ok = pageItems(map[string]any{"result": map[string]any{"items": []any{1}}}, "result.items")
+	assert.True(t, ok)
+	assert.Equal(t, []any{1}, items)
+
+	_, ok = pageItems(map[string]any{"data": "nope"}, "data")
+	assert.False(t, ok)
+
+	_, ok = pageItems(map[string]any{}, "data")
+	assert.False(t, ok)
+
+	body, err := setPageItems(map[string]any{"result": map[string]any{"items": []any{1}, "total": 2}}, "result.items", []any{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"result": map[string]any{"items": []any{1, 2}, "total": 2}}, body)
+}
+
+func TestPaginationOData(t *testing.T) {
+	defer gock.Off()
+
+	reset(false)
+
+	gock.New("http://odata.example.com").Get("/People").MatchParam("$skiptoken", "2").
+		Reply(http.StatusOK).JSON(map[string]any{
+		"@odata.context": "http://odata.example.com/$metadata#People",
+		"value":          []any{map[string]any{"name": "c"}},
+	})
+	gock.New("http://odata.example.com").Get("/People").
+		Reply(http.StatusOK).JSON(map[string]any{
+		"@odata.context":  "http://odata.example.com/$metadata#People",
+		"@odata.nextLink": "http://odata.example.com/People?$skiptoken=2",
+		"value":           []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://odata.example.com/People", nil)
+	resp, err := GetParsedResponse(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"@odata.context": "http://odata.example.com/$metadata#People",
+		"value": []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b"},
+			map[string]any{"name": "c"},
+		},
+	}, resp.Body)
+	assert.True(t, gock.IsDone())
+}
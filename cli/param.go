@@ -34,6 +34,8 @@ type Param struct {
 	Explode     bool        `json:"explode,omitempty" yaml:"explide,omitempty"`
 	Default     interface{} `json:"default,omitempty" yaml:"default,omitempty"`
 	Example     interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	Required    bool        `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum        []any       `json:"enum,omitempty" yaml:"enum,omitempty"`
 }
 
 // Parse the parameter from a string input (e.g. command line argument)
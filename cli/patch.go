@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Patch formats supported by `edit --rsh-patch`.
+const (
+	patchMerge = "merge"
+	patchJSON  = "json"
+)
+
+// patchContentTypes maps patch formats to their media types.
+var patchContentTypes = map[string]string{
+	patchMerge: "application/merge-patch+json",
+	patchJSON:  "application/json-patch+json",
+}
+
+// mergePatch computes a JSON Merge Patch (RFC 7386) which turns `orig` into
+// `modified`. Removed keys are set to `nil` and nested objects are diffed
+// recursively, while any other changed value is replaced as a whole.
+func mergePatch(orig, modified any) any {
+	o, ok1 := orig.(map[string]any)
+	m, ok2 := modified.(map[string]any)
+	if !ok1 || !ok2 {
+		return modified
+	}
+
+	patch := map[string]any{}
+	for k := range o {
+		if _, ok := m[k]; !ok {
+			patch[k] = nil
+		}
+	}
+
+	for k, v := range m {
+		ov, ok := o[k]
+		if ok && reflect.DeepEqual(ov, v) {
+			continue
+		}
+
+		if _, isMap := v.(map[string]any); ok && isMap {
+			patch[k] = mergePatch(ov, v)
+		} else {
+			patch[k] = v
+		}
+	}
+
+	return patch
+}
+
+// jsonPatchOp is a single JSON Patch (RFC 6902) operation. A map is used
+// rather than a struct so that `null` and other zero values are kept.
+type jsonPatchOp map[string]any
+
+// escapePointer escapes a key for use as a JSON Pointer (RFC 6901) token.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// jsonPatch computes the JSON Patch (RFC 6902) operations which turn `orig`
+// into `modified`. Objects are diffed key by key in sorted order, while any
+// other changed value, including arrays, is replaced as a whole.
+func jsonPatch(orig, modified any, path string) []jsonPatchOp {
+	if reflect.DeepEqual(orig, modified) {
+		return nil
+	}
+
+	o, ok1 := orig.(map[string]any)
+	m, ok2 := modified.(map[string]any)
+	if !ok1 || !ok2 {
+		return []jsonPatchOp{{"op": "replace", "path": path, "value": modified}}
+	}
+
+	keys := make([]string, 0, len(o)+len(m))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	for k := range m {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	ops := []jsonPatchOp{}
+	for _, k := range keys {
+		p := path + "/" + escapePointer(k)
+		ov, inOrig := o[k]
+		mv, inMod := m[k]
+		switch {
+		case !inMod:
+			ops = append(ops, jsonPatchOp{"op": "remove", "path": p})
+		case !inOrig:
+			ops = append(ops, jsonPatchOp{"op": "add", "path": p, "value": mv})
+		default:
+			ops = append(ops, jsonPatch(ov, mv, p)...)
+		}
+	}
+
+	return ops
+}
+
+// makePatch returns the patch body and content type for a patch format.
+func makePatch(format string, orig, modified any) (any, string, error) {
+	switch format {
+	case patchMerge:
+		return mergePatch(orig, modified), patchContentTypes[format], nil
+	case patchJSON:
+		return jsonPatch(orig, modified, ""), patchContentTypes[format], nil
+	}
+	return nil, "", fmt.Errorf("unsupported patch format %s, use %s or %s", format, patchMerge, patchJSON)
+}
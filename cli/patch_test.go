@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	orig := map[string]any{
+		"keep":   1.0,
+		"remove": "x",
+		"change": []any{1.0},
+		"nested": map[string]any{"a": 1.0, "b": 2.0},
+	}
+	modified := map[string]any{
+		"keep":   1.0,
+		"change": []any{1.0, 2.0},
+		"nested": map[string]any{"a": 1.0, "b": 3.0},
+		"new":    map[string]any{"c": true},
+	}
+
+	assert.Equal(t, map[string]any{
+		"remove": nil,
+		"change": []any{1.0, 2.0},
+		"nested": map[string]any{"b": 3.0},
+		"new":    map[string]any{"c": true},
+	}, mergePatch(orig, modified))
+}
+
+func TestJSONPatch(t *testing.T) {
+	orig := map[string]any{
+		"keep":   1.0,
+		"remove": "x",
+		"value":  "old",
+		"nested": map[string]any{"a~b": 1.0},
+	}
+	modified := map[string]any{
+		"keep":   1.0,
+		"value":  nil,
+		"nested": map[string]any{"a~b": 2.0},
+		"new":    true,
+	}
+
+	assert.Equal(t, []jsonPatchOp{
+		{"op": "replace", "path": "/nested/a~0b", "value": 2.0},
+		{"op": "add", "path": "/new", "value": true},
+		{"op": "remove", "path": "/remove"},
+		{"op": "replace", "path": "/value", "value": nil},
+	}, jsonPatch(orig, modified, ""))
+}
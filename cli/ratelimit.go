@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// sleep is used to pace requests. It is a variable so it can be replaced,
+// e.g. for testing.
+var sleep = time.Sleep
+
+// parseRateLimit parses a rate limit like `10/s`, `100/minute`, or `5/10s`
+// and returns the minimum interval between requests.
+func parseRateLimit(limit string) (time.Duration, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(limit), "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid rate limit %s, expected e.g. 10/s", limit)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate limit %s, expected a positive request count", limit)
+	}
+
+	var period time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	case "d", "day":
+		period = 24 * time.Hour
+	default:
+		if period, err = time.ParseDuration(unit); err != nil {
+			return 0, fmt.Errorf("invalid rate limit %s, unknown period %s", limit, unit)
+		}
+	}
+
+	return period / time.Duration(n), nil
+}
+
+// rateLimitKey returns the cache key for the time of the last request to a
+// host. Hosts contain dots, which the cache treats as nested keys, so a hash
+// is used instead.
+func rateLimitKey(host string) string {
+	sum := sha256.Sum256([]byte(host))
+	return "ratelimit-" + hex.EncodeToString(sum[:8])
+}
+
+// throttle waits as needed to keep requests to a host under the configured
+// `rsh-rate-limit`. The time of the last request is stored in the CLI cache
+// so that requests are also paced across invocations, e.g. in a script loop.
+func throttle(host string) error {
+	limit := viper.GetString("rsh-rate-limit")
+	if limit == "" {
+		return nil
+	}
+
+	interval, err := parseRateLimit(limit)
+	if err != nil {
+		return err
+	}
+
+	key := rateLimitKey(host)
+	if last := Cache.GetTime(key); !last.IsZero() {
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			LogDebug("Rate limit %s, waiting %s", limit, wait.Truncate(time.Millisecond))
+			sleep(wait)
+		}
+	}
+
+	Cache.Set(key, time.Now())
+	return Cache.WriteConfig()
+}
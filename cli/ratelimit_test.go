@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	for limit, expected := range map[string]time.Duration{
+		"10/s":       100 * time.Millisecond,
+		"1/second":   time.Second,
+		"120/minute": 500 * time.Millisecond,
+		"60/h":       time.Minute,
+		"5/10s":      2 * time.Second,
+	} {
+		interval, err := parseRateLimit(limit)
+		assert.NoError(t, err, limit)
+		assert.Equal(t, expected, interval, limit)
+	}
+
+	for _, limit := range []string{"10", "0/s", "abc/s", "10/fortnight"} {
+		_, err := parseRateLimit(limit)
+		assert.Error(t, err, limit)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+	defer func(orig func(time.Duration)) { sleep = orig }(sleep)
+
+	waits := []time.Duration{}
+	sleep = func(d time.Duration) {
+		waits = append(waits, d)
+	}
+
+	reset(false)
+	viper.Set("rsh-rate-limit", "1/minute")
+	Cache.Set(rateLimitKey("ratelimit.example.com"), time.Time{})
+
+	gock.New("http://ratelimit.example.com").Get("/").Times(2).Reply(204)
+
+	runNoReset("http://ratelimit.example.com/")
+	runNoReset("http://ratelimit.example.com/")
+
+	// Only the second request needs to wait, for roughly the full interval.
+	if assert.Len(t, waits, 1) {
+		assert.Greater(t, waits[0], 50*time.Second)
+	}
+}
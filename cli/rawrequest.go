@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseRawRequest parses a literal HTTP/1.x request, i.e. a request line,
+// headers, and an optional body, into a request which can be sent. Relative
+// request targets are resolved using the `Host` header, which may also be an
+// API short name. Unlike a request on the wire, a `Content-Length` header is
+// not required: everything after the headers is used as the body.
+func ParseRawRequest(data []byte) (*http.Request, error) {
+	// Allow files to start with blank lines or comments, like `.http` files.
+	lines := strings.SplitAfter(string(data), "\n")
+	for len(lines) > 0 {
+		trimmed := strings.TrimSpace(lines[0])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "//") {
+			break
+		}
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no request found")
+	}
+
+	// Add the protocol version if left out, e.g. `GET /items`.
+	if parts := strings.Fields(lines[0]); len(parts) == 2 {
+		lines[0] = parts[0] + " " + parts[1] + " HTTP/1.1\r\n"
+	}
+
+	// Allow a missing blank line after the headers when there is no body.
+	raw := strings.Join(lines, "")
+	if !strings.Contains(raw, "\n\n") && !strings.Contains(raw, "\n\r\n") {
+		if !strings.HasSuffix(raw, "\n") {
+			raw += "\r\n"
+		}
+		raw += "\r\n"
+	}
+
+	r := bufio.NewReader(strings.NewReader(raw))
+	parsed, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse request: %w", err)
+	}
+
+	var body []byte
+	if parsed.ContentLength > 0 || len(parsed.TransferEncoding) > 0 {
+		body, err = io.ReadAll(parsed.Body)
+	} else {
+		body, err = io.ReadAll(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	uri := parsed.RequestURI
+	if !strings.Contains(uri, "://") {
+		if parsed.Host == "" {
+			return nil, errors.New("request has a relative target but no Host header")
+		}
+		uri = fixAddress(parsed.Host) + uri
+	}
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(parsed.Method, uri, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = parsed.Header
+	req.Header.Del("Content-Length")
+
+	return req, nil
+}
+
+// rawRequest sends a literal HTTP request read from a file or stdin. Files
+// with a `.http` or `.rest` extension may contain multiple requests with
+// variables, see `runHTTPFile`.
+func rawRequest(filename string) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".http" || ext == ".rest" {
+		if err := runHTTPFile(filename); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	var data []byte
+	var err error
+	if filename == "" || filename == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	req, err := ParseRawRequest(data)
+	if err != nil {
+		panic(err)
+	}
+
+	MakeRequestAndFormat(req)
+}
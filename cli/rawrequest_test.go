@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestParseRawRequest(t *testing.T) {
+	req, err := ParseRawRequest([]byte("# Create an item\nPOST /items?a=1\nHost: example.com\nContent-Type: application/json\n\n{\"id\": 1}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "https://example.com/items?a=1", req.URL.String())
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "{\"id\": 1}\n", string(body))
+
+	req, err = ParseRawRequest([]byte("GET http://example.com/items HTTP/1.1\r\nContent-Length: 2\r\n\r\nhi-ignored"))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/items", req.URL.String())
+	body, _ = io.ReadAll(req.Body)
+	assert.Equal(t, "hi", string(body))
+
+	// The blank line after the headers is optional without a body.
+	req, err = ParseRawRequest([]byte("DELETE /items/1\nHost: example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/items/1", req.URL.String())
+
+	req, err = ParseRawRequest([]byte("GET http://example.com/items\nX-Foo: bar\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", req.Header.Get("X-Foo"))
+
+	_, err = ParseRawRequest([]byte("GET /items HTTP/1.1\n\n"))
+	assert.Error(t, err)
+
+	_, err = ParseRawRequest([]byte("\n# nothing\n"))
+	assert.Error(t, err)
+}
+
+func TestRawRequestNoAuth(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	reset(false)
+	AddAuth("test-auth", &TestAuth{})
+	configs["raw-request"] = &APIConfig{
+		name: "raw-request",
+		Base: "https://raw-request.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Auth: &APIAuth{Name: "test-auth"},
+			},
+		},
+	}
+	defer delete(configs, "raw-request")
+
+	filename := filepath.Join(t.TempDir(), "request.http")
+	assert.NoError(t, os.WriteFile(filename, []byte("DELETE /items/1 HTTP/1.1\nHost: raw-request\nX-Foo: bar\n\n"), 0600))
+
+	gock.New("https://raw-request.example.com").
+		Delete("/items/1").
+		MatchHeader("X-Foo", "bar").
+		MatchHeader("Authorization", "abc123").
+		Reply(204)
+
+	out := runNoReset("request " + filename)
+	assert.Contains(t, out, "204 No Content")
+
+	gock.New("https://raw-request.example.com").
+		Delete("/items/1").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return req.Header.Get("Authorization") == "", nil
+		}).
+		Reply(204)
+
+	out = runNoReset("request " + filename + " --rsh-no-auth")
+	assert.Contains(t, out, "204 No Content")
+	assert.True(t, gock.IsDone())
+}
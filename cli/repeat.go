@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// clearScreen moves the cursor home and clears the terminal so each repeated
+// response is rendered in place.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// reStatusCondition matches `--rsh-repeat-until` conditions like `200` or
+// `2xx`.
+var reStatusCondition = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+// repeating returns whether the request should be made repeatedly.
+func repeating() bool {
+	return viper.GetInt("rsh-repeat") > 0 || viper.GetDuration("rsh-interval") > 0
+}
+
+// parseStatusConditions parses a comma-separated list of status codes or
+// classes like `200,404` or `2xx`.
+func parseStatusConditions(value string) ([]string, error) {
+	conditions := []string{}
+	for _, c := range strings.Split(value, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+		if !reStatusCondition.MatchString(c) {
+			return nil, fmt.Errorf("invalid status condition %q, expected e.g. 200 or 2xx", c)
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions, nil
+}
+
+// statusMatches returns whether the status code matches any of the conditions.
+func statusMatches(status int, conditions []string) bool {
+	code := strconv.Itoa(status)
+	for _, c := range conditions {
+		if c == code || (strings.HasSuffix(c, "xx") && c[0] == code[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatRequest makes the request and prints the response `rsh-repeat` times,
+// or until interrupted if only `rsh-interval` is set, waiting the interval
+// between requests. Each response is rendered in place on a terminal like
+// `watch`, otherwise responses are printed one after another. Stops early
+// once the status matches `rsh-repeat-until`.
+func repeatRequest(req *http.Request) {
+	count := viper.GetInt("rsh-repeat")
+	interval := viper.GetDuration("rsh-interval")
+	until, err := parseStatusConditions(viper.GetString("rsh-repeat-until"))
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Body != nil && req.GetBody == nil {
+		// Buffer the body so it can be sent each time.
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			panic(err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	// Let Ctrl-C stop polling cleanly rather than exiting the process.
+	ctx, cancel := withInterrupt(req.Context())
+	defer cancel()
+
+	tty := viper.GetBool("tty")
+	for i := 1; count == 0 || i <= count; i++ {
+		// Requests are modified as they are sent, e.g. to add auth, so start
+		// from the original each time.
+		r := req.Clone(ctx)
+		if req.GetBody != nil {
+			r.Body, _ = req.GetBody()
+		}
+
+		if r.Header.Get("Cache-Control") == "" {
+			// Reuse the client but always get a fresh response.
+			r.Header.Set("Cache-Control", "no-cache")
+		}
+
+		if tty {
+			progress := ""
+			if count > 0 {
+				progress = fmt.Sprintf(" (%d/%d)", i, count)
+			}
+			fmt.Fprintf(Stdout, "%sEvery %s: %s %s%s  %s\n\n", clearScreen, interval, r.Method, r.URL, progress, time.Now().Format("15:04:05"))
+		}
+
+		makeRequestAndFormat(r)
+
+		if len(until) > 0 && statusMatches(GetLastStatus(), until) {
+			LogDebug("Status %d matches %s, done repeating", GetLastStatus(), strings.Join(until, ","))
+			return
+		}
+
+		if count > 0 && i == count {
+			return
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestStatusConditions(t *testing.T) {
+	conditions, err := parseStatusConditions("200, 4XX,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"200", "4xx"}, conditions)
+
+	assert.True(t, statusMatches(200, conditions))
+	assert.True(t, statusMatches(404, conditions))
+	assert.False(t, statusMatches(201, conditions))
+	assert.False(t, statusMatches(500, conditions))
+
+	for _, bad := range []string{"ok", "20", "6xx", "2x0"} {
+		_, err := parseStatusConditions(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	for i := 0; i < 3; i++ {
+		gock.New("http://example.com").Post("/poll").
+			MatchHeader("Cache-Control", "no-cache").
+			BodyString(`{"foo":1}`).
+			Reply(200).
+			JSON(map[string]any{"n": i})
+	}
+
+	// Each response is rendered in place on a terminal.
+	out := run("post -f body.n --rsh-repeat 3 http://example.com/poll foo: 1")
+	assert.Equal(t, 3, strings.Count(out, clearScreen))
+	assert.Contains(t, out, "Every 0s: POST http://example.com/poll (3/3)")
+	assert.Regexp(t, `0\n(.|\n)*1\n(.|\n)*2\n$`, out)
+	assert.True(t, gock.IsDone())
+}
+
+func TestRepeatUntil(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/job").Times(2).Reply(202)
+	gock.New("http://example.com").Get("/job").Reply(200).JSON(map[string]any{"done": true})
+	gock.New("http://example.com").Get("/job").Reply(500)
+
+	run("--rsh-repeat 10 --rsh-repeat-until 200,4xx http://example.com/job")
+	expectExitCode(t, 0)
+	assert.False(t, gock.IsDone())
+	assert.Len(t, gock.Pending(), 1)
+
+	out := run("--rsh-repeat 2 --rsh-repeat-until bad http://example.com/job")
+	assert.Contains(t, out, `invalid status condition "bad"`)
+}
+
+// interruptOnWriter interrupts requests once the given text is written.
+type interruptOnWriter struct {
+	strings.Builder
+	text string
+}
+
+func (w *interruptOnWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), w.text) {
+		interrupt()
+	}
+	return w.Builder.Write(p)
+}
+
+func TestRepeatInterrupt(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Get("/status").Reply(200).JSON(map[string]any{"ok": true})
+
+	// Ctrl-C while waiting for the next request stops cleanly.
+	reset(false)
+	out := &interruptOnWriter{text: "true"}
+	Stdout = out
+	Stderr = out
+	// Run directly since `run` replaces the output writers.
+	Root.SetOut(out)
+	Root.SetArgs(strings.Split("--rsh-interval 1h -f body.ok http://example.com/status", " "))
+
+	start := time.Now()
+	assert.NotPanics(t, func() {
+		assert.NoError(t, Root.Execute())
+	})
+	assert.Less(t, time.Since(start), time.Minute)
+	assert.Contains(t, out.String(), "true")
+	assert.True(t, gock.IsDone())
+}
@@ -8,11 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/danielgtaylor/shorthand/v2"
@@ -22,6 +24,81 @@ import (
 // lastStatus is the last HTTP status code returned by a request.
 var lastStatus int
 
+// requestSetupMu guards request setup in `MakeRequest`.
+var requestSetupMu sync.Mutex
+
+// requestSlots limits the number of requests in flight at once across all
+// features, see `rsh-max-concurrency`.
+var requestSlots chan struct{}
+var requestSlotsMu sync.Mutex
+
+// acquireRequestSlot waits for a free request slot and returns a function to
+// release it, which is safe to call more than once. Without a configured
+// limit it returns immediately.
+func acquireRequestSlot(ctx context.Context) (func(), error) {
+	limit := viper.GetInt("rsh-max-concurrency")
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	requestSlotsMu.Lock()
+	if cap(requestSlots) != limit {
+		requestSlots = make(chan struct{}, limit)
+	}
+	slots := requestSlots
+	requestSlotsMu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() { <-slots })
+	}, nil
+}
+
+// slotBody releases a request slot once the response body is closed, so
+// that streaming a large response still counts toward the limit.
+type slotBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// sendRequest sends a single request once a request slot is available. The
+// returned function releases the slot early, e.g. before waiting to retry.
+func sendRequest(client *http.Client, req *http.Request) (*http.Response, func(), error) {
+	release, err := acquireRequestSlot(req.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timing := timingFrom(req.Context())
+	if timing != nil {
+		timing.begin()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return resp, release, err
+	}
+
+	if timing != nil {
+		resp.Body = timing.body(resp.Body)
+	}
+	resp.Body = &slotBody{ReadCloser: resp.Body, release: release}
+	return resp, release, nil
+}
+
 // GetLastStatus returns the last HTTP status code returned by a request. A
 // request can opt out of this via the IgnoreStatus option.
 func GetLastStatus() int {
@@ -51,11 +128,12 @@ func fixAddress(addr string) string {
 					panic("invalid profile " + viper.GetString("rsh-profile"))
 				}
 			}
-			if p != nil && p.Base != "" {
-				parts[0] = p.Base
-				return strings.Join(parts, "/")
-			} else if c.Base != "" {
-				parts[0] = c.Base
+			base, err := c.baseFor(viper.GetString("rsh-profile"))
+			if err != nil {
+				panic(err)
+			}
+			if base != "" {
+				parts[0] = base
 				return strings.Join(parts, "/")
 			}
 		}
@@ -76,6 +154,8 @@ type requestConfig struct {
 	disableLog      bool
 	ignoreStatus    bool
 	ignoreCLIParams bool
+	fromAuth        bool
+	send            func(*http.Client, *http.Request) (*http.Response, error)
 }
 
 type requestOption func(*requestConfig)
@@ -108,6 +188,95 @@ func IgnoreCLIParams() requestOption {
 	}
 }
 
+// fromAuthHandler marks a request made by an auth handler while another
+// request is being set up, e.g. to log in. Auth is skipped to prevent
+// recursion and the setup lock is already held by the outer request.
+func fromAuthHandler() requestOption {
+	return func(conf *requestConfig) {
+		conf.fromAuth = true
+	}
+}
+
+// withSender replaces how the fully set up request is sent, e.g. to upgrade
+// the connection to a WebSocket instead of making a plain HTTP request.
+func withSender(send func(*http.Client, *http.Request) (*http.Response, error)) requestOption {
+	return func(conf *requestConfig) {
+		conf.send = send
+	}
+}
+
+// queryArrayDelimiters maps `--rsh-param-encoding` values, including the
+// equivalent OpenAPI style names, to the delimiter used to join array items.
+// An empty delimiter sends each item as a repeated param.
+var queryArrayDelimiters = map[string]string{
+	"multi":          "",
+	"form":           "",
+	"csv":            ",",
+	"simple":         ",",
+	"ssv":            " ",
+	"spaceDelimited": " ",
+	"pipes":          "|",
+	"pipeDelimited":  "|",
+}
+
+// addQueryParams adds `name=value` query params from the command line. By
+// default values are sent verbatim. With an array encoding, shorthand array
+// values like `[a, b]` and repeated names are combined into one array which
+// is sent using that encoding.
+func addQueryParams(query url.Values, params []string, encoding string) error {
+	var names []string
+	items := map[string][]string{}
+
+	delimiter, ok := queryArrayDelimiters[encoding]
+	if encoding != "" && !ok {
+		return fmt.Errorf("invalid param encoding %s, expected one of multi, csv, ssv, pipes", encoding)
+	}
+
+	for _, q := range params {
+		parts := strings.SplitN(q, "=", 2)
+		value := ""
+		if len(parts) > 1 {
+			value = parts[1]
+		}
+
+		if encoding == "" {
+			query.Add(parts[0], value)
+			continue
+		}
+
+		if _, seen := items[parts[0]]; !seen {
+			names = append(names, parts[0])
+		}
+
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			parsed, err := shorthand.Unmarshal(value, shorthand.ParseOptions{}, nil)
+			if err != nil {
+				return err
+			}
+			if list, ok := parsed.([]any); ok {
+				for _, item := range list {
+					items[parts[0]] = append(items[parts[0]], fmt.Sprintf("%v", item))
+				}
+				continue
+			}
+		}
+
+		items[parts[0]] = append(items[parts[0]], value)
+	}
+
+	for _, name := range names {
+		if delimiter == "" {
+			for _, item := range items[name] {
+				query.Add(name, item)
+			}
+		} else {
+			query.Add(name, strings.Join(items[name], delimiter))
+		}
+	}
+
+	return nil
+}
+
 // MakeRequest makes an HTTP request using the default client. It adds the
 // user-agent, auth, and any passed headers or query params to the request
 // before sending it out on the wire. If verbose mode is enabled, it will
@@ -118,6 +287,21 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 		opt(requestConf)
 	}
 
+	// Setting up a request reads & writes shared state like the cache, auth
+	// tokens, and the default transport, so concurrent requests (e.g. from
+	// `fanout`) are set up one at a time and then sent in parallel.
+	setupLocked := !requestConf.fromAuth
+	if setupLocked {
+		requestSetupMu.Lock()
+	}
+	unlockSetup := func() {
+		if setupLocked {
+			setupLocked = false
+			requestSetupMu.Unlock()
+		}
+	}
+	defer unlockSetup()
+
 	name, config := findAPI(req.URL.String())
 
 	if config == nil {
@@ -135,22 +319,36 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 		profile = &APIProfile{}
 	}
 
-	// Now that we have the profile, set up profile-based headers/params.
+	// Now that we have the profile, set up profile-based headers/params. The
+	// profile's values take precedence over those shared by the whole API.
 	query := req.URL.Query()
-	for k, v := range profile.Headers {
+	headers := http.Header{}
+	for _, m := range []map[string]string{config.Headers, profile.Headers} {
+		for k, v := range m {
+			headers.Set(k, v)
+		}
+	}
+	for k := range headers {
 		if req.Header.Get(k) == "" {
-			req.Header.Add(k, os.ExpandEnv(v))
+			req.Header.Add(k, os.ExpandEnv(headers.Get(k)))
 		}
 	}
 
-	for k, v := range profile.Query {
+	params := map[string]string{}
+	for _, m := range []map[string]string{config.Query, profile.Query} {
+		for k, v := range m {
+			params[k] = v
+		}
+	}
+	for k, v := range params {
 		if query.Get(k) == "" {
-			query.Add(k, v)
+			query.Add(k, os.ExpandEnv(v))
 		}
 	}
 
 	if !requestConf.ignoreCLIParams {
-		// Allow env vars and commandline arguments to override config.
+		// Allow env vars and commandline arguments to override config. Unlike
+		// config values these are not expanded, since the shell already does.
 		for _, h := range viper.GetStringSlice("rsh-header") {
 			parts := strings.SplitN(h, ":", 2)
 			value := ""
@@ -161,20 +359,29 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 			req.Header.Add(parts[0], value)
 		}
 
-		for _, q := range viper.GetStringSlice("rsh-query") {
-			parts := strings.SplitN(q, "=", 2)
-			value := ""
-			if len(parts) > 1 {
-				value = parts[1]
-			}
+		if err := addQueryParams(query, viper.GetStringSlice("rsh-query"), viper.GetString("rsh-param-encoding")); err != nil {
+			return nil, err
+		}
 
-			query.Add(parts[0], value)
+		if accept := viper.GetString("rsh-accept"); accept != "" {
+			// Takes precedence over any other `Accept` header, including the
+			// computed default.
+			value, err := normalizeAccept(accept)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", value)
 		}
 	}
 
 	// Save modified query string arguments.
 	req.URL.RawQuery = query.Encode()
 
+	req, err := withProxy(req, config, profile)
+	if err != nil {
+		return nil, err
+	}
+
 	// The assumption is that all Transport implementations eventually use the
 	// default HTTP transport.
 	// We can therefore inject the TLS config once here, along with all the other
@@ -227,14 +434,51 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 		}
 	}
 
+	if viper.GetBool("rsh-print-config") && !requestConf.ignoreCLIParams {
+		printEffectiveConfig(req, name, config, profile)
+	}
+
+	if viper.GetBool("rsh-no-body") && !requestConf.ignoreCLIParams {
+		// Guarantee nothing is sent, e.g. for servers which reject a body on
+		// `GET` or `DELETE`, regardless of how the request was built. Done
+		// before auth, which may sign the body & headers.
+		req.Body = nil
+		req.GetBody = nil
+		req.ContentLength = 0
+		req.Header.Del("Content-Type")
+	}
+
+	// Compress before auth, which may sign the body. Requests which are only
+	// printed via `--rsh-curl` or `--rsh-dry-run` are left uncompressed so the
+	// output stays readable.
+	var uncompressed []byte
+	if compress := viper.GetString("rsh-compress"); compress != "" && !requestConf.ignoreCLIParams && !viper.GetBool("rsh-curl") && !viper.GetBool("rsh-dry-run") {
+		var err error
+		if uncompressed, err = compressBody(req, compress); err != nil {
+			return nil, err
+		}
+	}
+
 	// Add auth if needed.
-	if profile.Auth != nil && profile.Auth.Name != "" {
+	var authHandler AuthHandler
+	var refresher AuthRefresher
+	authKey := name + ":" + viper.GetString("rsh-profile")
+	if profile.Auth != nil && profile.Auth.Name != "" && !viper.GetBool("rsh-no-auth") && !requestConf.fromAuth {
 		auth, ok := authHandlers[profile.Auth.Name]
 		if ok {
-			err := auth.OnRequest(req, name+":"+viper.GetString("rsh-profile"), profile.Auth.Params)
+			err := auth.OnRequest(req, authKey, profile.Auth.Params)
 			if err != nil {
 				panic(err)
 			}
+			authHandler = auth
+
+			if r, ok := auth.(AuthRefresher); ok && viper.GetBool("rsh-auth-refresh") {
+				refresher = r
+			}
+
+			if r, ok := auth.(AuthAlwaysRefresher); ok && r.AlwaysRefresh() {
+				refresher = r
+			}
 		}
 	}
 
@@ -247,7 +491,13 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 	}
 
 	if req.Header.Get("accept-encoding") == "" {
-		req.Header.Set("accept-encoding", buildAcceptEncodingHeader())
+		if viper.GetBool("rsh-no-default-accept-encoding") {
+			// Go's transport otherwise adds its own `Accept-Encoding: gzip` header
+			// when none is present, so explicitly ask for no encoding.
+			req.Header.Set("accept-encoding", "identity")
+		} else {
+			req.Header.Set("accept-encoding", buildAcceptEncodingHeader())
+		}
 	}
 
 	if req.Header.Get("content-type") == "" && req.Body != nil {
@@ -255,6 +505,32 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 		req.Header.Set("content-type", "application/json; charset=utf-8")
 	}
 
+	if viper.GetBool("rsh-timing") && !requestConf.ignoreCLIParams && !requestConf.fromAuth {
+		req = req.WithContext(withTiming(req.Context()))
+	}
+
+	// Short names, profiles, and overrides can all change where a request
+	// goes, so log what is actually being sent.
+	LogDebug("Effective request: %s %s", req.Method, req.URL)
+
+	if viper.GetBool("rsh-curl") && !requestConf.ignoreCLIParams {
+		cmd, err := curlCommand(req, config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(Stdout, cmd)
+		return nil, errRequestNotSent
+	}
+
+	if viper.GetBool("rsh-dry-run") && !requestConf.ignoreCLIParams {
+		dumped, err := dumpDryRun(req)
+		if err != nil {
+			return nil, err
+		}
+		Stdout.Write(dumped)
+		return nil, errRequestNotSent
+	}
+
 	client := CachedTransport().Client()
 	if viper.GetBool("rsh-no-cache") {
 		client = &http.Client{Transport: InvalidateCachedTransport()}
@@ -264,11 +540,115 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 		client = requestConf.client
 	}
 
-	resp, err := doRequestWithRetry(!requestConf.disableLog, client, req)
+	var jar *persistentJar
+	if profile.Cookies && name != "" && !viper.GetBool("rsh-no-cookies") && !requestConf.fromAuth {
+		var err error
+		if jar, err = loadCookieJar(name, viper.GetString("rsh-profile")); err != nil {
+			return nil, err
+		}
+		withJar := *client
+		withJar.Jar = jar
+		client = &withJar
+	}
+
+	if err := throttle(req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	if refresher != nil && req.Body != nil && req.GetBody == nil {
+		// Buffer the body so it can be sent again after refreshing auth.
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	send := func(client *http.Client, req *http.Request) (*http.Response, error) {
+		return doRequestWithRetry(!requestConf.disableLog, client, req)
+	}
+	if requestConf.send != nil {
+		send = requestConf.send
+	}
+
+	unlockSetup()
+	resp, err := send(client, req)
 	if err != nil {
 		return nil, err
 	}
 
+	if refresher != nil && resp.StatusCode == http.StatusUnauthorized {
+		// The cached credentials may be stale in ways an expiry check can't
+		// detect, e.g. revoked. Get fresh ones and retry exactly once.
+		LogWarning("Got 401 Unauthorized, refreshing auth and retrying")
+		resp.Body.Close()
+
+		err := func() error {
+			requestSetupMu.Lock()
+			defer requestSetupMu.Unlock()
+
+			if err := refresher.InvalidateAuth(authKey, profile.Auth.Params); err != nil {
+				return err
+			}
+
+			req.Header.Del("Authorization")
+			return authHandlers[profile.Auth.Name].OnRequest(req, authKey, profile.Auth.Params)
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		if resp, err = send(client, req); err != nil {
+			return nil, err
+		}
+	}
+
+	if uncompressed != nil && viper.GetBool("rsh-compress-safe") && rejectsEncoding(resp, req.Header.Get("Content-Encoding")) {
+		// The server doesn't support compressed requests, so send the body as-is.
+		LogWarning("Server does not accept %s request bodies, retrying uncompressed", req.Header.Get("Content-Encoding"))
+		resp.Body.Close()
+
+		req.Header.Del("Content-Encoding")
+		setBody(req, uncompressed)
+
+		if authHandler != nil {
+			// The body changed, so e.g. a signature over it must be redone.
+			err := func() error {
+				requestSetupMu.Lock()
+				defer requestSetupMu.Unlock()
+
+				req.Header.Del("Authorization")
+				return authHandler.OnRequest(req, authKey, profile.Auth.Params)
+			}()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if resp, err = send(client, req); err != nil {
+			return nil, err
+		}
+	}
+
+	if jar != nil {
+		if err := jar.Save(); err != nil {
+			LogWarning("Unable to save cookies: %v", err)
+		}
+	}
+
+	if resp.Request != nil && resp.Request.URL.String() != req.URL.String() {
+		LogDebug("Redirected to %s", resp.Request.URL)
+	}
+
 	if !requestConf.ignoreStatus {
 		lastStatus = resp.StatusCode
 	}
@@ -276,6 +656,95 @@ func MakeRequest(req *http.Request, options ...requestOption) (*http.Response, e
 	return resp, nil
 }
 
+// sensitiveNames are header names and auth param name fragments whose values
+// should never be printed.
+var sensitiveNames = []string{"authorization", "cookie", "secret", "password", "token", "key"}
+
+// redact hides the value if the name looks like it may contain a secret.
+func redact(name, value string) string {
+	lower := strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(lower, s) && value != "" {
+			return "<redacted>"
+		}
+	}
+	return value
+}
+
+// printEffectiveConfig writes the resolved configuration used to make a
+// request to stderr, with any secrets redacted. This is useful for debugging
+// which API, profile, and settings were selected for a given request.
+func printEffectiveConfig(req *http.Request, name string, config *APIConfig, profile *APIProfile) {
+	headers := map[string]string{}
+	for k, v := range req.Header {
+		headers[k] = redact(k, strings.Join(v, ", "))
+	}
+
+	query := map[string]string{}
+	for k, v := range req.URL.Query() {
+		query[k] = redact(k, strings.Join(v, ", "))
+	}
+
+	base, err := config.baseFor(viper.GetString("rsh-profile"))
+	if err != nil {
+		base = err.Error()
+	}
+
+	effective := map[string]any{
+		"api":     name,
+		"base":    base,
+		"profile": viper.GetString("rsh-profile"),
+		"method":  req.Method,
+		"url":     req.URL.String(),
+		"headers": headers,
+		"query":   query,
+		"flags": map[string]any{
+			"rsh-server":   viper.GetString("rsh-server"),
+			"rsh-no-cache": viper.GetBool("rsh-no-cache"),
+			"rsh-retry":    viper.GetInt("rsh-retry"),
+			"rsh-timeout":  viper.GetDuration("rsh-timeout").String(),
+		},
+	}
+
+	if config.OperationBase != "" {
+		effective["operation_base"] = config.OperationBase
+	}
+
+	if profile.Auth != nil && profile.Auth.Name != "" {
+		params := map[string]string{}
+		for k, v := range profile.Auth.Params {
+			params[k] = redact(k, v)
+		}
+		effective["auth"] = map[string]any{
+			"name":   profile.Auth.Name,
+			"params": params,
+		}
+	}
+
+	if u, ok := req.Context().Value(proxyContextKey{}).(*url.URL); ok {
+		effective["proxy"] = u.Redacted()
+	}
+
+	if config.TLS != nil {
+		effective["tls"] = config.TLS
+	}
+
+	encoded, err := MarshalShort("json", true, effective)
+	if err != nil {
+		LogError("Unable to print config: %v", err)
+		return
+	}
+
+	if useColor {
+		if highlighted, err := Highlight("json", encoded); err == nil {
+			encoded = highlighted
+		}
+	}
+
+	fmt.Fprintln(Stderr, "Effective configuration:")
+	Stderr.Write(encoded)
+}
+
 // isRetryable returns true if a request should be retried.
 func isRetryable(code int) bool {
 	if code == /* 408 */ http.StatusRequestTimeout ||
@@ -290,27 +759,71 @@ func isRetryable(code int) bool {
 	return false
 }
 
+// retryBackoff returns how long to wait before a retry when the server does
+// not say, where attempt starts at zero for the first retry. Without a
+// configured `rsh-retry-backoff` this is always one second. Otherwise the wait
+// doubles for each attempt up to `rsh-retry-max-wait`, with jitter so that many
+// clients retrying at once spread out.
+func retryBackoff(attempt int) time.Duration {
+	base := viper.GetDuration("rsh-retry-backoff")
+	if base <= 0 {
+		return 1 * time.Second
+	}
+
+	maxWait := viper.GetDuration("rsh-retry-max-wait")
+	wait := base
+	for i := 0; i < attempt && (maxWait <= 0 || wait < maxWait); i++ {
+		wait *= 2
+	}
+	if maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+
+	// Keep half the wait and randomize the other half.
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
 // doRequestWithRetry logs and makes a request, retrying as needed (if
 // configured) and returning the last response.
 func doRequestWithRetry(log bool, client *http.Client, req *http.Request) (*http.Response, error) {
 	retries := viper.GetInt("rsh-retry")
+	har := harEnabled()
 
-	if retries == 0 {
-		return client.Do(req)
+	if retries == 0 && !har {
+		resp, _, err := sendRequest(client, req)
+		return resp, err
 	}
 
+	// Bodies which can't be recreated are buffered so they can be resent.
 	var bodyContents []byte
-	if req.Body != nil {
+	if req.Body != nil && req.GetBody == nil {
 		bodyContents, _ = io.ReadAll(req.Body)
 	}
 
+	harReqBody := bodyContents
+	if har && req.GetBody != nil {
+		if b, err := req.GetBody(); err == nil {
+			harReqBody, _ = io.ReadAll(b)
+			b.Close()
+		}
+	}
+
+	// The parent context may carry an overall deadline, see `rsh-deadline`.
+	parent := req.Context()
+
 	var resp *http.Response
+	var release func()
 	var err error
 	triesLeft := 1 + retries
 	for triesLeft > 0 {
 		triesLeft--
 
-		if len(bodyContents) > 0 {
+		if triesLeft < retries && req.GetBody != nil {
+			// Get a fresh body for each retry, e.g. streamed from disk.
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		} else if len(bodyContents) > 0 {
 			// Reset the body reader for each retry.
 			req.Body = io.NopCloser(bytes.NewReader(bodyContents))
 		}
@@ -320,15 +833,20 @@ func doRequestWithRetry(log bool, client *http.Client, req *http.Request) (*http
 		}
 
 		if timeout := viper.GetDuration("rsh-timeout"); timeout > 0 {
-			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			// Each try gets a fresh timeout rather than inheriting the expired
+			// context of the previous try.
+			ctx, cancel := context.WithTimeout(parent, timeout)
 			defer cancel()
 			req = req.WithContext(ctx)
 		}
 
 		start := time.Now()
-		resp, err = client.Do(req)
+		resp, release, err = sendRequest(client, req)
+		if err == nil && har {
+			recordHAR(req, harReqBody, resp, start)
+		}
 		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
+			if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
 				if triesLeft > 0 {
 					// Try again after letting the user know.
 					LogWarning("Got request timeout after %s, retrying", viper.GetDuration("rsh-timeout").Truncate(time.Millisecond))
@@ -347,8 +865,9 @@ func doRequestWithRetry(log bool, client *http.Client, req *http.Request) (*http
 		}
 
 		if triesLeft > 0 && isRetryable(resp.StatusCode) {
-			// Attempt to parse when to retry! Default is 1 second.
-			retryAfter := 1 * time.Second
+			// Attempt to parse when to retry! The server's hint always wins over
+			// the computed backoff.
+			retryAfter := retryBackoff(retries - triesLeft - 1)
 
 			if v := resp.Header.Get("Retry-After"); v != "" {
 				// Could be either an integer number of seconds, or an HTTP date.
@@ -368,7 +887,15 @@ func doRequestWithRetry(log bool, client *http.Client, req *http.Request) (*http
 			}
 
 			LogWarning("Got %s, retrying in %s", resp.Status, retryAfter.Truncate(time.Millisecond))
-			time.Sleep(retryAfter)
+
+			// Let other requests go while waiting.
+			release()
+			select {
+			case <-time.After(retryAfter):
+			case <-parent.Done():
+				// Out of time, so return the last response as-is.
+				return resp, nil
+			}
 
 			continue
 		}
@@ -381,11 +908,14 @@ func doRequestWithRetry(log bool, client *http.Client, req *http.Request) (*http
 // Response describes a parsed HTTP response which can be marshalled to enable
 // printing and filtering/projection.
 type Response struct {
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url,omitempty"`
 	Proto   string            `json:"proto"`
 	Status  int               `json:"status"`
 	Headers map[string]string `json:"headers"`
 	Links   Links             `json:"links"`
 	Body    interface{}       `json:"body"`
+	Timing  *Timing           `json:"timing,omitempty"`
 }
 
 // Map returns a map representing this response matching the encoded JSON.
@@ -413,13 +943,27 @@ func (r Response) Map() map[string]any {
 		headers[k] = v
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"proto":   r.Proto,
 		"status":  r.Status,
 		"headers": headers,
 		"links":   links,
 		"body":    r.Body,
 	}
+
+	if r.Method != "" {
+		result["method"] = r.Method
+	}
+
+	if r.URL != "" {
+		result["url"] = r.URL
+	}
+
+	if r.Timing != nil {
+		result["timing"] = r.Timing.Map()
+	}
+
+	return result
 }
 
 // ParseResponse takes an HTTP response and tries to parse it using the
@@ -447,7 +991,24 @@ func ParseResponse(resp *http.Response) (Response, error) {
 		}
 	}
 
-	// Wrap the body to describe the entire response
+	output, err := wrapResponse(resp, parsed)
+	if err != nil {
+		return output, err
+	}
+
+	if resp.Request != nil {
+		if timing := timingFrom(resp.Request.Context()); timing != nil {
+			timing.finish()
+			output.Timing = timing.result()
+			LogInfo("Timing: %s", formatTiming(output.Timing))
+		}
+	}
+
+	return output, nil
+}
+
+// wrapResponse describes the entire response using the given parsed body.
+func wrapResponse(resp *http.Response, parsed any) (Response, error) {
 	headers := map[string]string{}
 	output := Response{
 		Proto:   resp.Proto,
@@ -465,25 +1026,67 @@ func ParseResponse(resp *http.Response) (Response, error) {
 		headers[k] = strings.Join(v, joiner)
 	}
 
+	if resp.Request != nil {
+		// The final method & URL after any redirects.
+		output.Method = resp.Request.Method
+		output.URL = resp.Request.URL.String()
+	}
+
 	if err := ParseLinks(resp.Request.URL, &output); err != nil {
 		LogWarning("Parse links failed")
 		return Response{}, err
 	}
 
+	warnDeprecatedResponse(output)
+
 	return output, nil
 }
 
+// deadlineError adds a human-friendly message to errors caused by the overall
+// `rsh-deadline` being exceeded.
+func deadlineError(req *http.Request, deadline time.Duration, err error) error {
+	if deadline > 0 && errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("Deadline of %s exceeded: %w", deadline, err)
+	}
+	return err
+}
+
 // GetParsedResponse makes a request and gets the parsed response back. It
 // handles any auto-pagination or linking that needs to be done and may
 // return a psuedo-responsse that is a combination of all responses.
 func GetParsedResponse(req *http.Request, options ...requestOption) (Response, error) {
+	// Let the user cancel with Ctrl-C, keeping any pages fetched so far.
+	ctx, cancel := withInterrupt(req.Context())
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	deadline := viper.GetDuration("rsh-deadline")
+	if deadline > 0 {
+		// Bound the whole operation, including all retries and pages. Each try
+		// may still have a shorter `rsh-timeout`.
+		ctx, cancel := context.WithTimeout(req.Context(), deadline)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
 	resp, err := MakeRequest(req, options...)
 	if err != nil {
-		return Response{}, err
+		return Response{}, interruptError(deadlineError(req, deadline, err))
+	}
+
+	if streaming, _ := req.Context().Value(eventStreamContextKey{}).(bool); streaming && isEventStream(resp) {
+		// Events are printed as they arrive rather than buffering the body.
+		if err := streamEvents(resp); err != nil {
+			return Response{}, err
+		}
+		return Response{}, errResponseStreamed
 	}
 
 	parsed, err := ParseResponse(resp)
 	if err != nil {
+		if err = interruptError(err); errors.Is(err, ErrInterrupted) {
+			return Response{}, err
+		}
 		LogError("Parse response error")
 		return Response{}, err
 	}
@@ -495,44 +1098,90 @@ func GetParsedResponse(req *http.Request, options ...requestOption) (Response, e
 
 	base := req.URL
 	allLinks := parsed.Links
+	page := parsed
+	pagination := paginationFor(req)
+	itemsPath := ""
+	if pagination != nil {
+		itemsPath = pagination.Items
+	}
+	if itemsPath == "" {
+		itemsPath = defaultItemsPath(parsed.Body)
+	}
+	pager := newQueryPager(req, pagination)
+	pages := 1
+	maxPages := viper.GetInt("rsh-max-pages")
 	for {
-		links := parsed.Links
-		if len(links["next"]) == 0 || viper.GetBool("rsh-no-paginate") {
+		if viper.GetBool("rsh-no-paginate") {
 			break
 		}
 
-		LogDebug("Found pagination via rel=next link: %s", links["next"][0].URI)
+		var next *url.URL
+		if links := page.Links; len(links["next"]) > 0 {
+			LogDebug("Found pagination via rel=next link: %s", links["next"][0].URI)
+
+			if _, ok := pageItems(parsed.Body, itemsPath); !ok {
+				if itemsPath != "" {
+					LogWarning("Skipping auto-pagination: no list of items at %s", itemsPath)
+				} else {
+					LogWarning("Skipping auto-pagination: response body not a list, not sure how to merge, set the API's pagination items path")
+				}
+				break
+			}
 
-		if _, ok := parsed.Body.([]interface{}); !ok {
-			// TODO: support non-list formats like JSON:API
-			LogWarning("Skipping auto-pagination: response body not a list, not sure how to merge")
+			next, _ = url.Parse(links["next"][0].URI)
+			next = base.ResolveReference(next)
+		} else if pager != nil {
+			if next = pager.next(page); next == nil {
+				break
+			}
+			LogDebug("Found pagination via %s strategy: %s", pager.config.Strategy, next)
+		} else {
 			break
 		}
 
+		if maxPages > 0 && pages >= maxPages {
+			// Guard against accidentally fetching huge collections.
+			LogWarning("Stopping auto-pagination after %d pages, more are available", maxPages)
+			break
+		}
+		pages++
+
 		// Make the next request
-		next, _ := url.Parse(links["next"][0].URI)
-		next = base.ResolveReference(next)
-		req, _ = http.NewRequest(http.MethodGet, next.String(), nil)
+		req, _ = http.NewRequestWithContext(req.Context(), http.MethodGet, next.String(), nil)
 
+		var parsedNext Response
 		resp, err = MakeRequest(req, options...)
-		if err != nil {
-			return Response{}, err
+		if err == nil {
+			// Merge the responses
+			parsedNext, err = ParseResponse(resp)
 		}
-
-		// Merge the responses
-		parsedNext, err := ParseResponse(resp)
 		if err != nil {
+			if isInterrupted() {
+				LogWarning("Interrupted, showing partial results")
+				break
+			}
+			if deadline > 0 && errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+				// Keep the pages fetched so far rather than failing outright.
+				LogWarning("Deadline of %s exceeded, showing partial results", deadline)
+				break
+			}
 			return Response{}, err
 		}
+		page = parsedNext
 
-		if l, ok := parsedNext.Body.([]interface{}); ok {
+		if l, ok := pageItems(parsedNext.Body, itemsPath); ok {
 			// The last request in the chain will be the one that gets displayed
-			// for the proto/status/headers, plus the merged body/links.
+			// for the proto/status/headers/envelope, plus the merged items/links.
+			merged, _ := pageItems(parsed.Body, itemsPath)
+			body, err := setPageItems(parsedNext.Body, itemsPath, append(merged, l...))
+			if err != nil {
+				return Response{}, err
+			}
 			parsed.Proto = parsedNext.Proto
 			parsed.Status = parsedNext.Status
 			parsed.Headers = parsedNext.Headers
 			parsed.Links = parsedNext.Links
-			parsed.Body = append(parsed.Body.([]interface{}), l...)
+			parsed.Body = body
 
 			for name, links := range parsedNext.Links {
 				allLinks[name] = append(allLinks[name], links...)
@@ -561,18 +1210,75 @@ func GetParsedResponse(req *http.Request, options ...requestOption) (Response, e
 
 // MakeRequestAndFormat is a convenience function for calling `GetParsedResponse`
 // and then calling the default formatter's `Format` function with the parsed
-// response. Panics on error.
+// response. The request may be repeated, see `--rsh-repeat`. Panics on error.
 func MakeRequestAndFormat(req *http.Request) {
+	if repeating() {
+		repeatRequest(req)
+		return
+	}
+
+	makeRequestAndFormat(req)
+}
+
+// makeRequestAndFormat makes a single request and prints the response.
+func makeRequestAndFormat(req *http.Request) {
+	if filename := viper.GetString("rsh-output-file"); filename != "" || viper.GetBool("rsh-remote-name") {
+		if err := download(req, filename); err != nil && !errors.Is(err, errRequestNotSent) {
+			panic(err)
+		}
+		return
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), eventStreamContextKey{}, true))
+
 	parsed, err := GetParsedResponse(req)
 	if err != nil {
+		if errors.Is(err, errRequestNotSent) || errors.Is(err, errResponseStreamed) {
+			return
+		}
 		panic(err)
 	}
 
-	if err := Formatter.Format(parsed); err != nil {
-		if e, ok := err.(shorthand.Error); ok {
-			panic(e.Pretty())
+	if viper.GetBool("rsh-select") {
+		if !viper.GetBool("tty") {
+			panic(fmt.Errorf("--rsh-select requires an interactive terminal"))
+		}
+		if parsed, err = selectItem(defaultAsker{}, parsed); err != nil {
+			panic(err)
+		}
+	}
+
+	if schemas, ok := req.Context().Value(responseSchemasContextKey{}).(map[string]string); ok {
+		validateResponse(parsed, schemas)
+	}
+
+	formatResponse(parsed)
+}
+
+// formatResponse diffs or prints a parsed response based on the output flags.
+func formatResponse(parsed Response) {
+	if baseline := viper.GetString("rsh-diff-against"); baseline != "" {
+		if err := diffAgainst(parsed, baseline); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if !viper.GetBool("rsh-quiet-on-success") || parsed.Status < 200 || parsed.Status >= 300 {
+		// With quiet on success only failures are printed, the exit code
+		// covers the rest.
+		if err := Formatter.Format(parsed); err != nil {
+			if e, ok := err.(shorthand.Error); ok {
+				panic(e.Pretty())
+			}
+			panic(err)
+		}
+	}
+
+	if location := viper.GetString("rsh-assert-schema"); location != "" {
+		if err := assertSchema(parsed, location); err != nil {
+			panic(err)
 		}
-		panic(err)
 	}
 }
 
@@ -4,6 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,6 +29,41 @@ func TestFixAddress(t *testing.T) {
 	delete(configs, "test")
 }
 
+func TestAddQueryParams(t *testing.T) {
+	params := []string{"tags=[a, b]", "tags=c", "q=[x]y", "flag"}
+
+	// Values are sent verbatim by default.
+	query := url.Values{}
+	assert.NoError(t, addQueryParams(query, params, ""))
+	assert.Equal(t, url.Values{"tags": {"[a, b]", "c"}, "q": {"[x]y"}, "flag": {""}}, query)
+
+	for encoding, expected := range map[string]string{
+		"multi":         "flag=&q=%5Bx%5Dy&tags=a&tags=b&tags=c",
+		"csv":           "flag=&q=%5Bx%5Dy&tags=a%2Cb%2Cc",
+		"ssv":           "flag=&q=%5Bx%5Dy&tags=a+b+c",
+		"pipeDelimited": "flag=&q=%5Bx%5Dy&tags=a%7Cb%7Cc",
+	} {
+		query = url.Values{}
+		assert.NoError(t, addQueryParams(query, params, encoding))
+		assert.Equal(t, expected, query.Encode(), encoding)
+	}
+
+	assert.ErrorContains(t, addQueryParams(url.Values{}, params, "bad"), "invalid param encoding")
+}
+
+func TestRequestParamEncoding(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/items").
+		MatchParam("ids", `^1\|2$`).
+		Reply(http.StatusNoContent)
+
+	run("http://example.com/items -q ids=[1,2] --rsh-param-encoding pipes")
+	expectExitCode(t, 0)
+	assert.True(t, gock.IsDone())
+}
+
 func TestRequestPagination(t *testing.T) {
 	defer gock.Off()
 
@@ -60,6 +100,31 @@ func TestRequestPagination(t *testing.T) {
 	assert.Equal(t, []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}, resp.Body)
 }
 
+func TestRequestPaginationMaxPages(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/paginated").
+		Reply(http.StatusOK).
+		SetHeader("Link", "</paginated2>; rel=\"next\"").
+		JSON([]interface{}{1, 2, 3})
+	gock.New("http://example.com").
+		Get("/paginated2").
+		Reply(http.StatusOK).
+		SetHeader("Link", "</paginated3>; rel=\"next\"").
+		JSON([]interface{}{4, 5})
+	gock.New("http://example.com").
+		Get("/paginated3").
+		Reply(http.StatusOK).
+		JSON([]interface{}{6})
+
+	// The partial result is still merged and printed.
+	captured := run("-o json -f body --rsh-max-pages 2 http://example.com/paginated")
+	assert.Contains(t, captured, "Stopping auto-pagination after 2 pages, more are available")
+	assert.Contains(t, captured, "[\n  1,\n  2,\n  3,\n  4,\n  5\n]\n")
+	assert.Len(t, gock.Pending(), 1)
+}
+
 type authHookFailure struct{}
 
 func (a *authHookFailure) Parameters() []AuthParam {
@@ -89,6 +154,166 @@ func TestAuthHookFailure(t *testing.T) {
 	})
 }
 
+func TestAPIKeyAuth(t *testing.T) {
+	auth := &APIKeyAuth{}
+
+	r, _ := http.NewRequest(http.MethodGet, "https://example.com/?a=1", nil)
+	assert.NoError(t, auth.OnRequest(r, "", map[string]string{"name": "X-API-Key", "value": "abc123"}))
+	assert.Equal(t, "abc123", r.Header.Get("X-API-Key"))
+
+	r, _ = http.NewRequest(http.MethodGet, "https://example.com/?a=1", nil)
+	assert.NoError(t, auth.OnRequest(r, "", map[string]string{"name": "api_key", "in": "query", "value": "abc123"}))
+	assert.Equal(t, "a=1&api_key=abc123", r.URL.RawQuery)
+
+	r, _ = http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	assert.NoError(t, auth.OnRequest(r, "", map[string]string{"name": "session", "in": "cookie", "value": "abc123"}))
+	c, err := r.Cookie("session")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", c.Value)
+
+	assert.Error(t, auth.OnRequest(r, "", map[string]string{"name": "key", "in": "body"}))
+	assert.Error(t, auth.OnRequest(r, "", map[string]string{"value": "abc123"}))
+}
+
+func TestBearerAuth(t *testing.T) {
+	auth := &BearerAuth{}
+
+	r, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	assert.NoError(t, auth.OnRequest(r, "", map[string]string{"token": "abc123"}))
+	assert.Equal(t, "Bearer abc123", r.Header.Get("Authorization"))
+
+	t.Setenv("RSH_TEST_TOKEN", "def456")
+	assert.NoError(t, auth.OnRequest(r, "", map[string]string{"token": "$RSH_TEST_TOKEN"}))
+	assert.Equal(t, "Bearer def456", r.Header.Get("Authorization"))
+
+	assert.Error(t, auth.OnRequest(r, "", map[string]string{}))
+}
+
+type authRefreshable struct {
+	tokens      []string
+	invalidated int
+}
+
+func (a *authRefreshable) Parameters() []AuthParam {
+	return []AuthParam{}
+}
+
+func (a *authRefreshable) OnRequest(req *http.Request, key string, params map[string]string) error {
+	if req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+a.tokens[a.invalidated])
+	}
+	return nil
+}
+
+func (a *authRefreshable) InvalidateAuth(key string, params map[string]string) error {
+	a.invalidated++
+	return nil
+}
+
+func TestAuthRefresh(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	reset(false)
+	viper.Set("rsh-auth-refresh", true)
+
+	configs["auth-refresh"] = &APIConfig{
+		Base: "http://refresh.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Auth: &APIAuth{
+					Name: "refreshable",
+				},
+			},
+		},
+	}
+	defer delete(configs, "auth-refresh")
+
+	auth := &authRefreshable{tokens: []string{"stale", "fresh", "unused"}}
+	authHandlers["refreshable"] = auth
+
+	gock.New("http://refresh.example.com").
+		Post("/").
+		MatchHeader("Authorization", "Bearer stale").
+		Reply(http.StatusUnauthorized)
+	gock.New("http://refresh.example.com").
+		Post("/").
+		MatchHeader("Authorization", "Bearer fresh").
+		BodyString("hello").
+		Reply(http.StatusOK)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://refresh.example.com/", bytes.NewReader([]byte("hello")))
+	resp, err := MakeRequest(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, auth.invalidated)
+
+	// Only a single retry is attempted, so a second 401 is returned as-is.
+	gock.New("http://refresh.example.com").
+		Get("/").
+		Times(2).
+		Reply(http.StatusUnauthorized)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://refresh.example.com/", nil)
+	resp, err = MakeRequest(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 2, auth.invalidated)
+	assert.True(t, gock.IsDone())
+}
+
+// authSession opts in to refreshing on any 401, like session auth.
+type authSession struct {
+	authRefreshable
+}
+
+func (a *authSession) AlwaysRefresh() bool {
+	return true
+}
+
+func TestAuthAlwaysRefresh(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+	defer delete(authHandlers, "session")
+
+	reset(false)
+
+	configs["auth-session"] = &APIConfig{
+		Base: "http://session.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Auth: &APIAuth{
+					Name: "session",
+				},
+			},
+		},
+	}
+	defer delete(configs, "auth-session")
+
+	auth := &authSession{authRefreshable{tokens: []string{"expired", "fresh"}}}
+	authHandlers["session"] = auth
+
+	gock.New("http://session.example.com").
+		Get("/").
+		MatchHeader("Authorization", "Bearer expired").
+		Reply(http.StatusUnauthorized)
+	gock.New("http://session.example.com").
+		Get("/").
+		MatchHeader("Authorization", "Bearer fresh").
+		Reply(http.StatusOK)
+
+	// Refreshes without needing `--rsh-auth-refresh`.
+	req, _ := http.NewRequest(http.MethodGet, "http://session.example.com/", nil)
+	resp, err := MakeRequest(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, auth.invalidated)
+	assert.True(t, gock.IsDone())
+}
+
 func TestGetStatus(t *testing.T) {
 	defer gock.Off()
 
@@ -184,6 +409,121 @@ func TestRequestRetryAfter(t *testing.T) {
 	assert.Equal(t, resp.StatusCode, http.StatusOK)
 }
 
+func TestRetryBackoff(t *testing.T) {
+	reset(false)
+
+	// Defaults to a flat wait.
+	assert.Equal(t, 1*time.Second, retryBackoff(0))
+	assert.Equal(t, 1*time.Second, retryBackoff(3))
+
+	viper.Set("rsh-retry-backoff", 100*time.Millisecond)
+	viper.Set("rsh-retry-max-wait", 1*time.Second)
+
+	for attempt, expected := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		1 * time.Second,
+		1 * time.Second,
+	} {
+		for i := 0; i < 10; i++ {
+			wait := retryBackoff(attempt)
+			assert.GreaterOrEqual(t, wait, expected/2)
+			assert.LessOrEqual(t, wait, expected)
+		}
+	}
+}
+
+func TestRequestRetryBackoff(t *testing.T) {
+	defer gock.Off()
+
+	reset(false)
+	viper.Set("rsh-retry", 2)
+	viper.Set("rsh-retry-backoff", 2*time.Millisecond)
+
+	gock.New("http://example.com").
+		Get("/").
+		Times(2).
+		Reply(http.StatusServiceUnavailable)
+
+	gock.New("http://example.com").
+		Get("/").
+		Times(1).
+		Reply(http.StatusOK)
+
+	start := time.Now()
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	resp, err := MakeRequest(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Less(t, time.Since(start), 1*time.Second)
+}
+
+func TestRequestMaxConcurrency(t *testing.T) {
+	reset(false)
+	viper.Set("rsh-max-concurrency", 2)
+
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := MakeRequest(req, IgnoreStatus())
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+}
+
+func TestRequestMaxConcurrencyRetry(t *testing.T) {
+	defer gock.Off()
+
+	reset(false)
+	viper.Set("rsh-max-concurrency", 1)
+	viper.Set("rsh-retry", 1)
+
+	// The slot is released while waiting so the retry can go out.
+	gock.New("http://example.com").
+		Get("/").
+		Times(1).
+		Reply(http.StatusServiceUnavailable).
+		SetHeader("X-Retry-In", "1ms")
+
+	gock.New("http://example.com").
+		Get("/").
+		Times(1).
+		Reply(http.StatusOK)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	resp, err := MakeRequest(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
 func TestRequestRetryTimeout(t *testing.T) {
 	defer gock.Off()
 
@@ -196,7 +536,7 @@ func TestRequestRetryTimeout(t *testing.T) {
 		Get("/").
 		Times(2).
 		Reply(http.StatusOK).
-		Delay(2 * time.Millisecond)
+		Delay(50 * time.Millisecond)
 		// Note: delay seems to have a bug where subsequent requests without the
 		// delay are still delayed... For now just have it reply twice.
 
@@ -206,3 +546,215 @@ func TestRequestRetryTimeout(t *testing.T) {
 	assert.Error(t, err)
 	assert.ErrorContains(t, err, "timed out")
 }
+
+func TestRequestDeadline(t *testing.T) {
+	defer reset(false)
+
+	reset(false)
+	viper.Set("rsh-retry", 5)
+	viper.Set("rsh-timeout", 20*time.Millisecond)
+	viper.Set("rsh-deadline", 50*time.Millisecond)
+
+	// Each try would time out on its own, but together they hit the deadline.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/slow", nil)
+	_, err := GetParsedResponse(req)
+	assert.ErrorContains(t, err, "Deadline of 50ms exceeded")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRequestDeadlineRetryAfter(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	reset(false)
+	viper.Set("rsh-retry", 1)
+	viper.Set("rsh-deadline", 50*time.Millisecond)
+
+	gock.New("http://example.com").
+		Get("/busy").
+		Reply(http.StatusServiceUnavailable).
+		SetHeader("Retry-After", "30")
+
+	// The deadline cuts the wait short and the last response is returned.
+	start := time.Now()
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/busy", nil)
+	parsed, err := GetParsedResponse(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, parsed.Status)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRequestDeadlinePagination(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	reset(false)
+	viper.Set("rsh-retry", 0)
+	viper.Set("rsh-deadline", 50*time.Millisecond)
+
+	gock.New("http://example.com").
+		Get("/pages").
+		Reply(http.StatusOK).
+		SetHeader("Link", "</pages2>; rel=\"next\"").
+		JSON([]any{1})
+
+	gock.New("http://example.com").
+		Get("/pages2").
+		Reply(http.StatusOK).
+		Delay(100 * time.Millisecond).
+		JSON([]any{2})
+
+	captured := &strings.Builder{}
+	Stderr = captured
+
+	// The pages fetched before the deadline are returned with a warning.
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/pages", nil)
+	parsed, err := GetParsedResponse(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0}, parsed.Body)
+	assert.Contains(t, captured.String(), "Deadline of 50ms exceeded, showing partial results")
+}
+
+func TestPrintConfig(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://print-config.example.com").Get("/").Reply(http.StatusNoContent)
+
+	reset(false)
+	configs["print-config"] = &APIConfig{
+		name: "print-config",
+		Base: "http://print-config.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Headers: map[string]string{
+					"Authorization": "abc123",
+					"X-Version":     "2",
+				},
+				Auth: &APIAuth{
+					Name: "http-basic",
+					Params: map[string]string{
+						"username": "user",
+						"password": "secret-password",
+					},
+				},
+			},
+		},
+	}
+
+	captured := runNoReset("print-config/ --rsh-print-config")
+	assert.Contains(t, captured, "Effective configuration")
+	assert.Contains(t, captured, `"api": "print-config"`)
+	assert.Contains(t, captured, `"X-Version": "2"`)
+	assert.Contains(t, captured, `"name": "http-basic"`)
+	assert.Contains(t, captured, `"username": "user"`)
+	assert.NotContains(t, captured, "abc123")
+	assert.NotContains(t, captured, "secret-password")
+	assert.Contains(t, captured, "204 No Content")
+}
+
+func TestResponseFinalURL(t *testing.T) {
+	defer reset(false)
+	reset(false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new?page=2", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	viper.Set("rsh-query", []string{"page=1"})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/old", nil)
+	parsed, err := GetParsedResponse(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, parsed.Method)
+	assert.Equal(t, server.URL+"/new?page=2", parsed.URL)
+	assert.Equal(t, server.URL+"/new?page=2", parsed.Map()["url"])
+}
+
+func TestSharedHeadersQuery(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "shared")
+
+	reset(false)
+	configs["shared"] = &APIConfig{
+		name: "shared",
+		Base: "http://shared.example.com",
+		Headers: map[string]string{
+			"X-Api-Version": "2",
+			"x-client":      "shared",
+		},
+		Query: map[string]string{
+			"format":  "full",
+			"region":  "us",
+			"api_key": "${SHARED_TEST_KEY}",
+		},
+		Profiles: map[string]*APIProfile{
+			"default": {
+				// Profile values override the shared ones.
+				Headers: map[string]string{"X-Client": "profile"},
+				Query:   map[string]string{"region": "eu"},
+			},
+		},
+	}
+
+	gock.New("http://shared.example.com").Get("/items").
+		MatchHeader("X-Api-Version", "2").
+		MatchHeader("X-Client", "^profile$").
+		MatchParam("format", "full").
+		MatchParam("region", "eu").
+		MatchParam("api_key", "^secret$").
+		Reply(http.StatusNoContent)
+
+	// Config values expand environment variables.
+	t.Setenv("SHARED_TEST_KEY", "secret")
+
+	req, _ := http.NewRequest(http.MethodGet, "http://shared.example.com/items", nil)
+	resp, err := MakeRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, []string{"profile"}, req.Header.Values("X-Client"))
+	assert.True(t, gock.IsDone())
+}
+
+func TestDryRun(t *testing.T) {
+	defer gock.Off()
+	defer delete(configs, "dry-run")
+
+	reset(false)
+	configs["dry-run"] = &APIConfig{
+		name: "dry-run",
+		Base: "http://dry-run.example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Query: map[string]string{"api_key": "abc123"},
+				Auth: &APIAuth{
+					Name:   "http-bearer",
+					Params: map[string]string{"token": "secret"},
+				},
+			},
+		},
+	}
+
+	gock.New("http://dry-run.example.com").Post("/items").Reply(http.StatusOK)
+
+	captured := runNoReset("post dry-run/items --rsh-dry-run name: foo")
+	assert.Contains(t, captured, "POST http://dry-run.example.com/items?api_key=abc123 HTTP/1.1\r\n")
+	assert.Contains(t, captured, "Authorization: Bearer secret\r\n")
+	assert.Contains(t, captured, "Content-Type: application/json; charset=utf-8\r\n")
+	assert.Contains(t, captured, `{"name":"foo"}`)
+	assert.False(t, gock.IsDone())
+	expectExitCode(t, 0)
+}
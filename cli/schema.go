@@ -0,0 +1,542 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/datamodel/low"
+	lowbase "github.com/pb33f/libopenapi/datamodel/low/base"
+	"github.com/pb33f/libopenapi/index"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadSchema parses a JSON or YAML JSON Schema document. Local references
+// within the document are resolved.
+func LoadSchema(data []byte) (*base.Schema, error) {
+	var rootNode yaml.Node
+	var ls lowbase.Schema
+
+	if err := yaml.Unmarshal(data, &rootNode); err != nil {
+		return nil, err
+	}
+
+	if len(rootNode.Content) == 0 {
+		return nil, errors.New("empty schema")
+	}
+
+	if err := low.BuildModel(rootNode.Content[0], &ls); err != nil {
+		return nil, err
+	}
+
+	if err := ls.Build(rootNode.Content[0], index.NewSpecIndex(&rootNode)); err != nil {
+		return nil, err
+	}
+
+	return base.NewSchema(&ls), nil
+}
+
+// loadSchemaFrom loads a schema from a local file or an `http(s)://` URL. URLs
+// are fetched using the normal request path so profiles and auth apply.
+func loadSchemaFrom(location string) (*base.Schema, error) {
+	var data []byte
+	var err error
+
+	if !strings.Contains(location, "://") {
+		data, err = os.ReadFile(location)
+	} else {
+		req, _ := http.NewRequest(http.MethodGet, location, nil)
+		var resp *http.Response
+		if resp, err = MakeRequest(req); err == nil {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return nil, fmt.Errorf("unable to fetch schema %s: %s", location, resp.Status)
+			}
+			if err = DecodeResponse(resp); err == nil {
+				data, err = io.ReadAll(resp.Body)
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadSchema(data)
+}
+
+// assertSchema validates the response body against the schema at the given
+// location, logging each validation error.
+func assertSchema(parsed Response, location string) error {
+	s, err := loadSchemaFrom(location)
+	if err != nil {
+		return fmt.Errorf("unable to load schema %s: %w", location, err)
+	}
+
+	errs := ValidateSchema(s, "body", parsed.Body)
+	for _, e := range errs {
+		LogError("%s", e)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("response does not match schema %s (%d errors)", location, len(errs))
+	}
+
+	return nil
+}
+
+// responseSchemasContextKey is the request context key for an operation's
+// response schemas, see `Operation.ResponseSchemas`.
+type responseSchemasContextKey struct{}
+
+// responseSchema returns the schema for a status code, preferring an exact
+// match like `404`, then a range like `4XX`, then `default`.
+func responseSchema(schemas map[string]string, status int) string {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		for k, v := range schemas {
+			if strings.EqualFold(k, key) {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// validateResponse validates a response body against the operation's schema
+// for its status code, logging a warning for each validation error. The
+// response is still displayed as usual.
+func validateResponse(parsed Response, schemas map[string]string) {
+	schema := responseSchema(schemas, parsed.Status)
+	if schema == "" {
+		LogDebug("No response schema for status %d", parsed.Status)
+		return
+	}
+
+	s, err := LoadSchema([]byte(schema))
+	if err != nil {
+		LogWarning("Unable to load response schema: %s", err)
+		return
+	}
+
+	if _, ok := parsed.Body.([]byte); ok {
+		LogDebug("Skipping validation of unstructured response body")
+		return
+	}
+
+	errs := ValidateSchema(s, "body", parsed.Body)
+	for _, e := range errs {
+		LogWarning("%s", e)
+	}
+
+	if len(errs) > 0 {
+		LogWarning("Response does not match the %d schema (%d errors)", parsed.Status, len(errs))
+	}
+}
+
+// validateBody validates a request body against an operation's JSON Schema
+// before it is sent, logging each validation error. Only structured bodies
+// like JSON or YAML are validated.
+func validateBody(mediaType, schema, body string) error {
+	if !strings.Contains(mediaType, "json") && !strings.Contains(mediaType, "yaml") {
+		LogDebug("Skipping validation of %s request body", mediaType)
+		return nil
+	}
+
+	s, err := LoadSchema([]byte(schema))
+	if err != nil {
+		return fmt.Errorf("unable to load request schema: %w", err)
+	}
+
+	var value any
+	if err := yaml.Unmarshal([]byte(body), &value); err != nil {
+		return fmt.Errorf("unable to parse request body: %w", err)
+	}
+
+	errs := ValidateSchema(s, "body", value)
+	for _, e := range errs {
+		LogError("%s", e)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("request body does not match schema (%d errors), not sending", len(errs))
+	}
+
+	return nil
+}
+
+// ValidateSchema validates a JSON-like value against a schema and returns a
+// list of human-readable errors, each prefixed with the path of the invalid
+// field relative to `path`. An empty list means the value is valid.
+func ValidateSchema(s *base.Schema, path string, value any) []string {
+	v := &schemaValidator{}
+	v.validate(s, path, makeJSONSafe(value))
+	return v.errors
+}
+
+type schemaValidator struct {
+	errors []string
+}
+
+func (v *schemaValidator) addError(path, format string, args ...any) {
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// valid returns whether the value is valid against the schema without
+// recording any errors, e.g. for `anyOf` and `oneOf`.
+func (v *schemaValidator) valid(s *base.Schema, path string, value any) bool {
+	sub := &schemaValidator{}
+	sub.validate(s, path, value)
+	return len(sub.errors) == 0
+}
+
+// schemaType returns the JSON Schema type name of a JSON-safe value.
+func schemaType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string, []byte:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		if f, ok := toFloat(v); ok {
+			if f == float64(int64(f)) {
+				return "integer"
+			}
+			return "number"
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// toFloat converts any numeric value to a float64.
+func toFloat(value any) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func (v *schemaValidator) validate(s *base.Schema, path string, value any) {
+	if s == nil {
+		return
+	}
+
+	actual := schemaType(value)
+
+	if len(s.Type) > 0 {
+		matched := false
+		for _, t := range s.Type {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+				break
+			}
+		}
+		if !matched && actual == "null" && s.Nullable != nil && *s.Nullable {
+			matched = true
+		}
+		if !matched {
+			v.addError(path, "expected %s but got %s", strings.Join(s.Type, " or "), actual)
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(makeJSONSafe(e), value) || fmt.Sprintf("%v", e) == fmt.Sprintf("%v", value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addError(path, "expected one of %v but got %v", s.Enum, value)
+		}
+	}
+
+	switch actual {
+	case "integer", "number":
+		f, _ := toFloat(value)
+		v.validateNumber(s, path, f)
+	case "string":
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		v.validateString(s, path, value.(string))
+	case "array":
+		v.validateArray(s, path, value.([]any))
+	case "object":
+		v.validateObject(s, path, value.(map[string]any))
+	}
+
+	for _, sp := range s.AllOf {
+		v.validate(sp.Schema(), path, value)
+	}
+
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sp := range s.AnyOf {
+			if v.valid(sp.Schema(), path, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.addError(path, "does not match any of the allowed schemas (anyOf)")
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		count := 0
+		for _, sp := range s.OneOf {
+			if v.valid(sp.Schema(), path, value) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.addError(path, "expected to match exactly one schema (oneOf) but matched %d", count)
+		}
+	}
+
+	if s.Not != nil && v.valid(s.Not.Schema(), path, value) {
+		v.addError(path, "must not match the schema (not)")
+	}
+
+	if s.If != nil {
+		if v.valid(s.If.Schema(), path, value) {
+			if s.Then != nil {
+				v.validate(s.Then.Schema(), path, value)
+			}
+		} else if s.Else != nil {
+			v.validate(s.Else.Schema(), path, value)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(s *base.Schema, path string, value float64) {
+	if s.Minimum != nil {
+		if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.IsA() && s.ExclusiveMinimum.A {
+			if value <= *s.Minimum {
+				v.addError(path, "expected number > %v but got %v", *s.Minimum, value)
+			}
+		} else if value < *s.Minimum {
+			v.addError(path, "expected number >= %v but got %v", *s.Minimum, value)
+		}
+	}
+
+	if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.IsB() && value <= s.ExclusiveMinimum.B {
+		v.addError(path, "expected number > %v but got %v", s.ExclusiveMinimum.B, value)
+	}
+
+	if s.Maximum != nil {
+		if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.IsA() && s.ExclusiveMaximum.A {
+			if value >= *s.Maximum {
+				v.addError(path, "expected number < %v but got %v", *s.Maximum, value)
+			}
+		} else if value > *s.Maximum {
+			v.addError(path, "expected number <= %v but got %v", *s.Maximum, value)
+		}
+	}
+
+	if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.IsB() && value >= s.ExclusiveMaximum.B {
+		v.addError(path, "expected number < %v but got %v", s.ExclusiveMaximum.B, value)
+	}
+
+	if s.MultipleOf != nil && *s.MultipleOf != 0 {
+		quotient := value / *s.MultipleOf
+		if quotient != float64(int64(quotient)) {
+			v.addError(path, "expected multiple of %v but got %v", *s.MultipleOf, value)
+		}
+	}
+}
+
+// schemaFormats validates common string formats. Unknown formats are ignored.
+var schemaFormats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		_, err := mail.ParseAddress(s)
+		return err == nil
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && strings.Contains(s, ".")
+	},
+	"ipv6": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	},
+}
+
+func (v *schemaValidator) validateString(s *base.Schema, path string, value string) {
+	length := int64(utf8.RuneCountInString(value))
+
+	if s.MinLength != nil && length < *s.MinLength {
+		v.addError(path, "expected length >= %d but got %d", *s.MinLength, length)
+	}
+
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.addError(path, "expected length <= %d but got %d", *s.MaxLength, length)
+	}
+
+	if s.Pattern != "" {
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(value) {
+			v.addError(path, "expected string matching %s but got %s", s.Pattern, strconv.Quote(value))
+		}
+	}
+
+	if check := schemaFormats[s.Format]; check != nil && !check(value) {
+		v.addError(path, "expected %s format but got %s", s.Format, strconv.Quote(value))
+	}
+}
+
+func (v *schemaValidator) validateArray(s *base.Schema, path string, value []any) {
+	length := int64(len(value))
+
+	if s.MinItems != nil && length < *s.MinItems {
+		v.addError(path, "expected at least %d items but got %d", *s.MinItems, length)
+	}
+
+	if s.MaxItems != nil && length > *s.MaxItems {
+		v.addError(path, "expected at most %d items but got %d", *s.MaxItems, length)
+	}
+
+	if s.UniqueItems != nil && *s.UniqueItems {
+	outer:
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					v.addError(path, "expected unique items but %d and %d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	for i, item := range value {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(s.PrefixItems) {
+			v.validate(s.PrefixItems[i].Schema(), itemPath, item)
+			continue
+		}
+
+		if s.Items != nil {
+			if s.Items.IsA() && s.Items.A != nil {
+				v.validate(s.Items.A.Schema(), itemPath, item)
+			} else if s.Items.IsB() && !s.Items.B {
+				v.addError(path, "expected at most %d items but got %d", len(s.PrefixItems), length)
+				break
+			}
+		}
+	}
+
+	if s.Contains != nil {
+		count := int64(0)
+		for i, item := range value {
+			if v.valid(s.Contains.Schema(), fmt.Sprintf("%s[%d]", path, i), item) {
+				count++
+			}
+		}
+
+		min := int64(1)
+		if s.MinContains != nil {
+			min = *s.MinContains
+		}
+		if count < min {
+			v.addError(path, "expected at least %d matching items (contains) but got %d", min, count)
+		}
+		if s.MaxContains != nil && count > *s.MaxContains {
+			v.addError(path, "expected at most %d matching items (contains) but got %d", *s.MaxContains, count)
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s *base.Schema, path string, value map[string]any) {
+	count := int64(len(value))
+
+	if s.MinProperties != nil && count < *s.MinProperties {
+		v.addError(path, "expected at least %d properties but got %d", *s.MinProperties, count)
+	}
+
+	if s.MaxProperties != nil && count > *s.MaxProperties {
+		v.addError(path, "expected at most %d properties but got %d", *s.MaxProperties, count)
+	}
+
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			v.addError(path+"."+name, "required property is missing")
+		}
+	}
+
+	// Go maps are unordered, so sort for stable output.
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		propPath := path + "." + k
+		evaluated := false
+
+		if sp := s.Properties[k]; sp != nil {
+			v.validate(sp.Schema(), propPath, value[k])
+			evaluated = true
+		}
+
+		for pattern, sp := range s.PatternProperties {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(k) {
+				v.validate(sp.Schema(), propPath, value[k])
+				evaluated = true
+			}
+		}
+
+		if evaluated {
+			continue
+		}
+
+		switch ap := s.AdditionalProperties.(type) {
+		case bool:
+			if !ap {
+				v.addError(propPath, "unexpected property")
+			}
+		case *base.SchemaProxy:
+			v.validate(ap.Schema(), propPath, value[k])
+		}
+	}
+}
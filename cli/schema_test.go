@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const testUserSchema = `{
+  "type": "object",
+  "required": ["id", "name"],
+  "additionalProperties": false,
+  "properties": {
+    "id": {"type": "integer", "minimum": 1},
+    "name": {"type": "string", "minLength": 2},
+    "email": {"type": "string", "format": "email"},
+    "role": {"enum": ["admin", "user"]},
+    "score": {"type": "number", "exclusiveMaximum": 100},
+    "tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+    "address": {"$ref": "#/$defs/address"},
+    "nickname": {"type": ["string", "null"]}
+  },
+  "$defs": {
+    "address": {
+      "type": "object",
+      "properties": {
+        "zip": {"type": "string", "pattern": "^[0-9]{5}$"}
+      }
+    }
+  }
+}`
+
+func TestValidateSchema(t *testing.T) {
+	s, err := LoadSchema([]byte(testUserSchema))
+	assert.NoError(t, err)
+
+	assert.Empty(t, ValidateSchema(s, "body", map[string]any{
+		"id":       1,
+		"name":     "Alice",
+		"email":    "alice@example.com",
+		"role":     "admin",
+		"score":    99.5,
+		"tags":     []any{"a", "b"},
+		"address":  map[string]any{"zip": "12345"},
+		"nickname": nil,
+	}))
+
+	assert.Equal(t, []string{
+		"body.name: required property is missing",
+		"body.address.zip: expected string matching ^[0-9]{5}$ but got \"abc\"",
+		"body.email: expected email format but got \"nope\"",
+		"body.extra: unexpected property",
+		"body.id: expected number >= 1 but got 0",
+		"body.role: expected one of [admin user] but got owner",
+		"body.score: expected number < 100 but got 100",
+		"body.tags: expected at most 3 items but got 4",
+		"body.tags: expected unique items but 0 and 1 are equal",
+		"body.tags[3]: expected string but got integer",
+	}, ValidateSchema(s, "body", map[string]any{
+		"id":      0,
+		"email":   "nope",
+		"role":    "owner",
+		"score":   100,
+		"tags":    []any{"a", "a", "b", 5},
+		"address": map[string]any{"zip": "abc"},
+		"extra":   true,
+	}))
+
+	assert.Equal(t, []string{"body: expected object but got array"}, ValidateSchema(s, "body", []any{}))
+}
+
+func TestValidateSchemaComposition(t *testing.T) {
+	s, err := LoadSchema([]byte(`
+oneOf:
+  - type: string
+  - type: integer
+    multipleOf: 5
+not:
+  enum: [10]
+`))
+	assert.NoError(t, err)
+
+	assert.Empty(t, ValidateSchema(s, "body", "hello"))
+	assert.Empty(t, ValidateSchema(s, "body", 15))
+	assert.Equal(t, []string{"body: expected to match exactly one schema (oneOf) but matched 0"}, ValidateSchema(s, "body", 7))
+	assert.Equal(t, []string{"body: must not match the schema (not)"}, ValidateSchema(s, "body", 10))
+}
+
+func TestAssertSchema(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	filename := filepath.Join(t.TempDir(), "user.schema.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(testUserSchema), 0600))
+
+	gock.New("http://example.com").
+		Get("/users/1").
+		Reply(200).
+		JSON(map[string]any{"id": 1, "name": "Alice"})
+
+	out := run("http://example.com/users/1 --rsh-assert-schema " + filename)
+	assert.Contains(t, out, "Alice")
+	assert.NotContains(t, out, "does not match")
+
+	gock.New("http://example.com").
+		Get("/users/2").
+		Reply(200).
+		JSON(map[string]any{"id": "2"})
+
+	out = run("http://example.com/users/2 --rsh-assert-schema " + filename)
+	assert.Contains(t, out, "body.id: expected integer but got string")
+	assert.Contains(t, out, "body.name: required property is missing")
+	assert.Contains(t, out, "does not match schema")
+}
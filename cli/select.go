@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/viper"
+)
+
+// selectLabelFields are tried in order to label items when no label field is
+// given.
+var selectLabelFields = []string{"name", "title", "id"}
+
+// selectItems returns the list of items to select from, which is either the
+// body itself or the only array field of an object body, e.g. `data`.
+func selectItems(body any) ([]any, bool) {
+	if list, ok := body.([]any); ok {
+		return list, true
+	}
+
+	var found []any
+	if m, ok := body.(map[string]any); ok {
+		for _, v := range m {
+			if list, ok := v.([]any); ok {
+				if found != nil {
+					// Ambiguous, multiple arrays.
+					return nil, false
+				}
+				found = list
+			}
+		}
+	}
+
+	return found, found != nil
+}
+
+// selectLabel returns a short label describing an item.
+func selectLabel(item any, field string) string {
+	if m, ok := item.(map[string]any); ok {
+		fields := selectLabelFields
+		if field != "" {
+			fields = []string{field}
+		}
+		for _, f := range fields {
+			if v, ok := m[f]; ok && v != nil {
+				return fmt.Sprintf("%v", v)
+			}
+		}
+	}
+
+	encoded, _ := json.Marshal(item)
+	label := string(encoded)
+	if runes := []rune(label); len(runes) > 60 {
+		label = string(runes[:57]) + "..."
+	}
+	return label
+}
+
+// selectItem prompts the user to pick an item from a list response. If the
+// chosen item has a `self` link, then it is fetched and returned. Otherwise
+// the item itself is returned as the response body.
+func selectItem(a asker, parsed Response) (Response, error) {
+	items, ok := selectItems(parsed.Body)
+	if !ok {
+		return parsed, fmt.Errorf("cannot select from response, expected a list of items")
+	}
+
+	if len(items) == 0 {
+		LogWarning("No items to select from")
+		return parsed, nil
+	}
+
+	field := viper.GetString("rsh-select-label")
+	options := make([]string, len(items))
+	for i, item := range items {
+		options[i] = fmt.Sprintf("%d. %s", i+1, selectLabel(item, field))
+	}
+
+	choice := a.askSelect("Select an item", options, nil, "")
+
+	index := -1
+	for i, option := range options {
+		if option == choice {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return parsed, fmt.Errorf("invalid selection %s", choice)
+	}
+	item := items[index]
+
+	// Use the existing link parsers to find the item's own link, resolving
+	// it against the list's URL.
+	base, _ := url.Parse(parsed.URL)
+	if base == nil {
+		base = &url.URL{}
+	}
+	itemResp := Response{Body: item, Headers: map[string]string{}, Links: Links{}}
+	if err := ParseLinks(base, &itemResp); err != nil {
+		return parsed, err
+	}
+
+	if self := itemResp.Links["self"]; len(self) > 0 {
+		LogDebug("Following selected item link %s", self[0].URI)
+		req, err := http.NewRequest(http.MethodGet, self[0].URI, nil)
+		if err != nil {
+			return parsed, err
+		}
+		return GetParsedResponse(req)
+	}
+
+	parsed.Body = item
+	parsed.Links = itemResp.Links
+	return parsed, nil
+}
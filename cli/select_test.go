@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestSelectLabel(t *testing.T) {
+	assert.Equal(t, "foo", selectLabel(map[string]any{"id": 1, "name": "foo"}, ""))
+	assert.Equal(t, "1", selectLabel(map[string]any{"id": 1, "name": "foo"}, "id"))
+	assert.Equal(t, `{"x":1}`, selectLabel(map[string]any{"x": 1}, ""))
+	assert.Equal(t, "5", selectLabel(5, ""))
+
+	// Long labels are truncated on a character boundary.
+	label := selectLabel([]any{strings.Repeat("é", 100)}, "")
+	assert.True(t, utf8.ValidString(label))
+	assert.Equal(t, `["`+strings.Repeat("é", 55)+"...", label)
+}
+
+func TestSelectItemFollowsLink(t *testing.T) {
+	defer gock.Off()
+	reset(false)
+
+	gock.New("http://example.com").
+		Get("/items/2").
+		Reply(200).
+		JSON(map[string]any{"id": 2, "detail": true})
+
+	parsed := Response{
+		URL:    "http://example.com/items",
+		Status: 200,
+		Body: []any{
+			map[string]any{"id": 1, "name": "one", "self": "/items/1"},
+			map[string]any{"id": 2, "name": "two", "self": "/items/2"},
+		},
+	}
+
+	mock := &mockAsker{t: t, responses: []string{"2. two"}}
+	selected, err := selectItem(mock, parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 2.0, "detail": true}, selected.Body)
+	assert.True(t, gock.IsDone())
+}
+
+func TestSelectItemWithoutLink(t *testing.T) {
+	reset(false)
+	viper.Set("rsh-select-label", "id")
+
+	// The only array in an object body is used, e.g. for `data` envelopes.
+	parsed := Response{
+		Status: 200,
+		Body: map[string]any{
+			"data":  []any{map[string]any{"id": "a"}, map[string]any{"id": "b"}},
+			"count": 2,
+		},
+	}
+
+	mock := &mockAsker{t: t, responses: []string{"1. a"}}
+	selected, err := selectItem(mock, parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": "a"}, selected.Body)
+
+	_, err = selectItem(mock, Response{Body: map[string]any{"id": "a"}})
+	assert.ErrorContains(t, err, "expected a list")
+}
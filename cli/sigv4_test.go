@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var sigV4TestParams = map[string]string{
+	"access_key_id":     "AKIDEXAMPLE",
+	"secret_access_key": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	"region":            "us-east-1",
+	"service":           "service",
+}
+
+func TestAWSSigV4Auth(t *testing.T) {
+	sigV4Now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	defer func() { sigV4Now = time.Now }()
+
+	// Known values from the AWS SigV4 test suite (get-vanilla).
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	assert.NoError(t, (&AWSSigV4Auth{}).OnRequest(req, "", sigV4TestParams))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31", req.Header.Get("Authorization"))
+
+	// The body is hashed but must still be available to send.
+	req, _ = http.NewRequest(http.MethodPost, "https://example.amazonaws.com/", strings.NewReader("Param1=value1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.NoError(t, (&AWSSigV4Auth{}).OnRequest(req, "", sigV4TestParams))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-amz-date,")
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "Param1=value1", string(body))
+}
+
+func TestAWSSigV4AuthEnv(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.s3.amazonaws.com/key?b=2&a=1", nil)
+	assert.NoError(t, (&AWSSigV4Auth{}).OnRequest(req, "", map[string]string{"region": "us-west-2", "service": "s3"}))
+	assert.Contains(t, req.Header.Get("Authorization"), "Credential=AKIDEXAMPLE/")
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,")
+	assert.Equal(t, "token", req.Header.Get("X-Amz-Security-Token"))
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", req.Header.Get("X-Amz-Content-Sha256"))
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	assert.Error(t, (&AWSSigV4Auth{}).OnRequest(req, "", map[string]string{"region": "us-west-2", "service": "s3"}))
+}
+
+func TestSigV4Canonical(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/a%20b/c?z=1&a=x%20y&a=b", nil)
+	assert.Equal(t, "/a%2520b/c", sigV4Path(req, true))
+	assert.Equal(t, "/a%20b/c", sigV4Path(req, false))
+	assert.Equal(t, "a=b&a=x%20y&z=1", sigV4Query(req))
+}
+
+// reSignedHeaders matches the list of signed headers in a SigV4 signature.
+var reSignedHeaders = regexp.MustCompile(`SignedHeaders=([^,]+),`)
+
+// sigV4Valid checks a received request's signature the way a server would, by
+// signing the body & signed headers it actually got.
+func sigV4Valid(req *http.Request) bool {
+	auth := req.Header.Get("Authorization")
+	match := reSignedHeaders.FindStringSubmatch(auth)
+	if match == nil {
+		return false
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+
+	check, _ := http.NewRequest(req.Method, req.URL.String(), bytes.NewReader(body))
+	for _, name := range strings.Split(match[1], ";") {
+		if name != "host" {
+			check.Header.Set(name, req.Header.Get(name))
+		}
+	}
+
+	if err := (&AWSSigV4Auth{}).OnRequest(check, "", sigV4TestParams); err != nil {
+		return false
+	}
+	return check.Header.Get("Authorization") == auth
+}
+
+// withSigV4API configures an API which signs requests to example.com.
+func withSigV4API(t *testing.T) {
+	sigV4Now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	configs["sigv4-test"] = &APIConfig{
+		Base: "http://example.com",
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Auth: &APIAuth{Name: "aws-sigv4", Params: sigV4TestParams},
+			},
+		},
+	}
+	t.Cleanup(func() {
+		sigV4Now = time.Now
+		delete(configs, "sigv4-test")
+	})
+}
+
+func TestAWSSigV4AuthNoBody(t *testing.T) {
+	defer gock.Off()
+	defer reset(false)
+
+	gock.New("http://example.com").Delete("/items/1").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			return req.Body == nil && sigV4Valid(req), nil
+		}).
+		Reply(http.StatusNoContent)
+
+	reset(false)
+	withSigV4API(t)
+	viper.Set("rsh-no-body", true)
+
+	req, _ := http.NewRequest(http.MethodDelete, "http://example.com/items/1", strings.NewReader(`{"foo": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := MakeRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// errResponseStreamed is returned by `GetParsedResponse` when the response
+// was already printed as it arrived, e.g. for server-sent events.
+var errResponseStreamed = errors.New("response streamed")
+
+// eventStreamContextKey marks requests whose caller prints server-sent events
+// as they arrive instead of waiting for the full response.
+type eventStreamContextKey struct{}
+
+// maxEventLine is the longest line accepted in an event stream.
+const maxEventLine = 16 * 1024 * 1024
+
+// isEventStream returns whether a response should be handled as a stream of
+// server-sent events. The `--rsh-stream` flag forces this for any successful
+// response, e.g. when a server uses the wrong content type.
+func isEventStream(resp *http.Response) bool {
+	if ct, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && ct == "text/event-stream" {
+		return true
+	}
+
+	return viper.GetBool("rsh-stream") && resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// streamEvents prints each server-sent event in the response as it arrives.
+// Each event is formatted as the response body so filters apply per event.
+func streamEvents(resp *http.Response) error {
+	defer resp.Body.Close()
+	if err := DecodeResponse(resp); err != nil {
+		return err
+	}
+
+	output, err := wrapResponse(resp, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := startStream(output); err != nil {
+		return err
+	}
+
+	err = readEvents(resp.Body, func(event map[string]any) error {
+		output.Body = event
+		return Formatter.Format(output)
+	})
+	if err != nil && isInterrupted() {
+		// Ctrl-C is the usual way to stop tailing a stream.
+		return nil
+	}
+	return err
+}
+
+// startStream prepares to print a stream of messages, like server-sent events,
+// from a response. The status and headers are shown once up front in readable
+// output, then each message is formatted as the response body.
+func startStream(output Response) error {
+	if viper.GetString("rsh-filter") == "" {
+		if format := viper.GetString("rsh-output-format"); viper.GetBool("tty") && (format == "auto" || format == "readable") {
+			if err := Formatter.Format(output); err != nil {
+				return err
+			}
+			Stdout.Write([]byte("\n"))
+		}
+		viper.Set("rsh-filter", "body")
+	}
+	return nil
+}
+
+// readEvents parses server-sent events from the reader, calling the handler
+// for each one with its `event` type, `data`, and optional `id` and `retry`
+// fields. Data which is valid JSON is decoded so it can be filtered. See
+// https://html.spec.whatwg.org/multipage/server-sent-events.html
+func readEvents(r io.Reader, handle func(event map[string]any) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventLine)
+	scanner.Split(scanEventLines)
+
+	eventType := ""
+	id := ""
+	retry := -1
+	data := strings.Builder{}
+	hasData := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			// A blank line dispatches the event. Events without data, e.g. only
+			// setting the retry interval, are not shown.
+			if hasData {
+				if eventType == "" {
+					eventType = "message"
+				}
+
+				event := map[string]any{
+					"event": eventType,
+					"data":  eventData(strings.TrimSuffix(data.String(), "\n")),
+				}
+				if id != "" {
+					event["id"] = id
+				}
+				if retry >= 0 {
+					event["retry"] = retry
+				}
+
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+
+			// The last event ID carries over to later events.
+			eventType = ""
+			retry = -1
+			data.Reset()
+			hasData = false
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			// Comment, often sent to keep the connection alive.
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				id = value
+			}
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				retry = n
+			}
+		}
+	}
+
+	// Any incomplete event at the end of the stream is discarded.
+	return scanner.Err()
+}
+
+// eventData decodes event data if it is JSON, otherwise it stays a string.
+func eventData(data string) any {
+	var decoded any
+	if err := Unmarshal("application/json", []byte(data), &decoded); err == nil {
+		return decoded
+	}
+	return data
+}
+
+// scanEventLines is a `bufio.SplitFunc` for event stream lines, which may end
+// with `\r\n`, `\n`, or `\r`.
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 == len(data) && !atEOF {
+				// Wait to see whether this is a `\r\n` pair.
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keep-alive\r\n" +
+		"retry: 500\r\n" +
+		"\r\n" +
+		"data: plain\r\n" +
+		"\r\n" +
+		"event: update\n" +
+		"id: 1\n" +
+		"data: {\"n\": 1,\n" +
+		"data:\"ok\": true}\n" +
+		"\n" +
+		"data:second\rretry: 10\r\r" +
+		"data: incomplete"
+
+	events := []map[string]any{}
+	err := readEvents(strings.NewReader(stream), func(event map[string]any) error {
+		events = append(events, event)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]any{
+		{"event": "message", "data": "plain"},
+		{"event": "update", "id": "1", "data": map[string]any{"n": 1.0, "ok": true}},
+		{"event": "message", "id": "1", "data": "second", "retry": 10},
+	}, events)
+}
+
+func TestReadEventsHandlerError(t *testing.T) {
+	err := readEvents(strings.NewReader("data: 1\n\ndata: 2\n\n"), func(event map[string]any) error {
+		return fmt.Errorf("stop")
+	})
+	assert.EqualError(t, err, "stop")
+}
+
+func TestStreamEvents(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/events").
+		Reply(200).
+		SetHeader("Content-Type", "text/event-stream").
+		BodyString("data: {\"n\": 1}\n\ndata: {\"n\": 2}\n\n")
+
+	out := run("http://example.com/events")
+	assert.Equal(t, "HTTP/1.1 200 OK\nContent-Type: text/event-stream\n\n{\n  data: {\n    n: 1\n  }\n  event: \"message\"\n}\n{\n  data: {\n    n: 2\n  }\n  event: \"message\"\n}\n", out)
+
+	// Filters apply to each event.
+	gock.New("http://example.com").Get("/events").
+		Reply(200).
+		SetHeader("Content-Type", "text/event-stream; charset=utf-8").
+		BodyString("data: {\"n\": 1}\n\ndata: {\"n\": 2}\n\n")
+
+	out = run("-o json -f body.data.n http://example.com/events")
+	assert.Equal(t, "1\n2\n", out)
+}
+
+func TestStreamEventsForced(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").Get("/events").
+		Reply(200).
+		SetHeader("Content-Type", "text/plain").
+		BodyString("event: ping\ndata: hi\n\n")
+
+	out := run("-o json --rsh-stream http://example.com/events")
+	assert.JSONEq(t, `{"event": "ping", "data": "hi"}`, out)
+}
+
+// interruptWriter interrupts requests once something has been written.
+type interruptWriter struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (w *interruptWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	interrupt()
+	return w.buf.Write(p)
+}
+
+func TestStreamEventsInterrupt(t *testing.T) {
+	defer reset(false)
+	reset(false)
+	viper.Set("rsh-output-format", "json")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+
+		// Never finish, the client must print events before the stream ends.
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	out := &interruptWriter{}
+	Stdout = out
+
+	start := time.Now()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	assert.NotPanics(t, func() {
+		MakeRequestAndFormat(req)
+	})
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.JSONEq(t, `{"event": "message", "data": "first"}`, out.buf.String())
+}
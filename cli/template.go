@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+)
+
+// templateFuncs are available to `-o template` output templates.
+var templateFuncs = template.FuncMap{
+	// json encodes a value as compact JSON.
+	"json": func(value any) (string, error) {
+		encoded, err := json.Marshal(makeJSONSafe(value))
+		return string(encoded), err
+	},
+	// join joins the items of a list with a separator.
+	"join": func(sep string, value any) (string, error) {
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return "", fmt.Errorf("join expects a list but got %T", value)
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprintf("%v", v.Index(i).Interface())
+		}
+		return strings.Join(parts, sep), nil
+	},
+	// default returns the default if the value is missing or empty.
+	"default": func(def, value any) any {
+		if value == nil {
+			return def
+		}
+		if v := reflect.ValueOf(value); v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+			return def
+		}
+		return value
+	},
+}
+
+// loadTemplate returns the output template from `--rsh-template`, which may
+// reference a file like `@report.tmpl`.
+func loadTemplate() (string, error) {
+	text := viper.GetString("rsh-template")
+	if text == "" {
+		return "", fmt.Errorf("template output requires --rsh-template")
+	}
+
+	if filename, ok := strings.CutPrefix(text, "@"); ok {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		text = string(data)
+	}
+
+	return text, nil
+}
+
+// renderTemplate renders the output template against the given data.
+func renderTemplate(data any) ([]byte, error) {
+	text, err := loadTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, makeJSONSafe(data)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
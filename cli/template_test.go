@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func formatTemplate(t *testing.T, filter, tmpl string, body any) (string, error) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	Stdout = buf
+	viper.Reset()
+	viper.Set("rsh-output-format", "template")
+	viper.Set("rsh-filter", filter)
+	viper.Set("rsh-template", tmpl)
+
+	err := NewDefaultFormatter(false, false).Format(Response{
+		Status:  200,
+		Headers: map[string]string{"Etag": "abc"},
+		Body:    body,
+	})
+	return buf.String(), err
+}
+
+func TestTemplateOutput(t *testing.T) {
+	body := map[string]any{
+		"name":  "alice",
+		"tags":  []any{"a", "b"},
+		"items": []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+	}
+
+	// The full response is used by default.
+	out, err := formatTemplate(t, "", `{{.status}} {{.headers.Etag}} {{.body.name}}`, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "200 abc alice\n", out)
+
+	// Filters select the data passed to the template.
+	out, err = formatTemplate(t, "body.items", "{{range .}}id={{.id}}\n{{end}}", body)
+	assert.NoError(t, err)
+	assert.Equal(t, "id=1\nid=2\n", out)
+
+	out, err = formatTemplate(t, "body", `{{join "," .tags}} {{json .items}} {{default "none" .missing}}`, body)
+	assert.NoError(t, err)
+	assert.Equal(t, `a,b [{"id":1},{"id":2}] none`+"\n", out)
+}
+
+func TestTemplateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.tmpl")
+	assert.NoError(t, os.WriteFile(filename, []byte("export NAME={{.body.name}}\n"), 0600))
+
+	out, err := formatTemplate(t, "", "@"+filename, map[string]any{"name": "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, "export NAME=bob\n", out)
+}
+
+func TestTemplateErrors(t *testing.T) {
+	_, err := formatTemplate(t, "", "", nil)
+	assert.ErrorContains(t, err, "requires --rsh-template")
+
+	_, err = formatTemplate(t, "", "{{.body.name", nil)
+	assert.Error(t, err)
+
+	_, err = formatTemplate(t, "", `{{join "," .body}}`, map[string]any{})
+	assert.ErrorContains(t, err, "join expects a list")
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in themes for highlighted output, see `--rsh-theme`. Any other value
+// is treated as the path to a theme file.
+const (
+	themeDark  = "dark"
+	themeLight = "light"
+	theme256   = "256"
+	themeNone  = "none"
+)
+
+// themeStyles maps built-in theme names to their registered chroma styles.
+var themeStyles = map[string]string{
+	themeDark:  "cli-dark",
+	themeLight: "cli-light",
+	theme256:   "cli-256",
+}
+
+func init() {
+	// Darker colors for terminals with a light background.
+	styles.Register(chroma.MustNewStyle("cli-light", chroma.StyleEntries{
+		chroma.Comment:      "#808080",
+		chroma.Keyword:      "#d7005f",
+		chroma.Punctuation:  "#808080",
+		chroma.NameTag:      "#005f87",
+		chroma.Number:       "#af5f00",
+		chroma.String:       "#5f8700",
+		chroma.StringSymbol: "italic #00875f",
+		chroma.Date:         "#875f87",
+		chroma.NumberHex:    "#af0000",
+
+		chroma.Name:          "#005f87",
+		chroma.NameFunction:  "#d7005f",
+		chroma.NameNamespace: "#585858",
+
+		chroma.GenericHeading:    "#005f87",
+		chroma.GenericSubheading: "#005f87",
+		chroma.GenericEmph:       "italic #870000",
+		chroma.GenericStrong:     "bold #875f87",
+		chroma.GenericDeleted:    "#d7005f",
+		chroma.GenericInserted:   "#5f8700",
+		chroma.NameAttribute:     "underline",
+
+		IndentLevel1: "#af5f00",
+		IndentLevel2: "#875f87",
+		IndentLevel3: "#005f87",
+	}))
+
+	// Mid-tone colors which are readable on both dark & light backgrounds.
+	styles.Register(chroma.MustNewStyle("cli-256", chroma.StyleEntries{
+		chroma.Comment:      "#8a8a8a",
+		chroma.Keyword:      "#d75f87",
+		chroma.Punctuation:  "#8a8a8a",
+		chroma.NameTag:      "#5f87af",
+		chroma.Number:       "#d7875f",
+		chroma.String:       "#87af5f",
+		chroma.StringSymbol: "italic #5faf87",
+		chroma.Date:         "#af5faf",
+		chroma.NumberHex:    "#d75f5f",
+
+		chroma.Name:          "#5f87af",
+		chroma.NameFunction:  "#d75f87",
+		chroma.NameNamespace: "#8a8a8a",
+
+		chroma.GenericHeading:    "#5f87af",
+		chroma.GenericSubheading: "#5f87af",
+		chroma.GenericEmph:       "italic #d75f5f",
+		chroma.GenericStrong:     "bold #af5faf",
+		chroma.GenericDeleted:    "#d75f87",
+		chroma.GenericInserted:   "#87af5f",
+		chroma.NameAttribute:     "underline",
+
+		IndentLevel1: "#d7875f",
+		IndentLevel2: "#af5faf",
+		IndentLevel3: "#5f87af",
+	}))
+}
+
+// themeFiles caches styles loaded from theme files by path.
+var themeFiles sync.Map
+
+// loadTheme loads a theme file mapping chroma token types like `String` or
+// `NameTag` to style entries like `bold #ff5f87`. Token types which are not
+// set use the `dark` theme.
+func loadTheme(filename string) (*chroma.Style, error) {
+	if style, ok := themeFiles.Load(filename); ok {
+		return style.(*chroma.Style), nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load theme: %w", err)
+	}
+
+	entries := map[string]string{}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("unable to parse theme %s: %w", filename, err)
+	}
+
+	// Chroma has no lookup by name, so build one from the standard types plus
+	// the indent levels used for matching brackets and the short aliases
+	// used by the built-in themes.
+	types := map[string]chroma.TokenType{
+		"IndentLevel1": IndentLevel1,
+		"IndentLevel2": IndentLevel2,
+		"IndentLevel3": IndentLevel3,
+		"String":       chroma.String,
+		"StringSymbol": chroma.StringSymbol,
+		"Number":       chroma.Number,
+		"NumberHex":    chroma.NumberHex,
+		"Date":         chroma.Date,
+	}
+	for t := range chroma.StandardTypes {
+		types[t.String()] = t
+	}
+
+	builder := styles.Get(themeStyles[themeDark]).Builder()
+	for name, entry := range entries {
+		t, ok := types[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown token type %s in theme %s", name, filename)
+		}
+		builder.Add(t, entry)
+	}
+
+	style, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %w", filename, err)
+	}
+
+	themeFiles.Store(filename, style)
+	return style, nil
+}
+
+// themeStyle returns the chroma style for the selected theme, or nil if
+// highlighting is disabled via the `none` theme.
+func themeStyle() (*chroma.Style, error) {
+	theme := viper.GetString("rsh-theme")
+	if theme == "" {
+		theme = themeDark
+	}
+
+	if theme == themeNone {
+		return nil, nil
+	}
+
+	if name, ok := themeStyles[theme]; ok {
+		return styles.Get(name), nil
+	}
+
+	return loadTheme(theme)
+}
+
+// markdownStyle returns the style for rendering Markdown help text in the
+// selected theme.
+func markdownStyle() ansi.StyleConfig {
+	switch viper.GetString("rsh-theme") {
+	case themeLight:
+		return glamour.LightStyleConfig
+	case themeNone:
+		return glamour.NoTTYStyleConfig
+	}
+	return MarkdownStyle
+}
+
+// highlight writes the source highlighted with the given lexer in the
+// selected theme's colors.
+func highlight(w io.Writer, source, lexer string) error {
+	style, err := themeStyle()
+	if err != nil {
+		return err
+	}
+
+	if style == nil {
+		_, err := io.WriteString(w, source)
+		return err
+	}
+
+	l := lexers.Get(lexer)
+	if l == nil {
+		l = lexers.Analyse(source)
+	}
+	if l == nil {
+		l = lexers.Fallback
+	}
+
+	iterator, err := chroma.Coalesce(l).Tokenise(nil, source)
+	if err != nil {
+		return err
+	}
+
+	return formatters.Get("terminal256").Format(w, style, iterator)
+}
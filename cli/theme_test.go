@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemes(t *testing.T) {
+	defer viper.Set("rsh-theme", "")
+
+	data := []byte(`{"hello": "world"}`)
+
+	viper.Set("rsh-theme", "")
+	dark, err := Highlight("json", data)
+	require.NoError(t, err)
+	assert.Contains(t, string(dark), "\x1b[38;5;150m\"world\"")
+
+	viper.Set("rsh-theme", "light")
+	light, err := Highlight("json", data)
+	require.NoError(t, err)
+	assert.NotEqual(t, dark, light)
+	assert.Equal(t, glamour.LightStyleConfig, markdownStyle())
+
+	viper.Set("rsh-theme", "256")
+	mid, err := Highlight("json", data)
+	require.NoError(t, err)
+	assert.NotEqual(t, dark, mid)
+
+	viper.Set("rsh-theme", "none")
+	plain, err := Highlight("json", data)
+	require.NoError(t, err)
+	assert.Equal(t, data, plain)
+}
+
+func TestThemeFile(t *testing.T) {
+	defer viper.Set("rsh-theme", "")
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "theme.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("String: \"bold #d70000\"\n"), 0600))
+
+	// Unset token types fall back to the dark theme.
+	viper.Set("rsh-theme", filename)
+	out, err := Highlight("json", []byte(`{"hello": "world"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "\x1b[1m\x1b[38;5;160m\"world\"")
+	assert.Contains(t, string(out), "\x1b[38;5;74m\"hello\"")
+
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"Bogus": "#fff"}`), 0600))
+	viper.Set("rsh-theme", bad)
+	_, err = Highlight("json", []byte(`{}`))
+	assert.ErrorContains(t, err, "unknown token type Bogus")
+
+	viper.Set("rsh-theme", filepath.Join(dir, "missing.yaml"))
+	_, err = Highlight("json", []byte(`{}`))
+	assert.ErrorContains(t, err, "unable to load theme")
+}
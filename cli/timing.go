@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing describes where the time went when making a request, see
+// `--rsh-timing`. Durations are in milliseconds. Steps which didn't happen,
+// e.g. DNS lookups for a reused connection, are zero.
+type Timing struct {
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	TLS     float64 `json:"tls"`
+	TTFB    float64 `json:"ttfb"`
+	Total   float64 `json:"total"`
+	Bytes   int64   `json:"bytes"`
+	Reused  bool    `json:"reused"`
+}
+
+// Map returns a map representing the timing matching the encoded JSON.
+func (t *Timing) Map() map[string]any {
+	return map[string]any{
+		"dns":     t.DNS,
+		"connect": t.Connect,
+		"tls":     t.TLS,
+		"ttfb":    t.TTFB,
+		"total":   t.Total,
+		"bytes":   t.Bytes,
+		"reused":  t.Reused,
+	}
+}
+
+// timingContextKey holds the `*requestTiming` for a request.
+type timingContextKey struct{}
+
+// requestTiming records timestamps while a request is made. Trace hooks may
+// be called from other goroutines, e.g. while racing connections.
+type requestTiming struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	end          time.Time
+	reused       bool
+	bytes        int64
+}
+
+// withTiming returns a context which records the timing of requests made
+// with it.
+func withTiming(ctx context.Context) context.Context {
+	t := &requestTiming{}
+
+	// Only the first connection attempt and the last completion are kept, so
+	// parallel dials count as a single step.
+	first := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+	last := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*field = time.Now()
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { first(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { last(&t.dnsDone) },
+		ConnectStart: func(string, string) { first(&t.connectStart) },
+		ConnectDone:  func(string, string, error) { last(&t.connectDone) },
+		TLSHandshakeStart: func() {
+			first(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			last(&t.tlsDone)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+		GotFirstResponseByte: func() { first(&t.firstByte) },
+	})
+
+	return context.WithValue(ctx, timingContextKey{}, t)
+}
+
+// timingFrom returns the timing recorder for a request context, if any.
+func timingFrom(ctx context.Context) *requestTiming {
+	t, _ := ctx.Value(timingContextKey{}).(*requestTiming)
+	return t
+}
+
+// begin starts timing a new attempt, discarding any previous one.
+func (t *requestTiming) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = time.Now()
+	t.dnsStart, t.dnsDone = time.Time{}, time.Time{}
+	t.connectStart, t.connectDone = time.Time{}, time.Time{}
+	t.tlsStart, t.tlsDone = time.Time{}, time.Time{}
+	t.firstByte, t.end = time.Time{}, time.Time{}
+	t.reused = false
+	t.bytes = 0
+}
+
+// finish marks the response as fully received, if not already done.
+func (t *requestTiming) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.end.IsZero() {
+		t.end = time.Now()
+	}
+}
+
+// body wraps a response body to count the bytes received and note when the
+// last one arrives.
+func (t *requestTiming) body(rc io.ReadCloser) io.ReadCloser {
+	return &timingBody{ReadCloser: rc, timing: t}
+}
+
+// result computes the durations of each step.
+func (t *requestTiming) result() *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ms := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return float64(to.Sub(from).Microseconds()) / 1000
+	}
+
+	return &Timing{
+		DNS:     ms(t.dnsStart, t.dnsDone),
+		Connect: ms(t.connectStart, t.connectDone),
+		TLS:     ms(t.tlsStart, t.tlsDone),
+		TTFB:    ms(t.start, t.firstByte),
+		Total:   ms(t.start, t.end),
+		Bytes:   t.bytes,
+		Reused:  t.reused,
+	}
+}
+
+// timingBody counts the bytes read from a response body.
+type timingBody struct {
+	io.ReadCloser
+	timing *requestTiming
+}
+
+func (b *timingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.timing.mu.Lock()
+	b.timing.bytes += int64(n)
+	b.timing.mu.Unlock()
+
+	if err == io.EOF {
+		b.timing.finish()
+	}
+	return n, err
+}
+
+// formatTiming describes the timing on a single line.
+func formatTiming(t *Timing) string {
+	d := func(ms float64) time.Duration {
+		return time.Duration(ms * float64(time.Millisecond)).Round(10 * time.Microsecond)
+	}
+
+	reused := ""
+	if t.Reused {
+		reused = " (reused connection)"
+	}
+
+	return fmt.Sprintf("DNS %s, connect %s, TLS %s, TTFB %s, total %s, %d bytes received%s", d(t.DNS), d(t.Connect), d(t.TLS), d(t.TTFB), d(t.Total), t.Bytes, reused)
+}
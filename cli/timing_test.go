@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTiming(t *testing.T) {
+	defer reset(false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hello": "world"}`))
+	}))
+	defer server.Close()
+
+	out := run("--rsh-timing -o json -f timing " + server.URL)
+
+	// The breakdown is logged, followed by the filtered timing data.
+	assert.Regexp(t, `INFO: Timing: DNS 0s, connect [0-9.]+[µm]?s, TLS 0s, TTFB [0-9.]+[µm]?s, total [0-9.]+[µm]?s, 18 bytes received\n`, out)
+
+	timing := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(out[strings.Index(out, "{"):]), &timing))
+	assert.Equal(t, 0.0, timing["dns"])
+	assert.Greater(t, timing["connect"], 0.0)
+	assert.Equal(t, 0.0, timing["tls"])
+	assert.Greater(t, timing["ttfb"], 0.0)
+	assert.GreaterOrEqual(t, timing["total"], timing["ttfb"])
+	assert.Equal(t, 18.0, timing["bytes"])
+	assert.Equal(t, false, timing["reused"])
+
+	// Timing is only recorded when asked for.
+	out = run("-o json -f timing " + server.URL)
+	assert.NotContains(t, out, "Timing:")
+	assert.NotContains(t, out, "ttfb")
+}
+
+func TestFormatTiming(t *testing.T) {
+	assert.Equal(t, "DNS 1.5ms, connect 2ms, TLS 10.25ms, TTFB 120ms, total 1.2s, 1024 bytes received (reused connection)", formatTiming(&Timing{
+		DNS:     1.5,
+		Connect: 2,
+		TLS:     10.25,
+		TTFB:    120,
+		Total:   1200,
+		Bytes:   1024,
+		Reused:  true,
+	}))
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Guides used to draw the tree outline. Each is four runes wide so the
+// lexer can determine the nesting level from the column.
+const (
+	treeBranch = "├── "
+	treeLast   = "└── "
+	treePipe   = "│   "
+	treeSpace  = "    "
+)
+
+// Tree describes an outline output format for exploring nested structures,
+// similar to the `tree` command.
+type Tree struct{}
+
+// Detect if the content type is tree.
+func (t Tree) Detect(contentType string) bool {
+	return false
+}
+
+// Marshal the value to a tree outline string.
+func (t Tree) Marshal(value interface{}) ([]byte, error) {
+	return MarshalTree(value)
+}
+
+// Unmarshal the value from a tree outline string.
+func (t Tree) Unmarshal(data []byte, value interface{}) error {
+	return fmt.Errorf("unimplemented")
+}
+
+// MarshalTree marshals a value into a tree outline. Objects and arrays below
+// the `rsh-max-depth` level are collapsed into a summary of their size.
+func MarshalTree(v interface{}) ([]byte, error) {
+	v = makeJSONSafe(v)
+
+	sb := &strings.Builder{}
+	if _, ok := treeChildren(v); ok {
+		sb.WriteString(treeSummary(v))
+	} else {
+		b, err := MarshalReadable(v)
+		if err != nil {
+			return nil, err
+		}
+		sb.Write(b)
+	}
+	sb.WriteString("\n")
+
+	if err := marshalTree(sb, "", v, 1, viper.GetInt("rsh-max-depth")); err != nil {
+		return nil, err
+	}
+
+	return []byte(sb.String()), nil
+}
+
+// treeNode is a single labelled child of an object or array.
+type treeNode struct {
+	label string
+	value any
+}
+
+// treeChildren returns the children of objects and arrays. The second return
+// value is false for scalars.
+func treeChildren(v any) ([]treeNode, bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		nodes := make([]treeNode, 0, len(keys))
+		for _, k := range keys {
+			nodes = append(nodes, treeNode{label: treeKey(k), value: t[k]})
+		}
+		return nodes, true
+	case []any:
+		nodes := make([]treeNode, 0, len(t))
+		for i, item := range t {
+			nodes = append(nodes, treeNode{label: fmt.Sprintf("[%d]", i), value: item})
+		}
+		return nodes, true
+	}
+
+	return nil, false
+}
+
+// treeKey quotes object keys which would otherwise be ambiguous in the output.
+func treeKey(k string) string {
+	if k == "" || strings.ContainsAny(k, ":{}[]\"\n\r\t") || strings.TrimSpace(k) != k {
+		return strconv.Quote(k)
+	}
+	return k
+}
+
+// treeSummary describes the size of an object or array, e.g. `{3}` or `[2]`.
+func treeSummary(v any) string {
+	switch t := reflect.ValueOf(v); t.Kind() {
+	case reflect.Map:
+		if t.Len() == 0 {
+			return "{}"
+		}
+		return fmt.Sprintf("{%d}", t.Len())
+	case reflect.Slice:
+		if t.Len() == 0 {
+			return "[]"
+		}
+		return fmt.Sprintf("[%d]", t.Len())
+	}
+	return ""
+}
+
+func marshalTree(sb *strings.Builder, prefix string, v any, level, maxDepth int) error {
+	nodes, _ := treeChildren(v)
+
+	for i, node := range nodes {
+		branch, next := treeBranch, treePipe
+		if i == len(nodes)-1 {
+			branch, next = treeLast, treeSpace
+		}
+
+		sb.WriteString(prefix + branch + node.label)
+
+		if grandchildren, ok := treeChildren(node.value); ok {
+			sb.WriteString(" " + treeSummary(node.value))
+			if maxDepth > 0 && level >= maxDepth && len(grandchildren) > 0 {
+				// Collapse anything past the max depth.
+				sb.WriteString(" …\n")
+				continue
+			}
+			sb.WriteString("\n")
+			if err := marshalTree(sb, prefix+next, node.value, level+1, maxDepth); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if str, ok := node.value.(string); ok {
+			// Multi-line strings would break the outline, so keep them on one line.
+			str = strings.ReplaceAll(str, `"`, `\"`)
+			str = strings.ReplaceAll(strings.TrimRight(str, "\n"), "\n", `\n`)
+			sb.WriteString(`: "` + str + "\"\n")
+			continue
+		}
+
+		b, err := MarshalReadable(node.value)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(": " + string(b) + "\n")
+	}
+
+	return nil
+}
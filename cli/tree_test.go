@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+var treeValue = map[string]any{
+	"id":   "abc",
+	"tags": []any{"a", "b"},
+	"owner": map[string]any{
+		"name":  "Multi\nline",
+		"roles": map[string]any{"admin": true},
+	},
+	"key: with colon": nil,
+	"empty":           []any{},
+}
+
+func TestTreeMarshal(t *testing.T) {
+	b, err := Tree{}.Marshal(treeValue)
+	assert.NoError(t, err)
+	assert.Equal(t, `{5}
+├── empty []
+├── id: "abc"
+├── "key: with colon": null
+├── owner {2}
+│   ├── name: "Multi\nline"
+│   └── roles {1}
+│       └── admin: true
+└── tags [2]
+    ├── [0]: "a"
+    └── [1]: "b"
+`, string(b))
+}
+
+func TestTreeMaxDepth(t *testing.T) {
+	viper.Set("rsh-max-depth", 1)
+	defer viper.Set("rsh-max-depth", 0)
+
+	b, err := MarshalTree(treeValue)
+	assert.NoError(t, err)
+	assert.Equal(t, `{5}
+├── empty []
+├── id: "abc"
+├── "key: with colon": null
+├── owner {2} …
+└── tags [2] …
+`, string(b))
+}
+
+func TestTreeScalar(t *testing.T) {
+	b, err := MarshalTree(123)
+	assert.NoError(t, err)
+	assert.Equal(t, "123\n", string(b))
+}
+
+func TestTreeHighlight(t *testing.T) {
+	b, err := MarshalTree(treeValue)
+	assert.NoError(t, err)
+
+	highlighted, err := Highlight("tree", b)
+	assert.NoError(t, err)
+	assert.Contains(t, string(highlighted), "\x1b[38;5;74mowner\x1b[0m")
+}
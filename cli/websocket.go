@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+)
+
+// dialWebSocket upgrades the set up request to a WebSocket connection, using
+// the same TLS, proxy, and cookie settings as normal requests. A handshake
+// the server refuses returns its response and `websocket.ErrBadHandshake`.
+func dialWebSocket(client *http.Client, req *http.Request) (*websocket.Conn, *http.Response, error) {
+	u := *req.URL
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+
+	dialer := websocket.Dialer{
+		Proxy: func(*http.Request) (*url.URL, error) {
+			return requestProxy(req)
+		},
+		Jar:              client.Jar,
+		HandshakeTimeout: viper.GetDuration("rsh-timeout"),
+	}
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		dialer.TLSClientConfig = t.TLSClientConfig.Clone()
+
+		// The upgrade requires HTTP/1.1, so don't offer HTTP/2.
+		dialer.TLSClientConfig.NextProtos = nil
+	}
+
+	LogDebugRequest(req)
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(req.Context(), u.String(), req.Header)
+	if resp != nil {
+		LogDebugResponse(start, resp)
+	}
+
+	return conn, resp, err
+}
+
+// connectWebSocket opens a WebSocket connection and prints each received
+// message as it arrives, while sending messages read from stdin. Text messages
+// which are valid JSON are decoded so they can be filtered. With `binary`,
+// stdin is sent as a single binary message and received messages are written
+// as-is. Runs until the server closes the connection or the user hits Ctrl-C.
+func connectWebSocket(addr string, binary bool) error {
+	if strings.HasPrefix(addr, "ws://") || strings.HasPrefix(addr, "wss://") {
+		// Resolve like any other request so API config & auth apply.
+		addr = "http" + strings.TrimPrefix(addr, "ws")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, fixAddress(addr), nil)
+
+	ctx, cancel := withInterrupt(req.Context())
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	var conn *websocket.Conn
+	resp, err := MakeRequest(req, withSender(func(client *http.Client, req *http.Request) (*http.Response, error) {
+		c, resp, err := dialWebSocket(client, req)
+		if err != nil && err != websocket.ErrBadHandshake {
+			return nil, err
+		}
+		conn = c
+		return resp, nil
+	}))
+	if err != nil {
+		if err == errRequestNotSent {
+			return nil
+		}
+		return interruptError(err)
+	}
+
+	if conn == nil {
+		// The server refused the upgrade, so show its response like any other.
+		parsed, err := ParseResponse(resp)
+		if err != nil {
+			return err
+		}
+		return Formatter.Format(parsed)
+	}
+	defer conn.Close()
+
+	output, err := wrapResponse(resp, nil)
+	if err != nil {
+		return err
+	}
+
+	if !binary {
+		// Raw bytes are written as-is, without the status & headers.
+		if err := startStream(output); err != nil {
+			return err
+		}
+	}
+
+	// Only one message may be written at a time.
+	writeMu := sync.Mutex{}
+	write := func(messageType int, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteMessage(messageType, data)
+	}
+
+	go sendInput(Stdin, binary, write)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Let the server know we're going away, then stop reading.
+			write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if isInterrupted() || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+		}
+
+		if binary {
+			Stdout.Write(data)
+			continue
+		}
+
+		if messageType == websocket.BinaryMessage {
+			LogWarning("Ignoring %d byte binary message, use --binary to print it", len(data))
+			continue
+		}
+
+		output.Body = eventData(string(data))
+		if err := Formatter.Format(output); err != nil {
+			return err
+		}
+	}
+}
+
+// sendInput sends each non-empty line from the input as a text message, or
+// all of it as a single binary message.
+func sendInput(input io.Reader, binary bool, write func(messageType int, data []byte) error) {
+	if binary {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			LogWarning("Unable to read stdin: %v", err)
+			return
+		}
+		if len(data) > 0 {
+			if err := write(websocket.BinaryMessage, data); err != nil {
+				LogWarning("Unable to send message: %v", err)
+			}
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventLine)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := write(websocket.TextMessage, scanner.Bytes()); err != nil {
+			LogWarning("Unable to send message: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+var upgrader = websocket.Upgrader{}
+
+func TestWebSocket(t *testing.T) {
+	defer reset(false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "abc123" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"detail": "missing auth"}`))
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"hello": "world"}`))
+		conn.WriteMessage(websocket.BinaryMessage, []byte{0, 1, 2})
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "bye" {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, data)
+		}
+	}))
+	defer server.Close()
+
+	reset(false)
+	configs["ws-test"] = &APIConfig{
+		name: "ws-test",
+		Base: server.URL,
+		Profiles: map[string]*APIProfile{
+			"default": {
+				Headers: map[string]string{"Authorization": "abc123"},
+			},
+		},
+	}
+
+	// Profile headers are sent with the handshake and stdin lines are sent as
+	// messages until the server closes the connection.
+	out := ""
+	WithFakeStdin([]byte("{\"n\": 1}\n\nplain text\nbye\n"), 0, func() {
+		out = runNoReset("-o json ws ws-test/socket")
+	})
+	assert.Contains(t, out, "Ignoring 3 byte binary message")
+	out = regexp.MustCompile(`WARN: .*\n`).ReplaceAllString(out, "")
+	assert.Equal(t, "{\n  \"hello\": \"world\"\n}\n{\n  \"n\": 1\n}\n\"plain text\"\n", out)
+	expectExitCode(t, 0)
+
+	// A refused handshake is shown like any other response.
+	WithFakeStdin([]byte{}, 0, func() {
+		out = run("ws " + strings.Replace(server.URL, "http", "ws", 1) + "/socket")
+	})
+	assert.Contains(t, out, "401 Unauthorized")
+	assert.Contains(t, out, "missing auth")
+	expectExitCode(t, 4)
+}
+
+func TestWebSocketBinary(t *testing.T) {
+	defer reset(false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		messageType, data, err := conn.ReadMessage()
+		if err != nil || messageType != websocket.BinaryMessage {
+			return
+		}
+		conn.WriteMessage(websocket.BinaryMessage, append([]byte{0xff}, data...))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	out := ""
+	WithFakeStdin([]byte{0, 1, 2, '\n', 3}, 0, func() {
+		out = run("ws --binary " + server.URL)
+	})
+	assert.Equal(t, string([]byte{0xff, 0, 1, 2, '\n', 3}), out)
+}
+
+func TestWebSocketInterrupt(t *testing.T) {
+	defer reset(false)
+	reset(false)
+	viper.Set("rsh-output-format", "json")
+
+	closed := make(chan int, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`"first"`))
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if e, ok := err.(*websocket.CloseError); ok {
+				closed <- e.Code
+			}
+		}
+	}))
+	defer server.Close()
+
+	// The connection is closed cleanly when the user hits Ctrl-C.
+	out := &interruptWriter{}
+	Stdout = out
+	WithFakeStdin([]byte{}, 0, func() {
+		assert.NoError(t, connectWebSocket(server.URL, false))
+	})
+	assert.Equal(t, "\"first\"\n", out.buf.String())
+	assert.Equal(t, websocket.CloseNormalClosure, <-closed)
+}
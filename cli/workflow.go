@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/danielgtaylor/shorthand/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// WorkflowStep describes a single request in a workflow. Values captured from
+// earlier responses can be used in the URI, headers, and body via `${name}`.
+type WorkflowStep struct {
+	Name    string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	URI     string            `json:"uri" yaml:"uri"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    any               `json:"body,omitempty" yaml:"body,omitempty"`
+	Capture []string          `json:"capture,omitempty" yaml:"capture,omitempty"`
+}
+
+// Workflow describes a list of steps to run in order, with optional initial
+// variables.
+type Workflow struct {
+	Vars  map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+	Steps []WorkflowStep    `json:"steps" yaml:"steps"`
+}
+
+// readWorkflow loads a workflow from a JSON or YAML file, or from stdin if no
+// filename is given.
+func readWorkflow(filename string) (*Workflow, error) {
+	var data []byte
+	var err error
+	if filename == "" || filename == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is a superset of JSON, so this handles both.
+	var wf Workflow
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, err
+	}
+
+	if len(wf.Steps) == 0 {
+		return nil, errors.New("no workflow steps found")
+	}
+
+	return &wf, nil
+}
+
+// workflowVarRegex matches `${name}` variable references. Other uses of `$`,
+// e.g. in prices, are left as-is.
+var workflowVarRegex = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// expandVars replaces `${name}` references using the workflow variables,
+// falling back to environment variables. Unknown names are an error.
+func expandVars(value string, vars map[string]string) (string, error) {
+	var missing []string
+	result := workflowVarRegex.ReplaceAllStringFunc(value, func(match string) string {
+		name := workflowVarRegex.FindStringSubmatch(match)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		missing = append(missing, name)
+		return ""
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variable %s", strings.Join(missing, ", "))
+	}
+
+	return result, nil
+}
+
+// expandBody expands variables in all string values of a request body.
+func expandBody(value any, vars map[string]string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return expandVars(v, vars)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			expanded, err := expandBody(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = expanded
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := expandBody(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = expanded
+		}
+		return out, nil
+	}
+	return value, nil
+}
+
+// workflowRequest builds the HTTP request for a step.
+func workflowRequest(step WorkflowStep, vars map[string]string) (*http.Request, error) {
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if step.URI == "" {
+		return nil, errors.New("missing uri")
+	}
+
+	uri, err := expandVars(step.URI, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for k, v := range step.Headers {
+		expanded, err := expandVars(v, vars)
+		if err != nil {
+			return nil, err
+		}
+		headers.Set(k, expanded)
+	}
+
+	var body io.Reader
+	if step.Body != nil {
+		expanded, err := expandBody(makeJSONSafe(step.Body), vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := expanded.(string); ok {
+			body = strings.NewReader(s)
+		} else {
+			encoded, err := json.Marshal(expanded)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(encoded)
+			if headers.Get("Content-Type") == "" {
+				headers.Set("Content-Type", "application/json")
+			}
+		}
+	}
+
+	req, err := http.NewRequest(method, fixAddress(uri), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+
+	return req, nil
+}
+
+// capture runs a `name = query` capture against a parsed response and stores
+// the result in the variables. Non-string values are stored as JSON.
+func capture(expr string, parsed Response, vars map[string]string) error {
+	name, query, ok := strings.Cut(expr, "=")
+	name = strings.TrimSpace(name)
+	query = strings.TrimSpace(query)
+	if !ok || name == "" || query == "" {
+		return fmt.Errorf("invalid capture %s, expected e.g. token = body.access_token", expr)
+	}
+
+	result, found, err := shorthand.GetPath(query, makeJSONSafe(parsed.Map()), shorthand.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("capture %s: %s", name, err.Pretty())
+	}
+
+	if !found || result == nil {
+		return fmt.Errorf("capture %s: nothing found for %s", name, query)
+	}
+
+	if s, ok := result.(string); ok {
+		vars[name] = s
+		return nil
+	}
+
+	encoded, jsonErr := json.Marshal(result)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	vars[name] = string(encoded)
+	return nil
+}
+
+// runWorkflow runs each workflow step in order, printing each response and
+// stopping at the first step which fails to send, returns an error status, or
+// cannot capture a value.
+func runWorkflow(filename string) error {
+	wf, err := readWorkflow(filename)
+	if err != nil {
+		return err
+	}
+
+	vars := map[string]string{}
+	for k, v := range wf.Vars {
+		vars[k] = v
+	}
+
+	for i, step := range wf.Steps {
+		label := fmt.Sprintf("step %d", i+1)
+		if step.Name != "" {
+			label += " (" + step.Name + ")"
+		}
+
+		req, err := workflowRequest(step, vars)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		LogInfo("Running %s: %s %s", label, req.Method, req.URL)
+		parsed, err := GetParsedResponse(req)
+		if errors.Is(err, errRequestNotSent) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		formatResponse(parsed)
+
+		if parsed.Status >= 400 {
+			return fmt.Errorf("%s failed with %d %s", label, parsed.Status, http.StatusText(parsed.Status))
+		}
+
+		for _, expr := range step.Capture {
+			if err := capture(expr, parsed, vars); err != nil {
+				return fmt.Errorf("%s: %w", label, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestExpandVars(t *testing.T) {
+	t.Setenv("RSH_TEST_WORKFLOW", "env")
+
+	value, err := expandVars("${a}/${RSH_TEST_WORKFLOW}", map[string]string{"a": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "b/env", value)
+
+	// Only `${name}` is a reference, so a literal `$` is kept.
+	value, err = expandVars("costs $5, or $a {b} $", map[string]string{"a": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "costs $5, or $a {b} $", value)
+
+	_, err = expandVars("${missing}", map[string]string{})
+	assert.ErrorContains(t, err, "undefined variable missing")
+}
+
+func TestCapture(t *testing.T) {
+	parsed := Response{Status: 200, Body: map[string]any{"id": 5, "token": "abc", "tags": []any{"a"}}}
+	vars := map[string]string{}
+
+	assert.NoError(t, capture("token = body.token", parsed, vars))
+	assert.NoError(t, capture("id=body.id", parsed, vars))
+	assert.NoError(t, capture("tags = body.tags", parsed, vars))
+	assert.Equal(t, map[string]string{"token": "abc", "id": "5", "tags": `["a"]`}, vars)
+
+	assert.ErrorContains(t, capture("body.token", parsed, vars), "invalid capture")
+	assert.ErrorContains(t, capture("x = body.missing", parsed, vars), "nothing found")
+}
+
+const testWorkflow = `vars:
+  base: https://workflow.example.com
+steps:
+  - name: login
+    method: post
+    uri: ${base}/login
+    body:
+      user: alice
+    capture:
+      - token = body.access_token
+      - id = body.id
+  - name: create
+    method: PUT
+    uri: ${base}/users/${id}/items/1
+    headers:
+      Authorization: Bearer ${token}
+    body:
+      owner: ${id}
+  - uri: ${base}/never
+`
+
+func TestRunWorkflow(t *testing.T) {
+	defer gock.Off()
+	reset(false)
+
+	gock.New("https://workflow.example.com").
+		Post("/login").
+		MatchType("json").
+		JSON(map[string]any{"user": "alice"}).
+		Reply(200).
+		JSON(map[string]any{"access_token": "abc123", "id": 5})
+
+	gock.New("https://workflow.example.com").
+		Put("/users/5/items/1").
+		MatchHeader("Authorization", "Bearer abc123").
+		JSON(map[string]any{"owner": "5"}).
+		Reply(403).
+		JSON(map[string]any{"detail": "forbidden"})
+
+	filename := filepath.Join(t.TempDir(), "workflow.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(testWorkflow), 0600))
+
+	out := runNoReset("run " + filename)
+	assert.Contains(t, out, "forbidden")
+	assert.Contains(t, out, "step 2 (create) failed with 403 Forbidden")
+	assert.True(t, gock.IsDone())
+}
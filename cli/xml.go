@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// XML describes content types like `application/xml`, `text/xml`, or
+// `application/foo+xml`.
+//
+// Documents are converted to and from generic maps using these conventions:
+//   - The root element is the only key of the top-level object.
+//   - Attributes are keys prefixed with `@`, e.g. `@id`.
+//   - Text in elements with attributes or children goes in `#text`.
+//   - Elements with only text become strings.
+//   - Repeated child elements become arrays.
+//   - Namespace prefixes are kept as part of names, e.g. `atom:link`, and
+//     namespace declarations are kept as `@xmlns` attributes.
+type XML struct{}
+
+// Detect if the content type is XML.
+func (x XML) Detect(contentType string) bool {
+	first := strings.Split(contentType, ";")[0]
+	if first == "application/xml" || first == "text/xml" || strings.HasSuffix(first, "+xml") {
+		return true
+	}
+
+	return false
+}
+
+// xmlName returns a name including its namespace prefix, if any.
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+// xmlElement is an element being decoded.
+type xmlElement struct {
+	name  string
+	value map[string]any
+	text  strings.Builder
+}
+
+// add a child value, turning repeated elements into arrays.
+func (e *xmlElement) add(name string, value any) {
+	switch existing := e.value[name].(type) {
+	case nil:
+		e.value[name] = value
+	case []any:
+		e.value[name] = append(existing, value)
+	default:
+		e.value[name] = []any{existing, value}
+	}
+}
+
+// result returns the decoded value for the element.
+func (e *xmlElement) result() any {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.value) == 0 {
+		return text
+	}
+
+	if text != "" {
+		e.value["#text"] = text
+	}
+	return e.value
+}
+
+// Unmarshal the value from encoded XML.
+func (x XML) Unmarshal(data []byte, value interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var stack []*xmlElement
+	var root map[string]any
+
+	for {
+		// Raw tokens keep namespace prefixes rather than resolving them to URLs.
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &xmlElement{name: xmlName(t.Name), value: map[string]any{}}
+			for _, attr := range t.Attr {
+				el.value["@"+xmlName(attr.Name)] = attr.Value
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return fmt.Errorf("unexpected end element %s", xmlName(t.Name))
+			}
+			el := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if len(stack) == 0 {
+				root = map[string]any{el.name: el.result()}
+			} else {
+				stack[len(stack)-1].add(el.name, el.result())
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return fmt.Errorf("no XML root element found")
+	}
+
+	if v, ok := value.(*any); ok {
+		*v = root
+		return nil
+	}
+
+	return fmt.Errorf("cannot unmarshal XML into %T", value)
+}
+
+// xmlString converts a scalar value to its text representation.
+func xmlString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// encodeXML writes an element and its children.
+func encodeXML(enc *xml.Encoder, name string, value any) error {
+	if list, ok := value.([]any); ok {
+		// Arrays are repeated elements with the same name.
+		for _, item := range list {
+			if err := encodeXML(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(xml.CharData(xmlString(value))); err != nil {
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if attr, ok := strings.CutPrefix(k, "@"); ok {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: xmlString(m[k])})
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if text, ok := m["#text"]; ok {
+		if err := enc.EncodeToken(xml.CharData(xmlString(text))); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		if strings.HasPrefix(k, "@") || k == "#text" {
+			continue
+		}
+		if err := encodeXML(enc, k, m[k]); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// marshalXML encodes a value as an XML document with optional indentation.
+func marshalXML(value any, indent string) ([]byte, error) {
+	value = makeJSONSafe(value)
+
+	// Use the single top-level key as the root element if possible, otherwise
+	// wrap the value in a generic root element.
+	name := "root"
+	if m, ok := value.(map[string]any); ok && len(m) == 1 {
+		for k, v := range m {
+			if _, isList := v.([]any); !isList && !strings.HasPrefix(k, "@") && k != "#text" {
+				name = k
+				value = v
+			}
+		}
+	} else if list, ok := value.([]any); ok {
+		value = map[string]any{"item": list}
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", indent)
+	if err := encodeXML(enc, name, value); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// Marshal the value to encoded XML.
+func (x XML) Marshal(value interface{}) ([]byte, error) {
+	return marshalXML(value, "")
+}
+
+// MarshalPretty the value to indented XML.
+func (x XML) MarshalPretty(value interface{}) ([]byte, error) {
+	return marshalXML(value, "  ")
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const xmlFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <title type="text">Tom &amp; Jerry</title>
+  <entry id="1">
+    <name>a</name>
+    <media:thumbnail url="https://example.com/a.png"/>
+  </entry>
+  <entry id="2">
+    <name>b</name>
+  </entry>
+</feed>`
+
+func TestXMLDetect(t *testing.T) {
+	for _, ct := range []string{"application/xml", "text/xml; charset=utf-8", "application/atom+xml"} {
+		assert.True(t, XML{}.Detect(ct), ct)
+	}
+	assert.False(t, XML{}.Detect("application/json"))
+}
+
+func TestXMLUnmarshal(t *testing.T) {
+	var data any
+	assert.NoError(t, XML{}.Unmarshal([]byte(xmlFeed), &data))
+
+	assert.Equal(t, map[string]any{
+		"feed": map[string]any{
+			"@xmlns":       "http://www.w3.org/2005/Atom",
+			"@xmlns:media": "http://search.yahoo.com/mrss/",
+			"title": map[string]any{
+				"@type": "text",
+				"#text": "Tom & Jerry",
+			},
+			"entry": []any{
+				map[string]any{
+					"@id":  "1",
+					"name": "a",
+					"media:thumbnail": map[string]any{
+						"@url": "https://example.com/a.png",
+					},
+				},
+				map[string]any{
+					"@id":  "2",
+					"name": "b",
+				},
+			},
+		},
+	}, data)
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	var data any
+	assert.NoError(t, XML{}.Unmarshal([]byte(xmlFeed), &data))
+
+	encoded, err := XML{}.Marshal(data)
+	assert.NoError(t, err)
+
+	var decoded any
+	assert.NoError(t, XML{}.Unmarshal(encoded, &decoded))
+	assert.Equal(t, data, decoded)
+}
+
+func TestXMLMarshalWrapsRoot(t *testing.T) {
+	encoded, err := XML{}.MarshalPretty([]any{1, map[string]any{"name": "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<root>
+  <item>1</item>
+  <item>
+    <name>b</name>
+  </item>
+</root>
+`, string(encoded))
+}
+
+func TestXMLInvalid(t *testing.T) {
+	var data any
+	assert.Error(t, XML{}.Unmarshal([]byte("not xml"), &data))
+}
+
+func TestXMLResponseFilter(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/feed").
+		Reply(http.StatusOK).
+		SetHeader("Content-Type", "application/atom+xml").
+		BodyString(xmlFeed)
+
+	captured := run("http://example.com/feed -f body.feed.entry[].name")
+	assert.JSONEq(t, `["a", "b"]`, captured)
+}
+
+func TestXMLTextContentType(t *testing.T) {
+	reset(false)
+
+	// Both XML and plain text accept `text/xml`, XML must always win.
+	for i := 0; i < 50; i++ {
+		var value any
+		assert.NoError(t, Unmarshal("text/xml; charset=utf-8", []byte(`<a><b>1</b></a>`), &value))
+		assert.Equal(t, map[string]any{"a": map[string]any{"b": "1"}}, value)
+	}
+
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/feed").
+		Reply(http.StatusOK).
+		SetHeader("Content-Type", "text/xml").
+		BodyString(xmlFeed)
+
+	captured := run("http://example.com/feed -f body.feed.entry[].name")
+	assert.JSONEq(t, `["a", "b"]`, captured)
+}
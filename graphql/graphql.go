@@ -0,0 +1,428 @@
+// Package graphql provides a Restish loader for GraphQL APIs, turning each
+// top-level query and mutation field into a CLI operation via introspection.
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/casing"
+	"github.com/danielgtaylor/restish/cli"
+)
+
+// introspectionQuery fetches the parts of the schema needed to generate
+// operations with typed arguments and a default selection set.
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind
+      name
+      description
+      fields(includeDeprecated: true) {
+        name
+        description
+        isDeprecated
+        deprecationReason
+        args { name description defaultValue type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+      enumValues(includeDeprecated: false) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// maxDepth limits how deep the generated selection sets go into nested
+// objects.
+const maxDepth = 2
+
+// typeRef is a reference to a named type, possibly wrapped in lists and
+// non-null modifiers.
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String returns the type in GraphQL syntax, e.g. `[ID!]!`.
+func (t *typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// named returns the innermost named type.
+func (t *typeRef) named() *typeRef {
+	for t.OfType != nil {
+		t = t.OfType
+	}
+	return t
+}
+
+type inputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	DefaultValue *string `json:"defaultValue"`
+	Type         typeRef `json:"type"`
+}
+
+type field struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason"`
+	Args              []inputValue `json:"args"`
+	Type              typeRef      `json:"type"`
+}
+
+type fullType struct {
+	Kind        string  `json:"kind"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Fields      []field `json:"fields"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+type schema struct {
+	QueryType    *struct{ Name string } `json:"queryType"`
+	MutationType *struct{ Name string } `json:"mutationType"`
+	Types        []fullType             `json:"types"`
+}
+
+// document wraps an introspected schema so types can be looked up by name.
+type document struct {
+	schema schema
+	types  map[string]*fullType
+}
+
+// scalarTypes maps built-in GraphQL scalars to Restish param types.
+var scalarTypes = map[string]string{
+	"Int":     "integer",
+	"Float":   "number",
+	"Boolean": "boolean",
+	"String":  "string",
+	"ID":      "string",
+}
+
+// paramType returns the Restish param type for an argument, or an empty
+// string if it can't be passed as an option and must be part of the body,
+// like input objects.
+func (d *document) paramType(t *typeRef) string {
+	if t.Kind == "NON_NULL" {
+		t = t.OfType
+	}
+
+	array := false
+	if t.Kind == "LIST" {
+		array = true
+		t = t.OfType
+		if t.Kind == "NON_NULL" {
+			t = t.OfType
+		}
+	}
+
+	typ := ""
+	switch t.Kind {
+	case "SCALAR":
+		typ = scalarTypes[t.Name]
+		if typ == "" {
+			// Custom scalars like `DateTime` are usually strings.
+			typ = "string"
+		}
+	case "ENUM":
+		typ = "string"
+	}
+
+	if typ == "" {
+		return ""
+	}
+
+	if array {
+		if typ == "number" {
+			// Float slices are not supported as options.
+			return ""
+		}
+		return "array[" + typ + "]"
+	}
+
+	return typ
+}
+
+// selection returns the default selection set for a type, which includes its
+// scalar fields and those of nested objects up to `maxDepth`. Fields which
+// require arguments are skipped.
+func (d *document) selection(t *typeRef, depth int) string {
+	named := d.types[t.named().Name]
+	if named == nil {
+		return ""
+	}
+
+	switch named.Kind {
+	case "OBJECT", "INTERFACE":
+	case "UNION":
+		return "{ __typename }"
+	default:
+		return ""
+	}
+
+	fields := []string{}
+	for _, f := range named.Fields {
+		if f.IsDeprecated || requiresArgs(f) {
+			continue
+		}
+
+		sub := d.types[f.Type.named().Name]
+		if sub == nil {
+			continue
+		}
+
+		switch sub.Kind {
+		case "SCALAR", "ENUM":
+			fields = append(fields, f.Name)
+		default:
+			if depth < maxDepth {
+				if s := d.selection(&f.Type, depth+1); s != "" && s != "{ __typename }" {
+					fields = append(fields, f.Name+" "+s)
+				}
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		fields = append(fields, "__typename")
+	}
+
+	return "{ " + strings.Join(fields, " ") + " }"
+}
+
+// requiresArgs returns whether a field has any non-null arguments without a
+// default value.
+func requiresArgs(f field) bool {
+	for _, a := range f.Args {
+		if a.Type.Kind == "NON_NULL" && a.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// operation creates a CLI operation for a top-level query or mutation field.
+func (d *document) operation(endpoint string, kind string, f field) cli.Operation {
+	vars := []string{}
+	args := []string{}
+	params := []*cli.Param{}
+	bodyArgs := []string{}
+
+	for _, a := range f.Args {
+		vars = append(vars, fmt.Sprintf("$%s: %s", a.Name, a.Type.String()))
+		args = append(args, fmt.Sprintf("%s: $%s", a.Name, a.Name))
+
+		typ := d.paramType(&a.Type)
+		if typ == "" {
+			bodyArgs = append(bodyArgs, fmt.Sprintf("  %s: %s", a.Name, a.Type.String()))
+			continue
+		}
+
+		p := &cli.Param{
+			Type:        typ,
+			Name:        a.Name,
+			Description: a.Description,
+			Required:    a.Type.Kind == "NON_NULL",
+		}
+		if named := d.types[a.Type.named().Name]; named != nil && named.Kind == "ENUM" {
+			for _, v := range named.EnumValues {
+				p.Enum = append(p.Enum, v.Name)
+			}
+		}
+		params = append(params, p)
+	}
+
+	query := kind
+	if len(vars) > 0 {
+		query += "(" + strings.Join(vars, ", ") + ")"
+	}
+	query += " { " + f.Name
+	if len(args) > 0 {
+		query += "(" + strings.Join(args, ", ") + ")"
+	}
+	if s := d.selection(&f.Type, 1); s != "" {
+		query += " " + s
+	}
+	query += " }"
+
+	short := strings.TrimSpace(strings.Split(f.Description, "\n")[0])
+	if short == "" {
+		short = fmt.Sprintf("%s %s", strings.ToUpper(kind[:1])+kind[1:], f.Name)
+	}
+
+	long := f.Description
+	if len(bodyArgs) > 0 {
+		long += "\n\n## Input Variables\n\n```\n" + strings.Join(bodyArgs, "\n") + "\n```\n"
+	}
+	long += "\n\n## Query\n\n```graphql\n" + query + "\n```\n"
+
+	op := cli.Operation{
+		Name:        casing.Kebab(f.Name),
+		Group:       kind,
+		Short:       short,
+		Long:        strings.TrimSpace(long) + "\n",
+		Method:      http.MethodPost,
+		URITemplate: endpoint,
+		QueryParams: params,
+		GraphQL:     query,
+	}
+
+	if len(bodyArgs) > 0 {
+		op.BodyMediaType = "application/json"
+	}
+
+	if f.IsDeprecated {
+		op.Deprecated = f.DeprecationReason
+		if op.Deprecated == "" {
+			op.Deprecated = "deprecated"
+		}
+	}
+
+	return op
+}
+
+// operations creates operations for each field of a root type.
+func (d *document) operations(endpoint, kind, typeName string) []cli.Operation {
+	t := d.types[typeName]
+	if t == nil {
+		return nil
+	}
+
+	ops := []cli.Operation{}
+	for _, f := range t.Fields {
+		ops = append(ops, d.operation(endpoint, kind, f))
+	}
+	return ops
+}
+
+// introspect fetches the schema of a GraphQL endpoint.
+func introspect(endpoint string) (schema, error) {
+	req, err := cli.GraphQLRequest(endpoint, introspectionQuery, nil)
+	if err != nil {
+		return schema{}, err
+	}
+
+	resp, err := cli.MakeRequest(req, cli.IgnoreCLIParams())
+	if err != nil {
+		return schema{}, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			Schema *schema `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return schema{}, fmt.Errorf("could not decode GraphQL introspection: %w", err)
+	}
+
+	if result.Data.Schema == nil {
+		if len(result.Errors) > 0 {
+			return schema{}, fmt.Errorf("GraphQL introspection failed: %s", result.Errors[0].Message)
+		}
+		return schema{}, fmt.Errorf("GraphQL introspection failed: missing schema")
+	}
+
+	return *result.Data.Schema, nil
+}
+
+func loadGraphQL(endpoint string) (cli.API, error) {
+	s, err := introspect(endpoint)
+	if err != nil {
+		return cli.API{}, err
+	}
+
+	d := &document{schema: s, types: map[string]*fullType{}}
+	for i := range s.Types {
+		d.types[s.Types[i].Name] = &s.Types[i]
+	}
+
+	operations := []cli.Operation{}
+	if s.QueryType != nil {
+		operations = append(operations, d.operations(endpoint, "query", s.QueryType.Name)...)
+	}
+	if s.MutationType != nil {
+		operations = append(operations, d.operations(endpoint, "mutation", s.MutationType.Name)...)
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		return operations[i].Name < operations[j].Name
+	})
+
+	short := ""
+	if s.QueryType != nil && d.types[s.QueryType.Name] != nil {
+		short = d.types[s.QueryType.Name].Description
+	}
+
+	return cli.API{
+		Short:      short,
+		Operations: operations,
+	}, nil
+}
+
+type loader struct{}
+
+// LocationHints returns the conventional GraphQL endpoint location.
+func (l *loader) LocationHints() []string {
+	return []string{"/graphql"}
+}
+
+func (l *loader) Detect(resp *http.Response) bool {
+	if strings.HasPrefix(resp.Header.Get("content-type"), "application/graphql-response+json") {
+		return true
+	}
+
+	// Endpoints at the hint location respond to a request without a query with
+	// a GraphQL result containing `errors`.
+	if resp.Request == nil || !strings.HasSuffix(resp.Request.URL.Path, "/graphql") {
+		return false
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+
+	var result map[string]any
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false
+	}
+
+	_, hasErrors := result["errors"]
+	_, hasData := result["data"]
+	return hasErrors || hasData
+}
+
+func (l *loader) Load(entrypoint, spec url.URL, resp *http.Response) (cli.API, error) {
+	return loadGraphQL(spec.String())
+}
+
+// New creates a new GraphQL loader.
+func New() cli.Loader {
+	return &loader{}
+}
@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/restish/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectViaHeader(t *testing.T) {
+	resp := http.Response{
+		Header: http.Header{},
+	}
+	resp.Header.Set("Content-Type", "application/graphql-response+json")
+
+	assert.True(t, New().Detect(&resp))
+}
+
+func TestDetectViaHint(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/graphql", nil)
+	resp := http.Response{
+		Header:  http.Header{},
+		Request: req,
+		Body:    io.NopCloser(strings.NewReader(`{"errors": [{"message": "Must provide query string."}]}`)),
+	}
+
+	assert.True(t, New().Detect(&resp))
+
+	// Other documents at the same location are not GraphQL.
+	resp.Body = io.NopCloser(strings.NewReader(`<html>Not found</html>`))
+	assert.False(t, New().Detect(&resp))
+}
+
+func TestDetectOpenAPI(t *testing.T) {
+	resp := http.Response{
+		Header: http.Header{},
+		Body:   io.NopCloser(strings.NewReader("openapi: 3.1")),
+	}
+
+	assert.False(t, New().Detect(&resp))
+}
+
+func TestLoad(t *testing.T) {
+	introspection, err := os.ReadFile("testdata/introspection.json")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Contains(t, body["query"], "__schema")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(introspection)
+	}))
+	defer server.Close()
+
+	cli.Init("test", "1.0.0")
+	cli.Defaults()
+
+	spec, _ := url.Parse(server.URL + "/graphql")
+	api, err := New().Load(*spec, *spec, &http.Response{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Example users API", api.Short)
+	require.Len(t, api.Operations, 3)
+
+	create := api.Operations[0]
+	assert.Equal(t, "create-user", create.Name)
+	assert.Equal(t, "mutation", create.Group)
+	assert.Equal(t, "Create a new user", create.Short)
+	assert.Equal(t, http.MethodPost, create.Method)
+	assert.Equal(t, spec.String(), create.URITemplate)
+	assert.Equal(t, "application/json", create.BodyMediaType)
+	assert.Empty(t, create.QueryParams)
+	assert.Equal(t, "mutation($input: CreateUserInput!) { createUser(input: $input) { id name role address { city } } }", create.GraphQL)
+
+	user := api.Operations[1]
+	assert.Equal(t, "user", user.Name)
+	assert.Equal(t, "query", user.Group)
+	assert.Empty(t, user.BodyMediaType)
+	assert.Equal(t, []*cli.Param{{Type: "string", Name: "id", Description: "The user ID", Required: true}}, user.QueryParams)
+	assert.Equal(t, "query($id: ID!) { user(id: $id) { id name role address { city } } }", user.GraphQL)
+
+	byRole := api.Operations[2]
+	assert.Equal(t, "users-by-role", byRole.Name)
+	assert.Equal(t, "Query usersByRole", byRole.Short)
+	assert.Equal(t, "Use search instead", byRole.Deprecated)
+	assert.Equal(t, []any{"ADMIN", "MEMBER"}, byRole.QueryParams[0].Enum)
+	assert.Equal(t, "integer", byRole.QueryParams[1].Type)
+}
+
+func TestLoadErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors": [{"message": "introspection is disabled"}]}`))
+	}))
+	defer server.Close()
+
+	cli.Init("test", "1.0.0")
+	cli.Defaults()
+
+	spec, _ := url.Parse(server.URL + "/graphql")
+	_, err := New().Load(*spec, *spec, &http.Response{})
+	assert.ErrorContains(t, err, "introspection is disabled")
+}
@@ -0,0 +1,493 @@
+// Package hyperschema provides a Restish loader for APIs described with
+// JSON Hyper-Schema, turning each link description object into a CLI
+// operation.
+package hyperschema
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/casing"
+	"github.com/danielgtaylor/restish/cli"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// reHyperSchema is a regex used to detect hyper-schema documents from their
+// `$schema` meta-schema declaration.
+var reHyperSchema = regexp.MustCompile(`['"]?\$schema['"]?\s*:\s*['"][^'"]*hyper-schema`)
+
+// reVariable matches URI template expressions like `{id}`, `{+path}`,
+// `{?a,b}` or the Heroku-style `{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fid)}`.
+var reVariable = regexp.MustCompile(`\{([+#./;?&]?)([^}]+)\}`)
+
+// link is a JSON Hyper-Schema link description object. Both draft-04 style
+// (`method`, `schema`, `encType`) and draft-07 style (`submissionSchema`,
+// `submissionMediaType`, `hrefSchema`) fields are supported.
+type link struct {
+	Rel          string
+	Href         string
+	Method       string
+	Title        string
+	Description  string
+	MediaType    string
+	Schema       map[string]any
+	HrefSchema   map[string]any
+	TargetSchema map[string]any
+}
+
+// document wraps a parsed hyper-schema so `$ref` pointers can be resolved.
+type document struct {
+	root map[string]any
+}
+
+// resolve follows local `$ref` JSON pointers until a concrete schema is
+// found. Remote references are left as-is.
+func (d *document) resolve(s map[string]any) map[string]any {
+	for i := 0; s != nil && i < 32; i++ {
+		ref, ok := s["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return s
+		}
+
+		var current any = d.root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			m, ok := current.(map[string]any)
+			if !ok {
+				return nil
+			}
+			current = m[part]
+		}
+
+		s, _ = current.(map[string]any)
+	}
+
+	return s
+}
+
+// getString returns a string value from a map or an empty string.
+func getString(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// getSchema returns a resolved schema value from a map or nil.
+func (d *document) getSchema(m map[string]any, key string) map[string]any {
+	s, _ := m[key].(map[string]any)
+	return d.resolve(s)
+}
+
+// collectLinks walks the schema, its definitions, and its properties to find
+// all link description objects.
+func (d *document) collectLinks(s map[string]any, links []link) []link {
+	s = d.resolve(s)
+	if s == nil {
+		return links
+	}
+
+	if items, ok := s["links"].([]any); ok {
+		for _, item := range items {
+			m, ok := item.(map[string]any)
+			if !ok || getString(m, "href") == "" {
+				continue
+			}
+
+			l := link{
+				Rel:          getString(m, "rel"),
+				Href:         getString(m, "href"),
+				Method:       strings.ToUpper(getString(m, "method")),
+				Title:        getString(m, "title"),
+				Description:  getString(m, "description"),
+				MediaType:    getString(m, "submissionMediaType"),
+				Schema:       d.getSchema(m, "submissionSchema"),
+				HrefSchema:   d.getSchema(m, "hrefSchema"),
+				TargetSchema: d.getSchema(m, "targetSchema"),
+			}
+
+			if l.MediaType == "" {
+				l.MediaType = getString(m, "encType")
+			}
+
+			if l.Schema == nil {
+				l.Schema = d.getSchema(m, "schema")
+			}
+
+			if l.Method == "" {
+				l.Method = http.MethodGet
+				if l.Schema != nil {
+					l.Method = http.MethodPost
+				}
+			}
+
+			links = append(links, l)
+		}
+	}
+
+	for _, key := range []string{"definitions", "$defs", "properties"} {
+		children, ok := s[key].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		names := make([]string, 0, len(children))
+		for name := range children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if child, ok := children[name].(map[string]any); ok {
+				// Only walk inline schemas; references are walked where defined.
+				if _, isRef := child["$ref"]; !isRef {
+					links = d.collectLinks(child, links)
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// variableName converts a URI template variable into a CLI-friendly name.
+// Heroku-style JSON pointer variables like `(#/definitions/app/definitions/id)`
+// become `app-id`.
+func variableName(v string) string {
+	v = strings.TrimSuffix(v, "*")
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		if unescaped, err := url.PathUnescape(v[1 : len(v)-1]); err == nil {
+			v = unescaped
+		}
+
+		parts := []string{}
+		for _, part := range strings.Split(strings.TrimPrefix(v, "#"), "/") {
+			switch part {
+			case "", "definitions", "$defs", "properties":
+				continue
+			}
+			parts = append(parts, part)
+		}
+		v = strings.Join(parts, "-")
+	}
+
+	return casing.Kebab(v)
+}
+
+// schemaType returns the first non-null type of a schema, inferring objects
+// from their properties.
+func schemaType(s map[string]any) string {
+	switch t := s["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if str, ok := item.(string); ok && str != "null" {
+				return str
+			}
+		}
+	}
+
+	if _, ok := s["properties"]; ok {
+		return "object"
+	}
+
+	return ""
+}
+
+// paramType returns the simple CLI param type for a schema.
+func paramType(s map[string]any) string {
+	switch typ := schemaType(s); typ {
+	case "boolean", "integer", "number", "string":
+		return typ
+	case "array":
+		if items, ok := s["items"].(map[string]any); ok {
+			return "array[" + paramType(items) + "]"
+		}
+		return "array[string]"
+	}
+
+	return "string"
+}
+
+// newParam creates a CLI param, using the schema (if any) for type info.
+func (d *document) newParam(name string, schema map[string]any, style cli.Style) *cli.Param {
+	p := &cli.Param{
+		Type:  "string",
+		Name:  name,
+		Style: style,
+	}
+
+	if s := d.resolve(schema); s != nil {
+		p.Type = paramType(s)
+		p.Description = getString(s, "description")
+		p.Default = s["default"]
+		p.Example = s["example"]
+	}
+
+	return p
+}
+
+// property returns a named property schema from an object schema.
+func (d *document) property(s map[string]any, name string) map[string]any {
+	if props, ok := s["properties"].(map[string]any); ok {
+		if prop, ok := props[name].(map[string]any); ok {
+			return prop
+		}
+	}
+
+	return nil
+}
+
+// operation converts a link into a CLI operation.
+func (d *document) operation(base *url.URL, l link) (cli.Operation, error) {
+	var pathParams, queryParams []*cli.Param
+
+	// Rewrite the href template into the simple `{name}` form used by the CLI,
+	// turning query expansions like `{?a,b}` into query params.
+	href := reVariable.ReplaceAllStringFunc(l.Href, func(expr string) string {
+		match := reVariable.FindStringSubmatch(expr)
+		if match[1] == "?" || match[1] == "&" {
+			for _, v := range strings.Split(match[2], ",") {
+				v = strings.TrimSuffix(v, "*")
+				queryParams = append(queryParams, d.newParam(v, d.property(l.HrefSchema, v), cli.StyleForm))
+			}
+			return ""
+		}
+
+		name := variableName(match[2])
+		pathParams = append(pathParams, d.newParam(name, d.property(l.HrefSchema, match[2]), cli.StyleSimple))
+		return "{" + name + "}"
+	})
+
+	// Root-relative hrefs are treated as relative to the API base path, just
+	// like OpenAPI operation paths.
+	if strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//") {
+		href = strings.TrimSuffix(base.Path, "/") + href
+	}
+
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return cli.Operation{}, err
+	}
+	resolved := base.ResolveReference(parsed)
+
+	tmpl := resolved.String()
+	if s, err := url.PathUnescape(tmpl); err == nil {
+		tmpl = s
+	}
+
+	mediaType := ""
+	desc := l.Description
+
+	if l.Schema != nil {
+		if l.Method == http.MethodGet || l.Method == http.MethodHead || l.Method == http.MethodDelete {
+			// Draft-04 style: the link schema describes the query string.
+			if props, ok := l.Schema["properties"].(map[string]any); ok {
+				names := make([]string, 0, len(props))
+				for name := range props {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					prop, _ := props[name].(map[string]any)
+					queryParams = append(queryParams, d.newParam(name, prop, cli.StyleForm))
+				}
+			}
+		} else {
+			mediaType = l.MediaType
+			if mediaType == "" {
+				mediaType = "application/json"
+			}
+			desc += "\n## Request Schema (" + mediaType + ")\n\n```schema\n" + d.renderSchema(l.Schema, "", map[string]int{}) + "\n```\n"
+		}
+	}
+
+	if l.TargetSchema != nil {
+		desc += "\n## Response Schema\n\n```schema\n" + d.renderSchema(l.TargetSchema, "", map[string]int{}) + "\n```\n"
+	}
+
+	name := casing.Kebab(l.Title)
+	if name == "" {
+		name = casing.Kebab(l.Method + "-" + l.Rel + "-" + strings.Trim(parsed.Path, "/"))
+	}
+
+	short := l.Title
+	if short == "" {
+		short = fmt.Sprintf("%s %s (%s)", l.Method, l.Href, l.Rel)
+	}
+
+	return cli.Operation{
+		Name:          name,
+		Short:         short,
+		Long:          strings.Trim(desc, "\n") + "\n",
+		Method:        l.Method,
+		URITemplate:   tmpl,
+		PathParams:    pathParams,
+		QueryParams:   queryParams,
+		BodyMediaType: mediaType,
+	}, nil
+}
+
+// renderSchema renders a schema using the same compact syntax as the OpenAPI
+// loader so that help output looks consistent between description formats.
+// Recursive references are expanded up to `rsh-expand-refs` times.
+func (d *document) renderSchema(s map[string]any, indent string, known map[string]int) string {
+	if ref, ok := s["$ref"].(string); ok {
+		if known[ref] > viper.GetInt("rsh-expand-refs") {
+			return "<recursive ref>"
+		}
+		known[ref]++
+		defer func() { known[ref]-- }()
+	}
+
+	s = d.resolve(s)
+	if s == nil {
+		return "<any>"
+	}
+
+	doc := getString(s, "title")
+	if doc == "" {
+		doc = getString(s, "description")
+	}
+	if doc != "" {
+		doc = " " + doc
+	}
+
+	typ := schemaType(s)
+	switch typ {
+	case "array":
+		items, _ := s["items"].(map[string]any)
+		if items == nil {
+			return "[<any>]"
+		}
+		return "[\n  " + indent + d.renderSchema(items, indent+"  ", known) + "\n" + indent + "]"
+	case "object":
+		props, _ := s["properties"].(map[string]any)
+		if len(props) == 0 {
+			return "(object)" + doc
+		}
+
+		required := map[string]bool{}
+		if req, ok := s["required"].([]any); ok {
+			for _, r := range req {
+				if str, ok := r.(string); ok {
+					required[str] = true
+				}
+			}
+		}
+
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		obj := "{\n"
+		for _, name := range names {
+			prop, _ := props[name].(map[string]any)
+			marker := ""
+			if required[name] {
+				marker = "*"
+			}
+			obj += indent + "  " + name + marker + ": " + d.renderSchema(prop, indent+"  ", known) + "\n"
+		}
+		return obj + indent + "}"
+	}
+
+	tags := []string{}
+	if f := getString(s, "format"); f != "" {
+		tags = append(tags, "format:"+f)
+	}
+	if s["default"] != nil {
+		tags = append(tags, fmt.Sprintf("default:%v", s["default"]))
+	}
+	if enum, ok := s["enum"].([]any); ok && len(enum) > 0 {
+		values := []string{}
+		for _, e := range enum {
+			values = append(values, fmt.Sprintf("%v", e))
+		}
+		tags = append(tags, "enum:"+strings.Join(values, ","))
+	}
+
+	tagStr := ""
+	if len(tags) > 0 {
+		tagStr = " " + strings.Join(tags, " ")
+	}
+
+	if typ == "" {
+		typ = "any"
+	}
+
+	return fmt.Sprintf("(%s%s)%s", typ, tagStr, doc)
+}
+
+func loadHyperSchema(base *url.URL, resp *http.Response) (cli.API, error) {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cli.API{}, err
+	}
+
+	// YAML is a superset of JSON, so this handles both.
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return cli.API{}, err
+	}
+
+	if root == nil {
+		return cli.API{}, fmt.Errorf("empty hyper-schema document")
+	}
+
+	d := &document{root: root}
+
+	operations := []cli.Operation{}
+	for _, l := range d.collectLinks(root, nil) {
+		op, err := d.operation(base, l)
+		if err != nil {
+			return cli.API{}, err
+		}
+		operations = append(operations, op)
+	}
+
+	return cli.API{
+		Short:      getString(root, "title"),
+		Long:       getString(root, "description"),
+		Operations: operations,
+	}, nil
+}
+
+type loader struct{}
+
+// LocationHints returns no hints, as hyper-schema documents are expected to
+// be advertised via a `describedby` link.
+func (l *loader) LocationHints() []string {
+	return []string{}
+}
+
+func (l *loader) Detect(resp *http.Response) bool {
+	// Try to detect via header first, e.g.
+	// `application/schema+json; profile="https://json-schema.org/draft/2019-09/hyper-schema"`
+	ct := resp.Header.Get("content-type")
+	if strings.HasPrefix(ct, "application/schema+json") && strings.Contains(ct, "hyper-schema") {
+		return true
+	}
+
+	// Fall back to looking for the hyper-schema meta-schema in the body.
+	body, _ := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+
+	return reHyperSchema.Match(body)
+}
+
+func (l *loader) Load(entrypoint, spec url.URL, resp *http.Response) (cli.API, error) {
+	return loadHyperSchema(&entrypoint, resp)
+}
+
+// New creates a new JSON Hyper-Schema loader.
+func New() cli.Loader {
+	return &loader{}
+}
@@ -0,0 +1,130 @@
+package hyperschema
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectViaHeader(t *testing.T) {
+	resp := http.Response{
+		Header: http.Header{},
+	}
+	resp.Header.Set("Content-Type", `application/schema+json; profile="https://json-schema.org/draft/2019-09/hyper-schema"`)
+
+	assert.True(t, New().Detect(&resp))
+}
+
+func TestDetectViaBody(t *testing.T) {
+	resp := http.Response{
+		Header: http.Header{},
+		Body:   io.NopCloser(strings.NewReader(`{"$schema": "http://json-schema.org/draft-07/hyper-schema#"}`)),
+	}
+
+	assert.True(t, New().Detect(&resp))
+}
+
+func TestDetectOpenAPI(t *testing.T) {
+	resp := http.Response{
+		Header: http.Header{},
+		Body:   io.NopCloser(strings.NewReader("openapi: 3.1")),
+	}
+
+	assert.False(t, New().Detect(&resp))
+}
+
+func TestEmptyDocument(t *testing.T) {
+	base, _ := url.Parse("http://api.example.com")
+
+	resp := &http.Response{
+		Body: io.NopCloser(strings.NewReader("")),
+	}
+
+	_, err := New().Load(*base, *base, resp)
+	assert.Error(t, err)
+}
+
+func TestLoadDraft04(t *testing.T) {
+	input, err := os.ReadFile("testdata/heroku.json")
+	require.NoError(t, err)
+
+	base, _ := url.Parse("https://api.example.com/v1")
+	spec, _ := url.Parse("https://api.example.com/v1/schema")
+
+	api, err := New().Load(*base, *spec, &http.Response{
+		Body: io.NopCloser(bytes.NewReader(input)),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Example API", api.Short)
+	require.Len(t, api.Operations, 3)
+
+	list := api.Operations[0]
+	assert.Equal(t, "list-apps", list.Name)
+	assert.Equal(t, http.MethodGet, list.Method)
+	assert.Equal(t, "https://api.example.com/v1/apps", list.URITemplate)
+	require.Len(t, list.QueryParams, 1)
+	assert.Equal(t, "limit", list.QueryParams[0].Name)
+	assert.Equal(t, "integer", list.QueryParams[0].Type)
+	assert.Contains(t, list.Long, "## Response Schema")
+
+	create := api.Operations[1]
+	assert.Equal(t, "create-app", create.Name)
+	assert.Equal(t, "application/json", create.BodyMediaType)
+	assert.Contains(t, create.Long, "name*: (string) name of app")
+
+	info := api.Operations[2]
+	assert.Equal(t, "info", info.Name)
+	assert.Equal(t, "https://api.example.com/v1/apps/{app-id}", info.URITemplate)
+	require.Len(t, info.PathParams, 1)
+	assert.Equal(t, "app-id", info.PathParams[0].Name)
+}
+
+func TestLoadDraft07(t *testing.T) {
+	input := `
+$schema: http://json-schema.org/draft-07/hyper-schema#
+title: Things
+links:
+  - rel: search
+    href: /things{?q,page}
+    hrefSchema:
+      properties:
+        page:
+          type: integer
+  - rel: create
+    href: things/{thingId}
+    submissionMediaType: application/merge-patch+json
+    submissionSchema:
+      type: object
+      properties:
+        name:
+          type: string
+`
+
+	base, _ := url.Parse("https://api.example.com/")
+
+	api, err := New().Load(*base, *base, &http.Response{
+		Body: io.NopCloser(strings.NewReader(input)),
+	})
+	require.NoError(t, err)
+	require.Len(t, api.Operations, 2)
+
+	search := api.Operations[0]
+	assert.Equal(t, "get-search-things", search.Name)
+	assert.Equal(t, "https://api.example.com/things", search.URITemplate)
+	require.Len(t, search.QueryParams, 2)
+	assert.Equal(t, "string", search.QueryParams[0].Type)
+	assert.Equal(t, "integer", search.QueryParams[1].Type)
+
+	create := api.Operations[1]
+	assert.Equal(t, http.MethodPost, create.Method)
+	assert.Equal(t, "application/merge-patch+json", create.BodyMediaType)
+	assert.Equal(t, "https://api.example.com/things/{thing-id}", create.URITemplate)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/danielgtaylor/restish/bulk"
 	"github.com/danielgtaylor/restish/cli"
+	"github.com/danielgtaylor/restish/graphql"
+	"github.com/danielgtaylor/restish/hyperschema"
 	"github.com/danielgtaylor/restish/oauth"
 	"github.com/danielgtaylor/restish/openapi"
 )
@@ -31,13 +34,20 @@ func main() {
 
 	// Register format loaders to auto-discover API descriptions
 	cli.AddLoader(openapi.New())
+	cli.AddLoader(hyperschema.New())
+	cli.AddLoader(graphql.New())
 
 	// Register auth schemes
 	cli.AddAuth("oauth-client-credentials", &oauth.ClientCredentialsHandler{})
 	cli.AddAuth("oauth-authorization-code", &oauth.AuthorizationCodeHandler{})
+	cli.AddAuth("oauth-device-code", &oauth.DeviceCodeHandler{})
 
 	// Run the CLI, parsing arguments, making requests, and printing responses.
 	if err := cli.Run(); err != nil {
+		if errors.Is(err, cli.ErrInterrupted) {
+			// Conventional exit code for SIGINT.
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 
@@ -6,11 +6,10 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"runtime"
 	"strings"
 	"time"
 
@@ -126,24 +125,6 @@ var htmlError = `
 </html>
 `
 
-// open opens the specified URL in the default browser regardless of OS.
-func open(url string) error {
-	var cmd string
-	var args []string
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = "cmd"
-		args = []string{"/c", "start"}
-	case "darwin": // mac, ios
-		cmd = "open"
-	default: // "linux", "freebsd", "openbsd", "netbsd"
-		cmd = "xdg-open"
-	}
-	args = append(args, url)
-	return exec.Command(cmd, args...).Start()
-}
-
 // getInput waits for user input and sends it to the input channel with the
 // trailing newline removed.
 func getInput(input chan string) {
@@ -179,9 +160,11 @@ func (h authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // AuthorizationCodeTokenSource with PKCE as described in:
 // https://www.oauth.com/oauth2-servers/pkce/
-// This works by running a local HTTP server on port 8484 and then having the
-// user log in through a web browser, which redirects to the redirect url with
-// an authorization code. That code is then used to make another HTTP request
+// PKCE uses the S256 challenge method by default. Legacy providers may need
+// `plain` or for it to be disabled entirely via `none`.
+// This works by running a local HTTP server on port 8484 (or the port and path
+// from `RedirectURL`) and then having the user log in through a web browser,
+// which redirects to the redirect url with an authorization code. That code is then used to make another HTTP request
 // to fetch an auth token (and refresh token). That token is then in turn
 // used to make requests against the API.
 type AuthorizationCodeTokenSource struct {
@@ -192,6 +175,7 @@ type AuthorizationCodeTokenSource struct {
 	RedirectURL    string
 	EndpointParams *url.Values
 	Scopes         []string
+	PKCEMethod     string
 }
 
 func (ac *AuthorizationCodeTokenSource) getRedirectUrl() string {
@@ -202,8 +186,28 @@ func (ac *AuthorizationCodeTokenSource) getRedirectUrl() string {
 	return ac.RedirectURL
 }
 
+// parseRedirectURL validates a redirect URL for the local redirect server,
+// which must be a plain `http` URL with a host. The port defaults to 80.
+func parseRedirectURL(redirect string) (*url.URL, error) {
+	u, err := url.Parse(redirect)
+	if err != nil || u.Scheme != "http" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid redirect_url %s, expected e.g. http://localhost:8484/callback", redirect)
+	}
+
+	return u, nil
+}
+
 // Token generates a new token using an authorization code.
 func (ac *AuthorizationCodeTokenSource) Token() (*oauth2.Token, error) {
+	method := ac.PKCEMethod
+	if method == "" {
+		method = "S256"
+	}
+
+	if method != "S256" && method != "plain" && method != "none" {
+		return nil, fmt.Errorf("unsupported PKCE method %s, expected one of S256, plain, none", method)
+	}
+
 	// Generate a random code verifier string
 	verifierBytes := make([]byte, 32)
 	if _, err := rand.Read(verifierBytes); err != nil {
@@ -214,8 +218,11 @@ func (ac *AuthorizationCodeTokenSource) Token() (*oauth2.Token, error) {
 
 	// Generate a code challenge. Only the challenge is sent when requesting a
 	// code which allows us to keep it secret for now.
-	shaBytes := sha256.Sum256([]byte(verifier))
-	challenge := base64.RawURLEncoding.EncodeToString(shaBytes[:])
+	challenge := verifier
+	if method == "S256" {
+		shaBytes := sha256.Sum256([]byte(verifier))
+		challenge = base64.RawURLEncoding.EncodeToString(shaBytes[:])
+	}
 
 	// Generate a URL with the challenge to have the user log in.
 	authorizeURL, err := url.Parse(ac.AuthorizeURL)
@@ -225,8 +232,10 @@ func (ac *AuthorizationCodeTokenSource) Token() (*oauth2.Token, error) {
 
 	aq := authorizeURL.Query()
 	aq.Set("response_type", "code")
-	aq.Set("code_challenge", challenge)
-	aq.Set("code_challenge_method", "S256")
+	if method != "none" {
+		aq.Set("code_challenge", challenge)
+		aq.Set("code_challenge_method", method)
+	}
 	aq.Set("client_id", ac.ClientID)
 	aq.Set("redirect_uri", ac.getRedirectUrl())
 	aq.Set("scope", strings.Join(ac.Scopes, " "))
@@ -244,23 +253,41 @@ func (ac *AuthorizationCodeTokenSource) Token() (*oauth2.Token, error) {
 	}
 
 	// strip protocol prefix from configured redirect url for local webserver
-	u, err := url.Parse(ac.getRedirectUrl())
+	u, err := parseRedirectURL(ac.getRedirectUrl())
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	redirectServer := net.JoinHostPort(u.Hostname(), port)
+
+	// Only handle the configured path, so e.g. a browser requesting a favicon
+	// does not abort the login.
+	mux := http.NewServeMux()
+	path := u.Path
+	if path == "" {
+		path = "/"
 	}
-	redirectServer := fmt.Sprintf("%s:%s", u.Hostname(), u.Port())
+	mux.Handle(path, handler)
 
 	s := &http.Server{
-		Addr:           redirectServer,
-		Handler:        handler,
+		Handler:        mux,
 		ReadTimeout:    5 * time.Second,
 		WriteTimeout:   5 * time.Second,
 		MaxHeaderBytes: 1024,
 	}
 
+	listener, err := net.Listen("tcp", redirectServer)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on %s for the OAuth redirect, set the redirect_url auth param to use another port: %w", redirectServer, err)
+	}
+
 	go func() {
 		// Run in a goroutine until the server is closed or we get an error.
-		if err := s.ListenAndServe(); err != http.ErrServerClosed {
+		if err := s.Serve(listener); err != http.ErrServerClosed {
 			panic(err)
 		}
 	}()
@@ -268,7 +295,7 @@ func (ac *AuthorizationCodeTokenSource) Token() (*oauth2.Token, error) {
 	// Open auth URL in browser, print for manual use in case open fails.
 	fmt.Fprintln(os.Stderr, "Open your browser to log in using the URL:")
 	fmt.Fprintln(os.Stderr, authorizeURL.String())
-	open(authorizeURL.String())
+	cli.OpenBrowser(authorizeURL.String())
 
 	// Provide a way to manually enter the code, e.g. for remote SSH sessions.
 	// Only read from stdin if it is a live terminal, if a file or command has
@@ -298,12 +325,19 @@ func (ac *AuthorizationCodeTokenSource) Token() (*oauth2.Token, error) {
 	payload := url.Values{}
 	payload.Set("grant_type", "authorization_code")
 	payload.Set("client_id", ac.ClientID)
-	payload.Set("code_verifier", verifier)
+	if method != "none" {
+		payload.Set("code_verifier", verifier)
+	}
 	payload.Set("code", code)
 	payload.Set("redirect_uri", ac.getRedirectUrl())
 	if ac.ClientSecret != "" {
 		payload.Set("client_secret", ac.ClientSecret)
 	}
+	if ac.EndpointParams != nil {
+		for k, v := range *ac.EndpointParams {
+			payload.Set(k, v[0])
+		}
+	}
 
 	return requestToken(ac.TokenURL, payload.Encode())
 }
@@ -317,26 +351,35 @@ func (h *AuthorizationCodeHandler) Parameters() []cli.AuthParam {
 	return []cli.AuthParam{
 		{Name: "client_id", Required: true, Help: "OAuth 2.0 Client ID"},
 		{Name: "client_secret", Required: false, Help: "OAuth 2.0 Client Secret if exists"},
-		{Name: "authorize_url", Required: true, Help: "OAuth 2.0 authorization URL, e.g. https://api.example.com/oauth/authorize"},
-		{Name: "token_url", Required: true, Help: "OAuth 2.0 token URL, e.g. https://api.example.com/oauth/token"},
+		{Name: "issuer", Help: "Optional OpenID Connect issuer URL used to discover the authorization & token URLs, e.g. https://example.auth0.com/"},
+		{Name: "authorize_url", Help: "OAuth 2.0 authorization URL, e.g. https://api.example.com/oauth/authorize. Required unless an issuer is set"},
+		{Name: "token_url", Help: "OAuth 2.0 token URL, e.g. https://api.example.com/oauth/token. Required unless an issuer is set"},
 		{Name: "scopes", Help: "Optional scopes to request in the token"},
-		{Name: "redirect_url", Help: "Optional redirect URL with protocol and port, defaults to 'http://localhost:8484' if not specified. "},
+		{Name: "introspection_url", Help: "Optional OAuth 2.0 token introspection URL used by auth-info for opaque tokens"},
+		{Name: "revocation_url", Help: "Optional OAuth 2.0 token revocation URL used to revoke tokens on logout"},
+		{Name: "pkce_method", Help: "Optional PKCE code challenge method [S256, plain, none], defaults to S256"},
+		{Name: "redirect_url", Help: "Optional redirect URL with protocol, port, and path, e.g. http://localhost:9000/callback. Defaults to http://localhost:8484"},
 	}
 }
 
 // OnRequest gets run before the request goes out on the wire.
 func (h *AuthorizationCodeHandler) OnRequest(request *http.Request, key string, params map[string]string) error {
 	if request.Header.Get("Authorization") == "" {
-		endpointParams := url.Values{}
-		for k, v := range params {
-			if k == "client_id" || k == "client_secret" || k == "scopes" || k == "authorize_url" || k == "token_url" || k == "redirect_url" {
-				// Not a custom param...
-				continue
-			}
+		var err error
+		params, err = withDiscovery(withScopes(params), "authorize_url", "token_url")
+		if err != nil {
+			return err
+		}
+		key = cacheKey(key, params)
 
-			endpointParams.Add(k, v)
+		if params["redirect_url"] != "" {
+			if _, err := parseRedirectURL(params["redirect_url"]); err != nil {
+				return err
+			}
 		}
 
+		endpointParams := extraParams(params, "client_id", "client_secret", "scopes", "authorize_url", "token_url", "redirect_url", "pkce_method", "issuer", "device_authorization_url", "introspection_url", "revocation_url")
+
 		source := &AuthorizationCodeTokenSource{
 			ClientID:       params["client_id"],
 			ClientSecret:   params["client_secret"],
@@ -345,6 +388,7 @@ func (h *AuthorizationCodeHandler) OnRequest(request *http.Request, key string,
 			RedirectURL:    params["redirect_url"],
 			EndpointParams: &endpointParams,
 			Scopes:         strings.Split(params["scopes"], ","),
+			PKCEMethod:     params["pkce_method"],
 		}
 
 		// Try to get a cached refresh token from the current profile and use
@@ -363,3 +407,27 @@ func (h *AuthorizationCodeHandler) OnRequest(request *http.Request, key string,
 
 	return nil
 }
+
+// InvalidateAuth removes the cached token so a new one is fetched, using the
+// refresh token if available.
+func (h *AuthorizationCodeHandler) InvalidateAuth(key string, params map[string]string) error {
+	params, err := withDiscovery(withScopes(params), "authorize_url", "token_url")
+	if err != nil {
+		return err
+	}
+	return InvalidateToken(cacheKey(key, params))
+}
+
+// AuthInfo describes the cached token without fetching a new one.
+func (h *AuthorizationCodeHandler) AuthInfo(key string, params map[string]string) (*cli.TokenInfo, error) {
+	params, err := withDiscovery(withScopes(params), "authorize_url", "token_url")
+	if err != nil {
+		return nil, err
+	}
+	return tokenInfo(cacheKey(key, params), params)
+}
+
+// RevokeAuth revokes the cached tokens with the provider, if supported.
+func (h *AuthorizationCodeHandler) RevokeAuth(key string, params map[string]string) error {
+	return revokeTokens(key, params)
+}
@@ -3,7 +3,6 @@ package oauth
 import (
 	"context"
 	"net/http"
-	"net/url"
 	"strings"
 
 	"github.com/danielgtaylor/restish/cli"
@@ -18,14 +17,24 @@ func (h *ClientCredentialsHandler) Parameters() []cli.AuthParam {
 	return []cli.AuthParam{
 		{Name: "client_id", Required: true, Help: "OAuth 2.0 Client ID"},
 		{Name: "client_secret", Required: true, Help: "OAuth 2.0 Client Secret"},
-		{Name: "token_url", Required: true, Help: "OAuth 2.0 token URL, e.g. https://api.example.com/oauth/token"},
+		{Name: "issuer", Help: "Optional OpenID Connect issuer URL used to discover the token URL, e.g. https://example.auth0.com/"},
+		{Name: "token_url", Help: "OAuth 2.0 token URL, e.g. https://api.example.com/oauth/token. Required unless an issuer is set"},
 		{Name: "scopes", Help: "Optional scopes to request in the token"},
+		{Name: "introspection_url", Help: "Optional OAuth 2.0 token introspection URL used by auth-info for opaque tokens"},
+		{Name: "revocation_url", Help: "Optional OAuth 2.0 token revocation URL used to revoke tokens on logout"},
 	}
 }
 
 // OnRequest gets run before the request goes out on the wire.
 func (h *ClientCredentialsHandler) OnRequest(request *http.Request, key string, params map[string]string) error {
 	if request.Header.Get("Authorization") == "" {
+		var err error
+		params, err = withDiscovery(withScopes(params), "token_url")
+		if err != nil {
+			return err
+		}
+		key = cacheKey(key, params)
+
 		if params["client_id"] == "" {
 			return ErrInvalidProfile
 		}
@@ -38,15 +47,7 @@ func (h *ClientCredentialsHandler) OnRequest(request *http.Request, key string,
 			return ErrInvalidProfile
 		}
 
-		endpointParams := url.Values{}
-		for k, v := range params {
-			if k == "client_id" || k == "client_secret" || k == "scopes" || k == "token_url" {
-				// Not a custom param...
-				continue
-			}
-
-			endpointParams.Add(k, v)
-		}
+		endpointParams := extraParams(params, "client_id", "client_secret", "scopes", "token_url", "issuer", "device_authorization_url", "introspection_url", "revocation_url")
 
 		source := (&clientcredentials.Config{
 			ClientID:       params["client_id"],
@@ -61,3 +62,26 @@ func (h *ClientCredentialsHandler) OnRequest(request *http.Request, key string,
 
 	return nil
 }
+
+// InvalidateAuth removes the cached token so a new one is fetched.
+func (h *ClientCredentialsHandler) InvalidateAuth(key string, params map[string]string) error {
+	params, err := withDiscovery(withScopes(params), "token_url")
+	if err != nil {
+		return err
+	}
+	return InvalidateToken(cacheKey(key, params))
+}
+
+// AuthInfo describes the cached token without fetching a new one.
+func (h *ClientCredentialsHandler) AuthInfo(key string, params map[string]string) (*cli.TokenInfo, error) {
+	params, err := withDiscovery(withScopes(params), "token_url")
+	if err != nil {
+		return nil, err
+	}
+	return tokenInfo(cacheKey(key, params), params)
+}
+
+// RevokeAuth revokes the cached tokens with the provider, if supported.
+func (h *ClientCredentialsHandler) RevokeAuth(key string, params map[string]string) error {
+	return revokeTokens(key, params)
+}
@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/restish/cli"
+	"golang.org/x/oauth2"
+)
+
+// sleep is used to wait between token polls. It is a variable so it can be
+// replaced, e.g. for testing.
+var sleep = time.Sleep
+
+// deviceAuthResponse is the response from the device authorization endpoint.
+type deviceAuthResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURL         string `json:"verification_url"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceCodeTokenSource implements the OAuth 2.0 device authorization grant
+// as described in RFC 8628. The user is shown a code and URL to visit on any
+// other device, e.g. a phone, while the token endpoint is polled until they
+// have logged in. This works on headless machines where a browser and local
+// redirect server are not available.
+type DeviceCodeTokenSource struct {
+	ClientID               string
+	ClientSecret           string
+	DeviceAuthorizationURL string
+	TokenURL               string
+	EndpointParams         *url.Values
+	Scopes                 []string
+}
+
+// authorizeDevice requests a device and user code.
+func (dc *DeviceCodeTokenSource) authorizeDevice() (*deviceAuthResponse, error) {
+	payload := url.Values{}
+	payload.Set("client_id", dc.ClientID)
+	if dc.ClientSecret != "" {
+		payload.Set("client_secret", dc.ClientSecret)
+	}
+	if scope := strings.TrimSpace(strings.Join(dc.Scopes, " ")); scope != "" {
+		payload.Set("scope", scope)
+	}
+	if dc.EndpointParams != nil {
+		for k, v := range *dc.EndpointParams {
+			payload.Set(k, v[0])
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, dc.DeviceAuthorizationURL, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	cli.LogDebugRequest(req)
+
+	start := time.Now()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	cli.LogDebugResponse(start, res)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode > 200 {
+		return nil, fmt.Errorf("bad response from device authorization endpoint:\n%s", body)
+	}
+
+	decoded := &deviceAuthResponse{}
+	if err := json.Unmarshal(body, decoded); err != nil {
+		return nil, err
+	}
+
+	if decoded.DeviceCode == "" || decoded.UserCode == "" {
+		return nil, fmt.Errorf("missing device or user code from device authorization endpoint:\n%s", body)
+	}
+
+	if decoded.VerificationURI == "" {
+		// Some providers use the older draft name.
+		decoded.VerificationURI = decoded.VerificationURL
+	}
+
+	return decoded, nil
+}
+
+// Token generates a new token by having the user authorize this device.
+func (dc *DeviceCodeTokenSource) Token() (*oauth2.Token, error) {
+	auth, err := dc.authorizeDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "To log in, open %s and enter the code: %s\n", auth.VerificationURI, auth.UserCode)
+	if auth.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "Or open this URL, which includes the code: %s\n", auth.VerificationURIComplete)
+	}
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	expiresIn := time.Duration(auth.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	deadline := time.Now().Add(expiresIn)
+
+	payload := url.Values{}
+	payload.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+	payload.Set("device_code", auth.DeviceCode)
+	payload.Set("client_id", dc.ClientID)
+	if dc.ClientSecret != "" {
+		payload.Set("client_secret", dc.ClientSecret)
+	}
+	if dc.EndpointParams != nil {
+		for k, v := range *dc.EndpointParams {
+			payload.Set(k, v[0])
+		}
+	}
+
+	for {
+		sleep(interval)
+
+		token, err := requestToken(dc.TokenURL, payload.Encode())
+		if err == nil {
+			fmt.Fprintln(os.Stderr, "Logged in!")
+			return token, nil
+		}
+
+		var tokenErr *TokenError
+		if !errors.As(err, &tokenErr) {
+			return nil, err
+		}
+
+		switch tokenErr.Code {
+		case "authorization_pending":
+			// Keep waiting for the user.
+		case "slow_down":
+			interval += 5 * time.Second
+			cli.LogDebug("Token endpoint asked to slow down, polling every %s", interval)
+		case "access_denied":
+			return nil, errors.New("device authorization was denied")
+		case "expired_token":
+			return nil, errors.New("device code expired before authorization, please try again")
+		default:
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.New("device code expired before authorization, please try again")
+		}
+	}
+}
+
+// DeviceCodeHandler sets up the OAuth 2.0 device authorization grant flow.
+type DeviceCodeHandler struct{}
+
+// Parameters returns a list of OAuth2 Device Code inputs.
+func (h *DeviceCodeHandler) Parameters() []cli.AuthParam {
+	return []cli.AuthParam{
+		{Name: "client_id", Required: true, Help: "OAuth 2.0 Client ID"},
+		{Name: "client_secret", Required: false, Help: "OAuth 2.0 Client Secret if exists"},
+		{Name: "issuer", Help: "Optional OpenID Connect issuer URL used to discover the device authorization & token URLs, e.g. https://example.auth0.com/"},
+		{Name: "device_authorization_url", Help: "OAuth 2.0 device authorization URL, e.g. https://api.example.com/oauth/device/code. Required unless an issuer is set"},
+		{Name: "token_url", Help: "OAuth 2.0 token URL, e.g. https://api.example.com/oauth/token. Required unless an issuer is set"},
+		{Name: "scopes", Help: "Optional scopes to request in the token"},
+		{Name: "introspection_url", Help: "Optional OAuth 2.0 token introspection URL used by auth-info for opaque tokens"},
+		{Name: "revocation_url", Help: "Optional OAuth 2.0 token revocation URL used to revoke tokens on logout"},
+	}
+}
+
+// OnRequest gets run before the request goes out on the wire.
+func (h *DeviceCodeHandler) OnRequest(request *http.Request, key string, params map[string]string) error {
+	if request.Header.Get("Authorization") == "" {
+		var err error
+		params, err = withDiscovery(withScopes(params), "device_authorization_url", "token_url")
+		if err != nil {
+			return err
+		}
+		key = cacheKey(key, params)
+
+		if params["client_id"] == "" || params["device_authorization_url"] == "" || params["token_url"] == "" {
+			return ErrInvalidProfile
+		}
+
+		endpointParams := extraParams(params, "client_id", "client_secret", "scopes", "token_url", "issuer", "device_authorization_url", "introspection_url", "revocation_url")
+
+		source := &DeviceCodeTokenSource{
+			ClientID:               params["client_id"],
+			ClientSecret:           params["client_secret"],
+			DeviceAuthorizationURL: params["device_authorization_url"],
+			TokenURL:               params["token_url"],
+			EndpointParams:         &endpointParams,
+			Scopes:                 strings.Split(params["scopes"], ","),
+		}
+
+		// Try to get a cached refresh token from the current profile and use
+		// it to wrap the device code token source with a refreshing source.
+		refreshKey := key + ".refresh"
+		refreshSource := RefreshTokenSource{
+			ClientID:       params["client_id"],
+			TokenURL:       params["token_url"],
+			EndpointParams: &endpointParams,
+			RefreshToken:   cli.Cache.GetString(refreshKey),
+			TokenSource:    source,
+		}
+
+		return TokenHandler(&refreshSource, key, request)
+	}
+
+	return nil
+}
+
+// InvalidateAuth removes the cached token so a new one is fetched, using the
+// refresh token if available.
+func (h *DeviceCodeHandler) InvalidateAuth(key string, params map[string]string) error {
+	params, err := withDiscovery(withScopes(params), "device_authorization_url", "token_url")
+	if err != nil {
+		return err
+	}
+	return InvalidateToken(cacheKey(key, params))
+}
+
+// AuthInfo describes the cached token without fetching a new one.
+func (h *DeviceCodeHandler) AuthInfo(key string, params map[string]string) (*cli.TokenInfo, error) {
+	params, err := withDiscovery(withScopes(params), "device_authorization_url", "token_url")
+	if err != nil {
+		return nil, err
+	}
+	return tokenInfo(cacheKey(key, params), params)
+}
+
+// RevokeAuth revokes the cached tokens with the provider, if supported.
+func (h *DeviceCodeHandler) RevokeAuth(key string, params map[string]string) error {
+	return revokeTokens(key, params)
+}
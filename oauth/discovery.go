@@ -0,0 +1,121 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/restish/cli"
+)
+
+// discoveryTTL is how long a fetched discovery document is cached.
+const discoveryTTL = 24 * time.Hour
+
+// openIDConfiguration is the subset of an OpenID Connect discovery document
+// that is used to configure the OAuth 2.0 flows.
+type openIDConfiguration struct {
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint,omitempty"`
+}
+
+// discover fetches the OpenID Connect discovery document for an issuer, using
+// the CLI cache to avoid repeated fetches.
+func discover(issuer string) (*openIDConfiguration, error) {
+	// Issuer URLs contain dots, which the cache treats as nested keys, so use a
+	// hash of the issuer instead.
+	sum := sha256.Sum256([]byte(issuer))
+	key := "oidc-" + hex.EncodeToString(sum[:8])
+
+	if expires := cli.Cache.GetTime(key + ".expires"); time.Now().Before(expires) {
+		cli.LogDebug("Loading OpenID configuration for %s from cache.", issuer)
+		return &openIDConfiguration{
+			AuthorizationEndpoint:       cli.Cache.GetString(key + ".authorization_endpoint"),
+			TokenEndpoint:               cli.Cache.GetString(key + ".token_endpoint"),
+			DeviceAuthorizationEndpoint: cli.Cache.GetString(key + ".device_authorization_endpoint"),
+		}, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cli.LogDebugRequest(req)
+
+	start := time.Now()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	cli.LogDebugResponse(start, res)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response from OpenID discovery endpoint:\n%s", body)
+	}
+
+	config := &openIDConfiguration{}
+	if err := json.Unmarshal(body, config); err != nil {
+		return nil, err
+	}
+
+	cli.Cache.Set(key+".expires", time.Now().Add(discoveryTTL))
+	cli.Cache.Set(key+".authorization_endpoint", config.AuthorizationEndpoint)
+	cli.Cache.Set(key+".token_endpoint", config.TokenEndpoint)
+	cli.Cache.Set(key+".device_authorization_endpoint", config.DeviceAuthorizationEndpoint)
+	if err := cli.Cache.WriteConfig(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// withDiscovery fills in any missing endpoint URLs in a copy of the auth params
+// from the OpenID Connect discovery document of the `issuer` param, if set.
+// Discovery is skipped when all the `needed` params are already configured,
+// and explicitly configured URLs always take precedence.
+func withDiscovery(params map[string]string, needed ...string) (map[string]string, error) {
+	issuer := params["issuer"]
+	if issuer == "" {
+		return params, nil
+	}
+
+	missing := false
+	for _, name := range needed {
+		if params[name] == "" {
+			missing = true
+		}
+	}
+	if !missing {
+		return params, nil
+	}
+
+	config, err := discover(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := make(map[string]string, len(params)+3)
+	for k, v := range params {
+		modified[k] = v
+	}
+
+	for k, v := range map[string]string{
+		"authorize_url":            config.AuthorizationEndpoint,
+		"token_url":                config.TokenEndpoint,
+		"device_authorization_url": config.DeviceAuthorizationEndpoint,
+	} {
+		if modified[k] == "" && v != "" {
+			modified[k] = v
+		}
+	}
+
+	return modified, nil
+}
@@ -0,0 +1,163 @@
+package oauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/restish/cli"
+)
+
+// decodeJWT decodes the header and claims of a JWT without verifying its
+// signature, which is fine for showing what a token claims to be.
+func decodeJWT(token string) (map[string]any, map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("not a JWT")
+	}
+
+	decoded := make([]map[string]any, 2)
+	for i, part := range parts[:2] {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(b, &decoded[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return decoded[0], decoded[1], nil
+}
+
+// postClientForm posts a form to a provider endpoint like token introspection
+// or revocation, authenticating as the client. Confidential clients use HTTP
+// basic auth, while public clients send their ID in the form.
+func postClientForm(endpoint string, params map[string]string, payload url.Values) ([]byte, error) {
+	if params["client_secret"] == "" {
+		payload.Set("client_id", params["client_id"])
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	if params["client_secret"] != "" {
+		req.SetBasicAuth(url.QueryEscape(params["client_id"]), url.QueryEscape(params["client_secret"]))
+	}
+
+	cli.LogDebugRequest(req)
+
+	start := time.Now()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	cli.LogDebugResponse(start, res)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response from %s:\n%s", endpoint, body)
+	}
+
+	return body, nil
+}
+
+// introspect asks the provider about an opaque token via its RFC 7662 token
+// introspection endpoint.
+func introspect(params map[string]string, token string) (map[string]any, error) {
+	body, err := postClientForm(params["introspection_url"], params, url.Values{
+		"token":           []string{token},
+		"token_type_hint": []string{"access_token"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]any{}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, err
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, errors.New("token is no longer active, log in again")
+	}
+
+	return claims, nil
+}
+
+// tokenInfo describes the cached token for a cache key, see `cacheKey`.
+func tokenInfo(key string, params map[string]string) (*cli.TokenInfo, error) {
+	token := cli.Cache.GetString(key + ".token")
+	if token == "" {
+		return nil, errors.New("no cached token, log in first")
+	}
+
+	info := &cli.TokenInfo{
+		Expires: cli.Cache.GetTime(key + ".expires"),
+	}
+
+	var err error
+	if info.Header, info.Claims, err = decodeJWT(token); err == nil {
+		return info, nil
+	}
+
+	if params["introspection_url"] == "" {
+		return nil, errors.New("token is opaque, set an introspection_url auth param to inspect it")
+	}
+
+	info.Opaque = true
+	info.Header = nil
+	if info.Claims, err = introspect(params, token); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// revokeTokens revokes every cached token for a profile key, e.g. for each
+// set of scopes, via the provider's RFC 7009 token revocation endpoint.
+// Refresh tokens are revoked first since providers typically revoke the
+// access tokens issued from them too.
+func revokeTokens(key string, params map[string]string) error {
+	if params["revocation_url"] == "" {
+		return nil
+	}
+
+	prefix := strings.ToLower(key) + ":"
+	for k := range cli.Cache.AllSettings() {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		for _, hint := range []string{"refresh_token", "access_token"} {
+			suffix := ".token"
+			if hint == "refresh_token" {
+				suffix = ".refresh"
+			}
+
+			token := cli.Cache.GetString(k + suffix)
+			if token == "" {
+				continue
+			}
+
+			cli.LogDebug("Revoking cached OAuth2 %s", hint)
+			if _, err := postClientForm(params["revocation_url"], params, url.Values{
+				"token":           []string{token},
+				"token_type_hint": []string{hint},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,16 +1,83 @@
 package oauth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
+	"time"
 
 	"github.com/danielgtaylor/restish/cli"
+	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
 
 // ErrInvalidProfile is thrown when a profile is missing or invalid.
 var ErrInvalidProfile = errors.New("invalid profile")
 
+// extraParams returns any auth params which are not in the list of params
+// known to a handler, like `audience` or `resource`. These are forwarded to
+// the provider as-is when requesting tokens.
+func extraParams(params map[string]string, known ...string) url.Values {
+	values := url.Values{}
+
+outer:
+	for k, v := range params {
+		for _, name := range known {
+			if k == name {
+				// Not a custom param...
+				continue outer
+			}
+		}
+
+		values.Add(k, v)
+	}
+
+	return values
+}
+
+// withScopes applies the `--rsh-scopes` override, if set, to a copy of the
+// auth params.
+func withScopes(params map[string]string) map[string]string {
+	scopes := viper.GetString("rsh-scopes")
+	if scopes == "" {
+		return params
+	}
+
+	modified := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		modified[k] = v
+	}
+	modified["scopes"] = scopes
+
+	return modified
+}
+
+// cacheKey returns the token cache key for a profile key and its auth params.
+// The params which affect the issued token (e.g. scopes or audience) are
+// hashed into the key so that changing them never reuses a stale token.
+func cacheKey(key string, params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for k := range params {
+		if k == "client_secret" || k == "redirect_url" || k == "introspection_url" || k == "revocation_url" {
+			// These do not change the token that gets issued.
+			continue
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, k := range names {
+		fmt.Fprintf(hash, "%s=%s\n", k, params[k])
+	}
+
+	return key + ":" + hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
 // TokenHandler takes a token source, gets a token, and modifies a request to
 // add the token auth as a header. Uses the CLI cache to store tokens on a per-
 // profile basis between runs.
@@ -71,3 +138,13 @@ func TokenHandler(source oauth2.TokenSource, key string, request *http.Request)
 	token.SetAuthHeader(request)
 	return nil
 }
+
+// InvalidateToken removes a cached access token so the next request gets a new
+// one. Any refresh token is kept so it can be used to get the new token.
+func InvalidateToken(key string) error {
+	cli.LogDebug("Invalidating cached OAuth2 token.")
+	cli.Cache.Set(key+".expires", time.Time{})
+	cli.Cache.Set(key+".type", "")
+	cli.Cache.Set(key+".token", "")
+	return cli.Cache.WriteConfig()
+}
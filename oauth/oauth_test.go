@@ -0,0 +1,366 @@
+package oauth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/restish/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenServer returns a test token endpoint which records the parsed form of
+// each token request.
+func tokenServer(t *testing.T, forms *[]url.Values) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		*forms = append(*forms, r.PostForm)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token_type": "Bearer", "access_token": "abc123", "expires_in": 3600}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExtraParams(t *testing.T) {
+	values := extraParams(map[string]string{
+		"client_id": "id",
+		"audience":  "aud",
+		"resource":  "https://api.example.com/",
+	}, "client_id")
+
+	assert.Equal(t, url.Values{
+		"audience": []string{"aud"},
+		"resource": []string{"https://api.example.com/"},
+	}, values)
+}
+
+func TestClientCredentialsParams(t *testing.T) {
+	cli.Init("test", "1.0.0")
+
+	forms := []url.Values{}
+	server := tokenServer(t, &forms)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+	err := (&ClientCredentialsHandler{}).OnRequest(req, "client-creds-test:default", map[string]string{
+		"client_id":     "id",
+		"client_secret": "secret",
+		"token_url":     server.URL,
+		"audience":      "aud",
+		"resource":      "https://api.example.com/",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer abc123", req.Header.Get("Authorization"))
+	if assert.Len(t, forms, 1) {
+		assert.Equal(t, "aud", forms[0].Get("audience"))
+		assert.Equal(t, "https://api.example.com/", forms[0].Get("resource"))
+		assert.Empty(t, forms[0].Get("token_url"))
+	}
+}
+
+func TestAuthorizationCodeParams(t *testing.T) {
+	cli.Init("test", "1.0.0")
+
+	forms := []url.Values{}
+	server := tokenServer(t, &forms)
+
+	redirect := "http://localhost:18484"
+	var authorize url.Values
+
+	orig := cli.OpenBrowser
+	defer func() { cli.OpenBrowser = orig }()
+	cli.OpenBrowser = func(u string) error {
+		// Simulate the user logging in and being redirected with a code.
+		parsed, _ := url.Parse(u)
+		authorize = parsed.Query()
+		go func() {
+			// The local redirect server may not be listening quite yet.
+			for i := 0; i < 50; i++ {
+				if resp, err := http.Get(redirect + "?code=xyz"); err == nil {
+					resp.Body.Close()
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+		return nil
+	}
+
+	source := &AuthorizationCodeTokenSource{
+		ClientID:       "id",
+		AuthorizeURL:   "https://auth.example.com/authorize",
+		TokenURL:       server.URL,
+		RedirectURL:    redirect,
+		EndpointParams: &url.Values{"audience": []string{"aud"}},
+	}
+
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token.AccessToken)
+
+	assert.Equal(t, "aud", authorize.Get("audience"))
+	assert.Equal(t, "S256", authorize.Get("code_challenge_method"))
+	if assert.Len(t, forms, 1) {
+		assert.Equal(t, "xyz", forms[0].Get("code"))
+		assert.Equal(t, "aud", forms[0].Get("audience"))
+	}
+
+	// Refreshing the token also includes the params.
+	refresh := &RefreshTokenSource{
+		ClientID:       "id",
+		TokenURL:       server.URL,
+		EndpointParams: source.EndpointParams,
+		RefreshToken:   "refresh",
+	}
+
+	_, err = refresh.Token()
+	assert.NoError(t, err)
+	if assert.Len(t, forms, 2) {
+		assert.Equal(t, "refresh_token", forms[1].Get("grant_type"))
+		assert.Equal(t, "aud", forms[1].Get("audience"))
+	}
+}
+
+func TestAuthorizationCodeRedirectURL(t *testing.T) {
+	forms := []url.Values{}
+	server := tokenServer(t, &forms)
+
+	redirect := "http://127.0.0.1:18485/callback"
+	var authorize url.Values
+	var favicon int
+
+	orig := cli.OpenBrowser
+	defer func() { cli.OpenBrowser = orig }()
+	cli.OpenBrowser = func(u string) error {
+		parsed, _ := url.Parse(u)
+		authorize = parsed.Query()
+		go func() {
+			// Other paths must not be treated as the redirect.
+			if resp, err := http.Get("http://127.0.0.1:18485/favicon.ico"); err == nil {
+				favicon = resp.StatusCode
+				resp.Body.Close()
+			}
+			if resp, err := http.Get(redirect + "?code=xyz"); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	source := &AuthorizationCodeTokenSource{
+		ClientID:     "id",
+		AuthorizeURL: "https://auth.example.com/authorize",
+		TokenURL:     server.URL,
+		RedirectURL:  redirect,
+	}
+
+	_, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, redirect, authorize.Get("redirect_uri"))
+	assert.Equal(t, http.StatusNotFound, favicon)
+	if assert.Len(t, forms, 1) {
+		assert.Equal(t, redirect, forms[0].Get("redirect_uri"))
+	}
+
+	// Invalid redirect URLs fail before anything else happens.
+	for _, invalid := range []string{"localhost:8484", "https://localhost:8484", "http://:8484", "http://local host"} {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+		err = (&AuthorizationCodeHandler{}).OnRequest(req, "redirect-test:default", map[string]string{
+			"client_id":     "id",
+			"authorize_url": "https://auth.example.com/authorize",
+			"token_url":     server.URL,
+			"redirect_url":  invalid,
+		})
+		assert.ErrorContains(t, err, "invalid redirect_url", invalid)
+	}
+
+	// A port which is in use gives a helpful error.
+	_, err = (&AuthorizationCodeTokenSource{
+		ClientID:     "id",
+		AuthorizeURL: "https://auth.example.com/authorize",
+		TokenURL:     server.URL,
+		RedirectURL:  "http://" + server.Listener.Addr().String(),
+	}).Token()
+	assert.ErrorContains(t, err, "set the redirect_url auth param")
+}
+
+func TestDeviceCode(t *testing.T) {
+	cli.Init("test", "1.0.0")
+
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	var device url.Values
+	polls := []url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/device" {
+			device = r.PostForm
+			w.Write([]byte(`{"device_code": "dev123", "user_code": "ABCD-EFGH", "verification_uri": "https://example.com/activate", "interval": 1, "expires_in": 600}`))
+			return
+		}
+
+		polls = append(polls, r.PostForm)
+		switch len(polls) {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "authorization_pending"}`))
+		case 2:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "slow_down"}`))
+		default:
+			w.Write([]byte(`{"token_type": "Bearer", "access_token": "abc123", "refresh_token": "def456", "expires_in": 3600}`))
+		}
+	}))
+	defer server.Close()
+
+	key := "device-code-test:default"
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+	err := (&DeviceCodeHandler{}).OnRequest(req, key, map[string]string{
+		"client_id":                "id",
+		"device_authorization_url": server.URL + "/device",
+		"token_url":                server.URL + "/token",
+		"scopes":                   "read,write",
+		"audience":                 "aud",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer abc123", req.Header.Get("Authorization"))
+	assert.Equal(t, "id", device.Get("client_id"))
+	assert.Equal(t, "read write", device.Get("scope"))
+	assert.Equal(t, "aud", device.Get("audience"))
+	assert.Equal(t, []time.Duration{time.Second, time.Second, 6 * time.Second}, waits)
+	if assert.Len(t, polls, 3) {
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:device_code", polls[2].Get("grant_type"))
+		assert.Equal(t, "dev123", polls[2].Get("device_code"))
+	}
+
+	// The refresh token is cached like the other flows.
+	assert.Equal(t, "def456", cli.Cache.GetString(cacheKey(key, map[string]string{
+		"client_id":                "id",
+		"device_authorization_url": server.URL + "/device",
+		"token_url":                server.URL + "/token",
+		"scopes":                   "read,write",
+		"audience":                 "aud",
+	})+".refresh"))
+}
+
+func TestDeviceCodeDenied(t *testing.T) {
+	sleep = func(d time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/device" {
+			w.Write([]byte(`{"device_code": "dev123", "user_code": "ABCD-EFGH", "verification_url": "https://example.com/activate"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "access_denied"}`))
+	}))
+	defer server.Close()
+
+	_, err := (&DeviceCodeTokenSource{
+		ClientID:               "id",
+		DeviceAuthorizationURL: server.URL + "/device",
+		TokenURL:               server.URL + "/token",
+	}).Token()
+	assert.ErrorContains(t, err, "denied")
+}
+
+func TestAuthInfo(t *testing.T) {
+	cli.Init("test", "1.0.0")
+
+	active := true
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		form = r.PostForm
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "id", user)
+		assert.Equal(t, "secret", pass)
+		w.Header().Set("Content-Type", "application/json")
+		if active {
+			w.Write([]byte(`{"active": true, "sub": "user2", "scope": "read"}`))
+		} else {
+			w.Write([]byte(`{"active": false}`))
+		}
+	}))
+	defer server.Close()
+
+	key := "auth-info-test:default"
+	params := map[string]string{
+		"client_id":         "id",
+		"client_secret":     "secret",
+		"token_url":         "https://example.com/token",
+		"introspection_url": server.URL,
+	}
+
+	// The introspection URL doesn't change which token gets used.
+	ck := cacheKey(key, params)
+	assert.Equal(t, cacheKey(key, map[string]string{"client_id": "id", "token_url": "https://example.com/token"}), ck)
+
+	h := &ClientCredentialsHandler{}
+	_, err := h.AuthInfo(key, params)
+	assert.ErrorContains(t, err, "no cached token")
+
+	// JWTs are decoded locally.
+	jwt := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user1","scp":["read","write"],"exp":2000000000}`)) + ".sig"
+	cli.Cache.Set(ck+".token", jwt)
+	info, err := h.AuthInfo(key, params)
+	assert.NoError(t, err)
+	assert.False(t, info.Opaque)
+	assert.Equal(t, "RS256", info.Header["alg"])
+	assert.Equal(t, "user1", info.Claims["sub"])
+	assert.Nil(t, form)
+
+	// Opaque tokens are introspected.
+	cli.Cache.Set(ck+".token", "opaque123")
+	info, err = h.AuthInfo(key, params)
+	assert.NoError(t, err)
+	assert.True(t, info.Opaque)
+	assert.Equal(t, "user2", info.Claims["sub"])
+	assert.Equal(t, "opaque123", form.Get("token"))
+
+	active = false
+	_, err = h.AuthInfo(key, params)
+	assert.ErrorContains(t, err, "no longer active")
+
+	delete(params, "introspection_url")
+	_, err = h.AuthInfo(key, params)
+	assert.ErrorContains(t, err, "token is opaque")
+}
+
+func TestRevokeAuth(t *testing.T) {
+	cli.Init("test", "1.0.0")
+
+	forms := []url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		forms = append(forms, r.PostForm)
+	}))
+	defer server.Close()
+
+	key := "revoke-test:default"
+	cli.Cache.Set(key+":abc.token", "access1")
+	cli.Cache.Set(key+":abc.refresh", "refresh1")
+
+	// Nothing happens without a revocation URL.
+	h := &AuthorizationCodeHandler{}
+	assert.NoError(t, h.RevokeAuth(key, map[string]string{"client_id": "id"}))
+	assert.Empty(t, forms)
+
+	// Public clients send their ID in the form.
+	assert.NoError(t, h.RevokeAuth(key, map[string]string{"client_id": "id", "revocation_url": server.URL}))
+	if assert.Len(t, forms, 2) {
+		assert.Equal(t, url.Values{"token": {"refresh1"}, "token_type_hint": {"refresh_token"}, "client_id": {"id"}}, forms[0])
+		assert.Equal(t, url.Values{"token": {"access1"}, "token_type_hint": {"access_token"}, "client_id": {"id"}}, forms[1])
+	}
+}
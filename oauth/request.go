@@ -23,6 +23,18 @@ type tokenResponse struct {
 	Expiry       time.Time     `json:"expiry,omitempty"`
 }
 
+// TokenError is returned when the token endpoint responds with an error. The
+// `Code` is the OAuth 2.0 error code, e.g. `invalid_grant`, if available.
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Body        []byte `json:"-"`
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("bad response from token endpoint:\n%s", e.Body)
+}
+
 // requestToken from the given URL with the given payload. This can be used
 // for many different grant types and will return a parsed token.
 func requestToken(tokenURL, payload string) (*oauth2.Token, error) {
@@ -45,7 +57,9 @@ func requestToken(tokenURL, payload string) (*oauth2.Token, error) {
 	body, _ := io.ReadAll(res.Body)
 
 	if res.StatusCode > 200 {
-		return nil, fmt.Errorf("bad response from token endpoint:\n%s", body)
+		tokenErr := &TokenError{Body: body}
+		json.Unmarshal(body, tokenErr)
+		return nil, tokenErr
 	}
 
 	decoded := tokenResponse{}
@@ -231,3 +231,63 @@ func genExampleInternal(s *base.Schema, mode schemaMode, known map[[32]byte]bool
 
 	return nil
 }
+
+// GenSeed creates request body seed data from a given schema. Unless `all` is
+// set, only required properties are included.
+func GenSeed(schema *base.Schema, all bool) any {
+	value := GenExample(schema, modeWrite)
+	if all {
+		return value
+	}
+	return requiredOnly(schema, value)
+}
+
+// requiredOnly removes any properties from a generated example value which
+// are not marked as required by the schema.
+func requiredOnly(s *base.Schema, value any) any {
+	if s == nil {
+		return value
+	}
+
+	if len(s.OneOf) > 0 {
+		return requiredOnly(sortedSchemas(s.OneOf)[0].Schema(), value)
+	}
+
+	if len(s.AnyOf) > 0 {
+		return requiredOnly(sortedSchemas(s.AnyOf)[0].Schema(), value)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		schemas := []*base.Schema{s}
+		for _, proxy := range sortedSchemas(s.AllOf) {
+			schemas = append(schemas, proxy.Schema())
+		}
+
+		result := map[string]any{}
+		for _, sub := range schemas {
+			for _, name := range sub.Required {
+				prop, ok := v[name]
+				if !ok {
+					continue
+				}
+				result[name] = prop
+				if proxy, ok := sub.Properties[name]; ok && proxy.Schema() != nil {
+					result[name] = requiredOnly(proxy.Schema(), prop)
+				}
+			}
+		}
+		return result
+	case []any:
+		if s.Items != nil && s.Items.IsA() {
+			items := s.Items.A.Schema()
+			result := make([]any, 0, len(v))
+			for _, item := range v {
+				result = append(result, requiredOnly(items, item))
+			}
+			return result
+		}
+	}
+
+	return value
+}
@@ -310,3 +310,48 @@ func TestExample(t *testing.T) {
 		})
 	}
 }
+
+func TestSeed(t *testing.T) {
+	var rootNode yaml.Node
+	var ls lowbase.Schema
+
+	in := `{
+		type: object,
+		required: [name, owner],
+		properties: {
+			id: {type: string, readOnly: true},
+			name: {type: string},
+			tags: {type: array, items: {type: string}},
+			owner: {
+				type: object,
+				required: [email],
+				properties: {
+					email: {type: string, format: email},
+					nickname: {type: string}
+				}
+			}
+		}
+	}`
+
+	require.NoError(t, yaml.Unmarshal([]byte(in), &rootNode))
+	require.NoError(t, low.BuildModel(rootNode.Content[0], &ls))
+	require.NoError(t, ls.Build(rootNode.Content[0], index.NewSpecIndex(&rootNode)))
+
+	s := base.NewSchema(&ls)
+
+	assert.Equal(t, map[string]any{
+		"name": "string",
+		"owner": map[string]any{
+			"email": "user@example.com",
+		},
+	}, GenSeed(s, false))
+
+	assert.Equal(t, map[string]any{
+		"name": "string",
+		"tags": []any{"string"},
+		"owner": map[string]any{
+			"email":    "user@example.com",
+			"nickname": "string",
+		},
+	}, GenSeed(s, true))
+}
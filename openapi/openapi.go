@@ -50,6 +50,13 @@ const (
 
 	// Custom auto-configuration for CLIs
 	ExtCLIConfig = "x-cli-config"
+
+	// Link to the API's documentation or web UI, overriding `externalDocs`
+	ExtDocs = "x-cli-docs"
+
+	// Documented rate limit for an operation, e.g. `10/s` or `100/minute`,
+	// used to pace requests and avoid hitting the limit.
+	ExtRateLimit = "x-cli-ratelimit"
 )
 
 type autoConfig struct {
@@ -103,7 +110,7 @@ func getExtSlice[T any](v map[string]any, key string, def []T) []T {
 // getBasePath returns the basePath to which the operation paths need to be appended (if any)
 // It assumes the open-api description has been validated before: the casts should always succeed
 // if the description adheres to the openapi spec schema.
-func getBasePath(location *url.URL, servers []*v3.Server) (string, error) {
+func getBasePath(location *url.URL, servers []*v3.Server, vars func(name string) (string, bool)) (string, error) {
 	prefix := fmt.Sprintf("%s://%s", location.Scheme, location.Host)
 
 	for _, s := range servers {
@@ -118,7 +125,12 @@ func getBasePath(location *url.URL, servers []*v3.Server) (string, error) {
 		endpoints := []string{s.URL}
 		for k, v := range s.Variables {
 			key := fmt.Sprintf("{%s}", k)
-			if len(v.Enum) == 0 {
+			if value, ok := vars(k); ok {
+				// The user picked a value for this variable.
+				for i := range endpoints {
+					endpoints[i] = strings.ReplaceAll(endpoints[i], key, value)
+				}
+			} else if len(v.Enum) == 0 {
 				for i := range endpoints {
 					endpoints[i] = strings.ReplaceAll(
 						endpoints[i],
@@ -201,6 +213,22 @@ func getRequestInfo(op *v3.Operation) (string, *base.Schema, []interface{}) {
 	return "", nil, nil
 }
 
+// getResponseSchema returns the schema of a response body, preferring JSON
+// then YAML media types, or nil if the response has no schema.
+func getResponseSchema(resp *v3.Response) *base.Schema {
+	mts := maps.Keys(resp.Content)
+	sort.Strings(mts)
+	for _, short := range []string{"json", "yaml", ""} {
+		for _, mt := range mts {
+			item := resp.Content[mt]
+			if strings.Contains(mt, short) && item.Schema != nil && item.Schema.Schema() != nil {
+				return item.Schema.Schema()
+			}
+		}
+	}
+	return nil
+}
+
 // paramSchema returns a rendered schema line for a given parameter, falling
 // back to the param type info if no schema is available.
 func paramSchema(p *cli.Param, s *base.Schema) string {
@@ -236,6 +264,7 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 
 		var def interface{}
 		var example interface{}
+		var enum []any
 
 		typ := "string"
 		var schema *base.Schema
@@ -258,6 +287,7 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 
 			def = s.Default
 			example = s.Example
+			enum = s.Enum
 		}
 
 		if p.Example != nil {
@@ -280,12 +310,18 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 			Style:       style,
 			Default:     def,
 			Example:     example,
+			Enum:        enum,
 		}
 
 		if p.Explode != nil {
 			param.Explode = *p.Explode
 		}
 
+		if p.Required && p.In != "path" {
+			// Path params are always required, so only track this for others.
+			param.Required = true
+		}
+
 		switch p.In {
 		case "path":
 			if pathParams == nil {
@@ -325,6 +361,7 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 
 	desc := getExt(op.Extensions, ExtDescription, op.Description)
 	hidden := getExt(op.Extensions, ExtHidden, false)
+	rateLimit := getExt(op.Extensions, ExtRateLimit, "")
 
 	if len(pathParams) > 0 {
 		desc += "\n## Argument Schema:\n```schema\n{\n"
@@ -347,10 +384,23 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 
 	mediaType := ""
 	var examples []string
+	var seed, seedAll, bodySchema string
 	if op.RequestBody != nil {
 		mt, reqSchema, reqExamples := getRequestInfo(op)
 		mediaType = mt
 
+		if reqSchema != nil {
+			if b, err := json.Marshal(GenSeed(reqSchema, false)); err == nil {
+				seed = string(b)
+			}
+			if b, err := json.Marshal(GenSeed(reqSchema, true)); err == nil {
+				seedAll = string(b)
+			}
+			if b, err := json.Marshal(schemaDocument(reqSchema, map[[32]byte]bool{})); err == nil {
+				bodySchema = string(b)
+			}
+		}
+
 		if len(reqExamples) > 0 {
 			wroteHeader := false
 			for _, ex := range reqExamples {
@@ -429,6 +479,7 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 		schema *base.Schema
 	}
 	schemaMap := map[[32]byte][]schemaEntry{}
+	var responseSchemas map[string]string
 	for _, code := range codes {
 		var resp *v3.Response
 		if respMap[code] == nil {
@@ -437,6 +488,15 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 
 		resp = respMap[code]
 
+		if s := getResponseSchema(resp); s != nil {
+			if b, err := json.Marshal(schemaDocument(s, map[[32]byte]bool{})); err == nil {
+				if responseSchemas == nil {
+					responseSchemas = map[string]string{}
+				}
+				responseSchemas[code] = string(b)
+			}
+		}
+
 		hash := [32]byte{}
 		if len(resp.Content) > 0 {
 			for ct, typeInfo := range resp.Content {
@@ -532,6 +592,10 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 	dep := ""
 	if op.Deprecated != nil && *op.Deprecated {
 		dep = "do not use"
+		// A CLI-specific description can explain what to use instead.
+		if note := strings.TrimSpace(strings.Split(getExt(op.Extensions, ExtDescription, ""), "\n")[0]); note != "" {
+			dep = note
+		}
 	}
 
 	return cli.Operation{
@@ -546,9 +610,15 @@ func openapiOperation(cmd *cobra.Command, method string, uriTemplate *url.URL, p
 		QueryParams:   queryParams,
 		HeaderParams:  headerParams,
 		BodyMediaType: mediaType,
+		BodySchema:    bodySchema,
 		Examples:      examples,
+		Seed:          seed,
+		SeedAll:       seedAll,
 		Hidden:        hidden,
 		Deprecated:    dep,
+		RateLimit:     rateLimit,
+
+		ResponseSchemas: responseSchemas,
 	}
 }
 
@@ -573,6 +643,16 @@ func loadOpenAPI3(cfg Resolver, cmd *cobra.Command, location *url.URL, resp *htt
 		return cli.API{}, err
 	}
 
+	if info := doc.GetSpecInfo(); info.SpecType == utils.OpenApi2 && info.Version == "2.0" {
+		// Swagger 2.0 documents are converted to OpenAPI 3 and loaded as such.
+		if data, err = convertSwagger(data); err != nil {
+			return cli.API{}, err
+		}
+		if doc, err = libopenapi.NewDocumentWithConfiguration(data, config); err != nil {
+			return cli.API{}, err
+		}
+	}
+
 	var model v3.Document
 	switch doc.GetSpecInfo().SpecType {
 	case utils.OpenApi3:
@@ -593,7 +673,9 @@ func loadOpenAPI3(cfg Resolver, cmd *cobra.Command, location *url.URL, resp *htt
 	}
 
 	// See if this server has any base path prefix we need to account for.
-	basePath, err := getBasePath(cfg.GetBase(), model.Servers)
+	basePath, err := getBasePath(cfg.GetBase(), model.Servers, func(name string) (string, bool) {
+		return cli.LookupVar(cfg.GetBase().String(), name)
+	})
 	if err != nil {
 		return cli.API{}, err
 	}
@@ -629,7 +711,14 @@ func loadOpenAPI3(cfg Resolver, cmd *cobra.Command, location *url.URL, resp *htt
 			scheme := model.Components.SecuritySchemes[key]
 			switch scheme.Type {
 			case "apiKey":
-				// TODO: api key auth
+				authSchemes = append(authSchemes, cli.APIAuth{
+					Name: "api-key",
+					Params: map[string]string{
+						"name":  scheme.Name,
+						"in":    scheme.In,
+						"value": "",
+					},
+				})
 			case "http":
 				if scheme.Scheme == "basic" {
 					authSchemes = append(authSchemes, cli.APIAuth{
@@ -639,8 +728,18 @@ func loadOpenAPI3(cfg Resolver, cmd *cobra.Command, location *url.URL, resp *htt
 							"password": "",
 						},
 					})
+				} else if strings.EqualFold(scheme.Scheme, "bearer") {
+					params := map[string]string{
+						"token": "",
+					}
+					if scheme.BearerFormat != "" {
+						params["token_format"] = scheme.BearerFormat
+					}
+					authSchemes = append(authSchemes, cli.APIAuth{
+						Name:   "http-bearer",
+						Params: params,
+					})
 				}
-				// TODO: bearer
 			case "oauth2":
 				flows := scheme.Flows
 				if flows != nil {
@@ -681,9 +780,16 @@ func loadOpenAPI3(cfg Resolver, cmd *cobra.Command, location *url.URL, resp *htt
 		long = getExt(model.Info.Extensions, ExtDescription, model.Info.Description)
 	}
 
+	docs := ""
+	if model.ExternalDocs != nil {
+		docs = model.ExternalDocs.URL
+	}
+	docs = getExt(model.Extensions, ExtDocs, docs)
+
 	api := cli.API{
 		Short:      short,
 		Long:       long,
+		DocsURL:    docs,
 		Operations: operations,
 	}
 
@@ -691,6 +797,26 @@ func loadOpenAPI3(cfg Resolver, cmd *cobra.Command, location *url.URL, resp *htt
 		api.Auth = authSchemes
 	}
 
+	// Servers with variables let the user choose e.g. a region on setup.
+	for _, server := range model.Servers {
+		if len(server.Variables) == 0 {
+			continue
+		}
+
+		vars := map[string]cli.AutoConfigVar{}
+		for name, v := range server.Variables {
+			av := cli.AutoConfigVar{Description: v.Description}
+			if v.Default != "" {
+				av.Default = v.Default
+			}
+			for _, e := range v.Enum {
+				av.Enum = append(av.Enum, e)
+			}
+			vars[name] = av
+		}
+		api.Servers = append(api.Servers, cli.APIServer{URL: server.URL, Variables: vars})
+	}
+
 	loadAutoConfig(&api, &model)
 
 	return api, nil
@@ -723,9 +849,20 @@ func loadAutoConfig(api *cli.API, model *v3.Document) {
 
 		// Convert it to the Restish security type and set some default params.
 		switch scheme.Type {
+		case "apiKey":
+			authName = "api-key"
+			params["name"] = scheme.Name
+			params["in"] = scheme.In
+			params["value"] = ""
 		case "http":
 			if scheme.Scheme == "basic" {
 				authName = "http-basic"
+			} else if strings.EqualFold(scheme.Scheme, "bearer") {
+				authName = "http-bearer"
+				params["token"] = ""
+				if scheme.BearerFormat != "" {
+					params["token_format"] = scheme.BearerFormat
+				}
 			}
 		case "oauth2":
 			if scheme.Flows != nil {
@@ -781,7 +918,7 @@ func (l *loader) Resolve(relURI string) (*url.URL, error) {
 }
 
 func (l *loader) LocationHints() []string {
-	return []string{"/openapi.json", "/openapi.yaml", "openapi.json", "openapi.yaml"}
+	return []string{"/openapi.json", "/openapi.yaml", "openapi.json", "openapi.yaml", "/swagger.json", "/swagger.yaml"}
 }
 
 func (l *loader) Detect(resp *http.Response) bool {
@@ -794,7 +931,7 @@ func (l *loader) Detect(resp *http.Response) bool {
 	body, _ := io.ReadAll(resp.Body)
 	defer resp.Body.Close()
 
-	return reOpenAPI3.Match(body)
+	return reOpenAPI3.Match(body) || reSwagger2.Match(body)
 }
 
 func (l *loader) Load(entrypoint, spec url.URL, resp *http.Response) (cli.API, error) {
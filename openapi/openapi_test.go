@@ -136,7 +136,7 @@ func TestGetBasePath(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			output, err := getBasePath(tc.location, tc.servers)
+			output, err := getBasePath(tc.location, tc.servers, func(string) (string, bool) { return "", false })
 			if !tc.hasError {
 				if assert.NoError(t, err) {
 					assert.Equal(t, tc.output, output)
@@ -148,6 +148,26 @@ func TestGetBasePath(t *testing.T) {
 	}
 }
 
+func TestGetBasePathVars(t *testing.T) {
+	servers := []*v3.Server{
+		{
+			URL: "https://{region}.api.example.com/{version}",
+			Variables: map[string]*v3.ServerVariable{
+				"region":  {Default: "us", Enum: []string{"us", "eu"}},
+				"version": {Default: "v1"},
+			},
+		},
+	}
+
+	vars := map[string]string{"version": "v2"}
+	output, err := getBasePath(parseURL("https://eu.api.example.com"), servers, func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2", output)
+}
+
 func TestDetectViaHeader(t *testing.T) {
 	resp := http.Response{
 		Header: http.Header{},
@@ -168,8 +188,19 @@ func TestDetectViaBody(t *testing.T) {
 	assert.True(t, loader.Detect(&resp))
 }
 
+func TestDetectSwagger(t *testing.T) {
+	resp := http.Response{
+		Header: http.Header{},
+		Body:   io.NopCloser(strings.NewReader(`{"swagger": "2.0"}`)),
+	}
+
+	loader := New()
+	assert.True(t, loader.Detect(&resp))
+}
+
 func TestLocationHints(t *testing.T) {
 	assert.Contains(t, New().LocationHints(), "/openapi.json")
+	assert.Contains(t, New().LocationHints(), "/swagger.json")
 }
 
 func TestBrokenRequest(t *testing.T) {
@@ -201,7 +232,7 @@ func TestUnsupported(t *testing.T) {
 	spec, _ := url.Parse("/openapi.yaml")
 
 	resp := &http.Response{
-		Body: io.NopCloser(strings.NewReader(`swagger: 2.0`)),
+		Body: io.NopCloser(strings.NewReader(`swagger: "1.2"`)),
 	}
 
 	_, err := New().Load(*base, *spec, resp)
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/spf13/viper"
 )
 
 type schemaMode int
@@ -53,10 +54,17 @@ func sortedSchemas(s []*base.SchemaProxy) []*base.SchemaProxy {
 }
 
 func renderSchema(s *base.Schema, indent string, mode schemaMode) string {
-	return renderSchemaInternal(s, indent, mode, map[[32]byte]bool{})
+	return renderSchemaInternal(s, indent, mode, map[[32]byte]int{})
 }
 
-func renderSchemaInternal(s *base.Schema, indent string, mode schemaMode, known map[[32]byte]bool) string {
+// canExpand returns whether a schema can be rendered at the current position.
+// Known tracks how many times each schema is currently being rendered up the
+// stack; recursive references are expanded up to `rsh-expand-refs` times.
+func canExpand(s *base.Schema, hash [32]byte, known map[[32]byte]int) bool {
+	return isSimpleSchema(s) || known[hash] <= viper.GetInt("rsh-expand-refs")
+}
+
+func renderSchemaInternal(s *base.Schema, indent string, mode schemaMode, known map[[32]byte]int) string {
 	doc := s.Title
 	if doc == "" {
 		doc = s.Description
@@ -166,12 +174,11 @@ func renderSchemaInternal(s *base.Schema, indent string, mode schemaMode, known
 	case "array":
 		if s.Items != nil && s.Items.IsA() {
 			items := s.Items.A.Schema()
-			simple := isSimpleSchema(items)
 			hash := items.GoLow().Hash()
-			if simple || !known[hash] {
-				known[hash] = true
+			if canExpand(items, hash, known) {
+				known[hash]++
 				arr := "[\n  " + indent + renderSchemaInternal(items, indent+"  ", mode, known) + "\n" + indent + "]"
-				known[hash] = false
+				known[hash]--
 				return arr
 			}
 
@@ -211,12 +218,11 @@ func renderSchemaInternal(s *base.Schema, indent string, mode schemaMode, known
 				}
 			}
 
-			simple := isSimpleSchema(prop)
 			hash := prop.GoLow().Hash()
-			if simple || !known[hash] {
-				known[hash] = true
+			if canExpand(prop, hash, known) {
+				known[hash]++
 				obj += indent + "  " + name + ": " + renderSchemaInternal(prop, indent+"  ", mode, known) + "\n"
-				known[hash] = false
+				known[hash]--
 			} else {
 				obj += indent + "  " + name + ": <rescurive ref>\n"
 			}
@@ -226,11 +232,11 @@ func renderSchemaInternal(s *base.Schema, indent string, mode schemaMode, known
 			ap := s.AdditionalProperties
 			if sp, ok := ap.(*base.SchemaProxy); ok {
 				addl := sp.Schema()
-				simple := isSimpleSchema(addl)
 				hash := addl.GoLow().Hash()
-				if simple || !known[hash] {
-					known[hash] = true
+				if canExpand(addl, hash, known) {
+					known[hash]++
 					obj += indent + "  " + "<any>: " + renderSchemaInternal(addl, indent+"  ", mode, known) + "\n"
+					known[hash]--
 				} else {
 					obj += indent + "  <any>: <rescurive ref>\n"
 				}
@@ -246,3 +252,111 @@ func renderSchemaInternal(s *base.Schema, indent string, mode schemaMode, known
 
 	return "<any>"
 }
+
+// schemaDocument converts a request schema into a JSON Schema document which
+// can be stored with the operation and used to validate request bodies.
+// Read-only properties are not required and recursive references accept
+// anything.
+func schemaDocument(s *base.Schema, known map[[32]byte]bool) map[string]any {
+	hash := s.GoLow().Hash()
+	if known[hash] {
+		return map[string]any{}
+	}
+	known[hash] = true
+	defer delete(known, hash)
+
+	doc := map[string]any{}
+	set := func(key string, value any, ok bool) {
+		if ok {
+			doc[key] = value
+		}
+	}
+
+	set("type", s.Type, len(s.Type) > 0)
+	set("format", s.Format, s.Format != "")
+	set("enum", s.Enum, len(s.Enum) > 0)
+	set("pattern", s.Pattern, s.Pattern != "")
+	set("nullable", s.Nullable, s.Nullable != nil)
+	set("minimum", s.Minimum, s.Minimum != nil)
+	set("maximum", s.Maximum, s.Maximum != nil)
+	set("multipleOf", s.MultipleOf, s.MultipleOf != nil)
+	set("minLength", s.MinLength, s.MinLength != nil)
+	set("maxLength", s.MaxLength, s.MaxLength != nil)
+	set("minItems", s.MinItems, s.MinItems != nil)
+	set("maxItems", s.MaxItems, s.MaxItems != nil)
+	set("uniqueItems", s.UniqueItems, s.UniqueItems != nil)
+	set("minProperties", s.MinProperties, s.MinProperties != nil)
+	set("maxProperties", s.MaxProperties, s.MaxProperties != nil)
+
+	for key, value := range map[string]*base.DynamicValue[bool, float64]{
+		"exclusiveMinimum": s.ExclusiveMinimum,
+		"exclusiveMaximum": s.ExclusiveMaximum,
+	} {
+		if value != nil {
+			if value.IsA() {
+				doc[key] = value.A
+			} else {
+				doc[key] = value.B
+			}
+		}
+	}
+
+	for key, schemas := range map[string][]*base.SchemaProxy{
+		"allOf": s.AllOf,
+		"anyOf": s.AnyOf,
+		"oneOf": s.OneOf,
+	} {
+		if len(schemas) > 0 {
+			list := []any{}
+			for _, sub := range schemas {
+				list = append(list, schemaDocument(sub.Schema(), known))
+			}
+			doc[key] = list
+		}
+	}
+
+	if s.Not != nil {
+		doc["not"] = schemaDocument(s.Not.Schema(), known)
+	}
+
+	if s.Items != nil {
+		if s.Items.IsA() {
+			doc["items"] = schemaDocument(s.Items.A.Schema(), known)
+		} else {
+			doc["items"] = s.Items.B
+		}
+	}
+
+	readOnly := map[string]bool{}
+	if len(s.Properties) > 0 {
+		props := map[string]any{}
+		for name, prop := range s.Properties {
+			ps := prop.Schema()
+			if ps == nil {
+				continue
+			}
+			if ps.ReadOnly {
+				readOnly[name] = true
+			}
+			props[name] = schemaDocument(ps, known)
+		}
+		doc["properties"] = props
+	}
+
+	required := []string{}
+	for _, name := range s.Required {
+		if !readOnly[name] {
+			required = append(required, name)
+		}
+	}
+	set("required", required, len(required) > 0)
+
+	switch ap := s.AdditionalProperties.(type) {
+	case bool:
+		doc["additionalProperties"] = ap
+	case *base.SchemaProxy:
+		doc["additionalProperties"] = schemaDocument(ap.Schema(), known)
+	}
+
+	return doc
+}
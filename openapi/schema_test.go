@@ -7,16 +7,18 @@ import (
 	"github.com/pb33f/libopenapi/datamodel/low"
 	lowbase "github.com/pb33f/libopenapi/datamodel/low/base"
 	"github.com/pb33f/libopenapi/index"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
 
 var schemaTests = []struct {
-	name string
-	mode schemaMode
-	in   string
-	out  string
+	name   string
+	mode   schemaMode
+	expand int
+	in     string
+	out    string
 }{
 	{
 		name: "guess-array",
@@ -180,6 +182,12 @@ var schemaTests = []struct {
 		in:   `{type: object, properties: {person: {type: object, properties: {friend: {type: object, additionalProperties: {$ref: "#/properties/person"}}}}}}`,
 		out:  "{\n  person: {\n    friend: {\n      <any>: <rescurive ref>\n    }\n  }\n}",
 	},
+	{
+		name:   "recursive-prop-expanded",
+		expand: 1,
+		in:     `{type: object, properties: {person: {type: object, properties: {friend: {$ref: "#/properties/person"}}}}}`,
+		out:    "{\n  person: {\n    friend: {\n      friend: <rescurive ref>\n    }\n  }\n}",
+	},
 }
 
 func TestSchema(t *testing.T) {
@@ -194,8 +202,38 @@ func TestSchema(t *testing.T) {
 
 			// spew.Dump(ls)
 
+			viper.Set("rsh-expand-refs", example.expand)
+			defer viper.Set("rsh-expand-refs", 0)
+
 			s := base.NewSchema(&ls)
 			assert.Equal(t, example.out, renderSchema(s, "", example.mode))
 		})
 	}
 }
+
+func TestSchemaDocument(t *testing.T) {
+	var rootNode yaml.Node
+	var ls lowbase.Schema
+
+	in := `{type: object, required: [id, name], properties: {id: {type: string, readOnly: true}, name: {type: string, minLength: 1}, age: {type: integer, minimum: 0, exclusiveMinimum: true}, friend: {$ref: "#/properties/person"}, person: {type: object, properties: {friend: {$ref: "#/properties/person"}}}}}`
+	require.NoError(t, yaml.Unmarshal([]byte(in), &rootNode))
+	require.NoError(t, low.BuildModel(rootNode.Content[0], &ls))
+	require.NoError(t, ls.Build(rootNode.Content[0], index.NewSpecIndex(&rootNode)))
+
+	doc := schemaDocument(base.NewSchema(&ls), map[[32]byte]bool{})
+
+	// Read-only fields are not required in requests.
+	assert.Equal(t, []string{"name"}, doc["required"])
+
+	props := doc["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": []string{"string"}, "minLength": ptr(int64(1))}, props["name"])
+	assert.Equal(t, true, props["age"].(map[string]any)["exclusiveMinimum"])
+
+	// Recursive references accept anything.
+	person := props["person"].(map[string]any)
+	assert.Equal(t, map[string]any{}, person["properties"].(map[string]any)["friend"])
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
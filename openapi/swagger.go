@@ -0,0 +1,520 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reSwagger2 is a regex used to detect Swagger 2.0 files from their contents.
+var reSwagger2 = regexp.MustCompile(`['"]?swagger['"]?\s*:\s*['"]?2\.0`)
+
+// swaggerSchemaFields are the Swagger 2.0 parameter & header fields which
+// describe the value, and which live in a schema in OpenAPI 3.
+var swaggerSchemaFields = map[string]bool{
+	"type": true, "format": true, "items": true, "default": true, "enum": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true,
+	"exclusiveMaximum": true, "minLength": true, "maxLength": true,
+	"pattern": true, "minItems": true, "maxItems": true, "uniqueItems": true,
+	"multipleOf": true,
+}
+
+// swaggerMethods are the operations a Swagger 2.0 path item may have.
+var swaggerMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "options": true,
+	"head": true, "patch": true,
+}
+
+// swaggerFlows maps Swagger 2.0 OAuth 2.0 flow names to OpenAPI 3 ones.
+var swaggerFlows = map[string]string{
+	"implicit":    "implicit",
+	"password":    "password",
+	"application": "clientCredentials",
+	"accessCode":  "authorizationCode",
+}
+
+// swaggerConverter converts a Swagger 2.0 document into OpenAPI 3.0.
+type swaggerConverter struct {
+	doc      map[string]any
+	consumes []string
+	produces []string
+}
+
+// convertSwagger converts a Swagger 2.0 document into an equivalent OpenAPI
+// 3.0 document, so that it can be loaded the same way. See
+// https://swagger.io/specification/v2/ for the differences.
+func convertSwagger(data []byte) ([]byte, error) {
+	var parsed any
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	doc, ok := stringKeys(parsed).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid Swagger 2.0 document")
+	}
+
+	c := &swaggerConverter{
+		doc:      doc,
+		consumes: stringList(doc["consumes"], "application/json"),
+		produces: stringList(doc["produces"], "application/json"),
+	}
+
+	return json.Marshal(c.convert())
+}
+
+// stringKeys converts YAML maps with non-string keys, like response status
+// codes, into maps with string keys so they can be encoded as JSON.
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprintf("%v", k)] = stringKeys(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return value
+}
+
+// stringList returns a list of strings, or the default if empty.
+func stringList(value any, def ...string) []string {
+	result := []string{}
+	if list, ok := value.([]any); ok {
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return def
+	}
+	return result
+}
+
+// swaggerRef rewrites a reference to a Swagger 2.0 component to its OpenAPI
+// 3 location.
+func swaggerRef(ref string) string {
+	ref = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+	ref = strings.Replace(ref, "#/parameters/", "#/components/parameters/", 1)
+	ref = strings.Replace(ref, "#/responses/", "#/components/responses/", 1)
+	return ref
+}
+
+// schema converts a schema and all its nested schemas.
+func (c *swaggerConverter) schema(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			switch k {
+			case "$ref":
+				if ref, ok := item.(string); ok {
+					item = swaggerRef(ref)
+				}
+			case "discriminator":
+				if name, ok := item.(string); ok {
+					item = map[string]any{"propertyName": name}
+				}
+			case "x-nullable":
+				k = "nullable"
+			}
+			out[k] = c.schema(item)
+		}
+		if out["type"] == "file" {
+			out["type"] = "string"
+			out["format"] = "binary"
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = c.schema(item)
+		}
+		return out
+	}
+	return value
+}
+
+// valueSchema moves the schema fields of a parameter or header into a schema,
+// returning the remaining fields and the schema.
+func (c *swaggerConverter) valueSchema(value map[string]any) (map[string]any, map[string]any) {
+	out := map[string]any{}
+	schema := map[string]any{}
+	for k, v := range value {
+		if swaggerSchemaFields[k] {
+			schema[k] = v
+		} else if k != "collectionFormat" {
+			out[k] = v
+		}
+	}
+	return out, c.schema(schema).(map[string]any)
+}
+
+// resolveParam returns the parameter a local reference points to.
+func (c *swaggerConverter) resolveParam(p map[string]any) map[string]any {
+	if ref, ok := p["$ref"].(string); ok && strings.HasPrefix(ref, "#/parameters/") {
+		params, _ := c.doc["parameters"].(map[string]any)
+		if resolved, ok := params[strings.TrimPrefix(ref, "#/parameters/")].(map[string]any); ok {
+			return resolved
+		}
+	}
+	return p
+}
+
+// parameter converts a non-body parameter.
+func (c *swaggerConverter) parameter(p map[string]any) map[string]any {
+	if ref, ok := p["$ref"].(string); ok {
+		return map[string]any{"$ref": swaggerRef(ref)}
+	}
+
+	out, schema := c.valueSchema(p)
+	out["schema"] = schema
+
+	if schema["type"] == "array" {
+		switch p["collectionFormat"] {
+		case "multi":
+			out["style"] = "form"
+			out["explode"] = true
+		case "ssv":
+			out["style"] = "spaceDelimited"
+			out["explode"] = false
+		case "pipes":
+			out["style"] = "pipeDelimited"
+			out["explode"] = false
+		default:
+			// CSV is the default.
+			if p["in"] == "query" {
+				out["style"] = "form"
+				out["explode"] = false
+			}
+		}
+	}
+
+	return out
+}
+
+// requestBody converts body or form data parameters into a request body.
+func (c *swaggerConverter) requestBody(body map[string]any, form []map[string]any, consumes []string) map[string]any {
+	out := map[string]any{}
+	var schema any
+
+	if body != nil {
+		schema = c.schema(body["schema"])
+		if body["description"] != nil {
+			out["description"] = body["description"]
+		}
+		if body["required"] != nil {
+			out["required"] = body["required"]
+		}
+	} else {
+		properties := map[string]any{}
+		required := []any{}
+		hasFile := false
+		for _, p := range form {
+			name, _ := p["name"].(string)
+			_, s := c.valueSchema(p)
+			if p["description"] != nil {
+				s["description"] = p["description"]
+			}
+			if s["format"] == "binary" {
+				hasFile = true
+			}
+			properties[name] = s
+			if r, _ := p["required"].(bool); r {
+				required = append(required, name)
+			}
+		}
+
+		formSchema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			formSchema["required"] = required
+		}
+		schema = formSchema
+
+		formTypes := []string{}
+		for _, mt := range consumes {
+			if mt == "multipart/form-data" || mt == "application/x-www-form-urlencoded" {
+				formTypes = append(formTypes, mt)
+			}
+		}
+		if len(formTypes) == 0 {
+			formTypes = []string{"application/x-www-form-urlencoded"}
+			if hasFile {
+				formTypes = []string{"multipart/form-data"}
+			}
+		}
+		consumes = formTypes
+	}
+
+	content := map[string]any{}
+	for _, mt := range consumes {
+		content[mt] = map[string]any{"schema": schema}
+	}
+	out["content"] = content
+
+	return out
+}
+
+// response converts a response, moving its schema into content for each of
+// the media types the operation produces.
+func (c *swaggerConverter) response(r map[string]any, produces []string) map[string]any {
+	if ref, ok := r["$ref"].(string); ok {
+		return map[string]any{"$ref": swaggerRef(ref)}
+	}
+
+	out := map[string]any{}
+	for k, v := range r {
+		switch k {
+		case "schema", "examples":
+		case "headers":
+			headers := map[string]any{}
+			if m, ok := v.(map[string]any); ok {
+				for name, h := range m {
+					if hm, ok := h.(map[string]any); ok {
+						header, schema := c.valueSchema(hm)
+						header["schema"] = schema
+						headers[name] = header
+					}
+				}
+			}
+			out[k] = headers
+		default:
+			out[k] = v
+		}
+	}
+
+	if out["description"] == nil {
+		out["description"] = ""
+	}
+
+	if r["schema"] != nil {
+		examples, _ := r["examples"].(map[string]any)
+		content := map[string]any{}
+		for _, mt := range produces {
+			entry := map[string]any{"schema": c.schema(r["schema"])}
+			if ex, ok := examples[mt]; ok {
+				entry["example"] = ex
+			}
+			content[mt] = entry
+		}
+		out["content"] = content
+	}
+
+	return out
+}
+
+// operation converts an operation, including any body parameters inherited
+// from its path item.
+func (c *swaggerConverter) operation(op map[string]any, pathParams []any) map[string]any {
+	out := map[string]any{}
+	for k, v := range op {
+		switch k {
+		case "parameters", "responses", "consumes", "produces", "schemes":
+		default:
+			out[k] = v
+		}
+	}
+
+	consumes := stringList(op["consumes"], c.consumes...)
+	produces := stringList(op["produces"], c.produces...)
+
+	params := []any{}
+	var body map[string]any
+	form := []map[string]any{}
+	opParams, _ := op["parameters"].([]any)
+	for i, item := range append(append([]any{}, pathParams...), opParams...) {
+		p, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch c.resolveParam(p)["in"] {
+		case "body":
+			body = c.resolveParam(p)
+		case "formData":
+			form = append(form, c.resolveParam(p))
+		default:
+			// Path item params are converted once on the path item itself.
+			if i >= len(pathParams) {
+				params = append(params, c.parameter(p))
+			}
+		}
+	}
+
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if body != nil || len(form) > 0 {
+		out["requestBody"] = c.requestBody(body, form, consumes)
+	}
+
+	responses := map[string]any{}
+	if m, ok := op["responses"].(map[string]any); ok {
+		for code, r := range m {
+			if rm, ok := r.(map[string]any); ok {
+				responses[code] = c.response(rm, produces)
+			} else {
+				responses[code] = r
+			}
+		}
+	}
+	out["responses"] = responses
+
+	return out
+}
+
+// securityScheme converts a security definition.
+func (c *swaggerConverter) securityScheme(s map[string]any) map[string]any {
+	out := map[string]any{}
+	if s["description"] != nil {
+		out["description"] = s["description"]
+	}
+
+	switch s["type"] {
+	case "basic":
+		out["type"] = "http"
+		out["scheme"] = "basic"
+	case "apiKey":
+		out["type"] = "apiKey"
+		out["name"] = s["name"]
+		out["in"] = s["in"]
+	case "oauth2":
+		flow := map[string]any{"scopes": map[string]any{}}
+		if scopes, ok := s["scopes"].(map[string]any); ok {
+			flow["scopes"] = scopes
+		}
+		if s["authorizationUrl"] != nil {
+			flow["authorizationUrl"] = s["authorizationUrl"]
+		}
+		if s["tokenUrl"] != nil {
+			flow["tokenUrl"] = s["tokenUrl"]
+		}
+		name, _ := s["flow"].(string)
+		out["type"] = "oauth2"
+		out["flows"] = map[string]any{swaggerFlows[name]: flow}
+	default:
+		out["type"] = s["type"]
+	}
+
+	for k, v := range s {
+		if strings.HasPrefix(k, "x-") {
+			out[k] = v
+		}
+	}
+
+	return out
+}
+
+// convert the whole document.
+func (c *swaggerConverter) convert() map[string]any {
+	out := map[string]any{"openapi": "3.0.3"}
+	for k, v := range c.doc {
+		switch k {
+		case "swagger", "host", "basePath", "schemes", "consumes", "produces",
+			"definitions", "parameters", "responses", "securityDefinitions", "paths":
+		default:
+			// Info, tags, external docs, security requirements, & extensions.
+			out[k] = v
+		}
+	}
+
+	basePath, _ := c.doc["basePath"].(string)
+	if host, _ := c.doc["host"].(string); host != "" {
+		servers := []any{}
+		for _, scheme := range stringList(c.doc["schemes"], "https") {
+			servers = append(servers, map[string]any{"url": scheme + "://" + host + basePath})
+		}
+		out["servers"] = servers
+	} else if basePath != "" {
+		out["servers"] = []any{map[string]any{"url": basePath}}
+	}
+
+	components := map[string]any{}
+	if defs, ok := c.doc["definitions"].(map[string]any); ok {
+		components["schemas"] = c.schema(defs)
+	}
+	if params, ok := c.doc["parameters"].(map[string]any); ok {
+		converted := map[string]any{}
+		for name, p := range params {
+			// Body & form parameters become part of the request body instead.
+			if pm, ok := p.(map[string]any); ok && pm["in"] != "body" && pm["in"] != "formData" {
+				converted[name] = c.parameter(pm)
+			}
+		}
+		components["parameters"] = converted
+	}
+	if responses, ok := c.doc["responses"].(map[string]any); ok {
+		converted := map[string]any{}
+		for name, r := range responses {
+			if rm, ok := r.(map[string]any); ok {
+				converted[name] = c.response(rm, c.produces)
+			}
+		}
+		components["responses"] = converted
+	}
+	if defs, ok := c.doc["securityDefinitions"].(map[string]any); ok {
+		converted := map[string]any{}
+		for name, s := range defs {
+			if sm, ok := s.(map[string]any); ok {
+				converted[name] = c.securityScheme(sm)
+			}
+		}
+		components["securitySchemes"] = converted
+	}
+	if len(components) > 0 {
+		out["components"] = components
+	}
+
+	paths := map[string]any{}
+	if m, ok := c.doc["paths"].(map[string]any); ok {
+		for uri, item := range m {
+			pathItem, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			pathParams, _ := pathItem["parameters"].([]any)
+			converted := map[string]any{}
+			for k, v := range pathItem {
+				switch {
+				case k == "parameters":
+					params := []any{}
+					for _, p := range pathParams {
+						if pm, ok := p.(map[string]any); ok {
+							if in := c.resolveParam(pm)["in"]; in != "body" && in != "formData" {
+								params = append(params, c.parameter(pm))
+							}
+						}
+					}
+					if len(params) > 0 {
+						converted[k] = params
+					}
+				case swaggerMethods[k]:
+					if op, ok := v.(map[string]any); ok {
+						converted[k] = c.operation(op, pathParams)
+					}
+				default:
+					converted[k] = v
+				}
+			}
+			paths[uri] = converted
+		}
+	}
+	out["paths"] = paths
+
+	return out
+}